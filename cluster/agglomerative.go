@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"sort"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/similarity"
+)
+
+// Agglomerative clusters embs by average linkage: starting from one cluster per embedding, the two clusters
+// with the highest mean similarity between their members are merged until it is below threshold.
+// The number of clusters follows from the threshold. It holds the N×N similarity matrix.
+func Agglomerative(embs []model.Embedding, threshold float32, opts ...Option) (Clustering, error) {
+	vecs, err := similarity.Normalize(embs)
+	if err != nil {
+		return Clustering{}, err
+	}
+	sims, err := similarity.Matrix(vecs)
+	if err != nil {
+		return Clustering{}, err
+	}
+	n := len(vecs)
+	size := make([]int, n)   // members of the active clusters, 0 once merged
+	parent := make([]int, n) // cluster an embedding was merged into
+	nn := make([]int, n)     // most similar active cluster
+	nnSim := make([]float32, n)
+	for i := range size {
+		size[i] = 1
+		parent[i] = i
+	}
+	// neighbor sets the most similar active cluster of cluster i
+	neighbor := func(i int) {
+		nn[i], nnSim[i] = -1, -2
+		for j, s := range sims[i] {
+			if j != i && size[j] > 0 && s > nnSim[i] {
+				nn[i], nnSim[i] = j, s
+			}
+		}
+	}
+	for i := range nn {
+		neighbor(i)
+	}
+	for {
+		a := -1
+		for i := range nn {
+			if size[i] > 0 && nn[i] >= 0 && (a < 0 || nnSim[i] > nnSim[a]) {
+				a = i
+			}
+		}
+		if a < 0 || nnSim[a] < threshold {
+			break
+		}
+		b := nn[a]
+		// the similarity of the merged cluster is the mean of the ones of a and b weighted by their sizes
+		for k := range sims {
+			if size[k] > 0 && k != a && k != b {
+				s := (float32(size[a])*sims[a][k] + float32(size[b])*sims[b][k]) / float32(size[a]+size[b])
+				sims[a][k], sims[k][a] = s, s
+			}
+		}
+		size[a] += size[b]
+		size[b] = 0
+		parent[b] = a
+		neighbor(a)
+		for k := range nn {
+			switch {
+			case size[k] == 0 || k == a:
+			case nn[k] == a || nn[k] == b:
+				neighbor(k)
+			case sims[k][a] > nnSim[k]:
+				nn[k], nnSim[k] = a, sims[k][a]
+			}
+		}
+	}
+	assign := make([]int, n)
+	for i := range assign {
+		root := i
+		for parent[root] != root {
+			root = parent[root]
+		}
+		assign[i] = root
+	}
+	return newClustering(vecs, assign, n, newConfig(opts).minSize), nil
+}
+
+// Communities finds groups of embeddings all within threshold of a center, ex tickets about the same issue.
+// Embeddings with the most neighbors above threshold are centers first, a community is a center and its neighbors
+// that aren't in a community yet. Unlike Agglomerative it doesn't hold the N×N similarity matrix.
+func Communities(embs []model.Embedding, threshold float32, opts ...Option) (Clustering, error) {
+	vecs, err := similarity.Normalize(embs)
+	if err != nil {
+		return Clustering{}, err
+	}
+	pairs, err := similarity.NearDuplicates(vecs, threshold)
+	if err != nil {
+		return Clustering{}, err
+	}
+	minSize := newConfig(opts).minSize
+	neighbors := make([][]int, len(vecs))
+	for i := range neighbors {
+		neighbors[i] = []int{i}
+	}
+	for _, p := range pairs {
+		neighbors[p.I] = append(neighbors[p.I], p.J)
+		neighbors[p.J] = append(neighbors[p.J], p.I)
+	}
+	centers := make([]int, len(vecs))
+	for i := range centers {
+		centers[i] = i
+	}
+	sort.SliceStable(centers, func(a, b int) bool {
+		return len(neighbors[centers[a]]) > len(neighbors[centers[b]])
+	})
+	assign := make([]int, len(vecs))
+	for i := range assign {
+		assign[i] = -1
+	}
+	k := 0
+	for _, c := range centers {
+		if len(neighbors[c]) < minSize {
+			break
+		}
+		if assign[c] >= 0 {
+			continue
+		}
+		var members []int
+		for _, i := range neighbors[c] {
+			if assign[i] < 0 {
+				members = append(members, i)
+			}
+		}
+		if len(members) < minSize {
+			continue
+		}
+		for _, i := range members {
+			assign[i] = k
+		}
+		k++
+	}
+	return newClustering(vecs, assign, k, minSize), nil
+}
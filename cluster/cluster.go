@@ -0,0 +1,194 @@
+// Package cluster groups embeddings of texts by cosine similarity: k-means, agglomerative clustering and
+// community detection, with silhouette scoring of the result and label suggestions for its clusters.
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+	"github.com/sunhailin-Leo/gobert/similarity"
+)
+
+// Defaults of the clusterings
+const (
+	DefaultIterations = 100
+	DefaultMinSize    = 2
+)
+
+// Cluster is a group of embeddings, Members are their indexes by decreasing similarity to the centroid,
+// the most central first. Centroid is the normalized mean of the members.
+type Cluster struct {
+	Members  []int
+	Centroid model.Embedding
+}
+
+// Clustering is the clusters of embeddings by decreasing size, Assignments is the cluster of each embedding,
+// -1 for embeddings in no cluster
+type Clustering struct {
+	Clusters    []Cluster
+	Assignments []int
+}
+
+// config holds the parameters of a clustering
+type config struct {
+	iterations int
+	seed       int64
+	minSize    int
+}
+
+// Option configures a clustering
+type Option func(c *config) *config
+
+// WithIterations sets the max number of k-means iterations, they stop once assignments are stable
+func WithIterations(n int) Option {
+	return func(c *config) *config {
+		c.iterations = n
+		return c
+	}
+}
+
+// WithSeed seeds the choice of the initial k-means centroids
+func WithSeed(seed int64) Option {
+	return func(c *config) *config {
+		c.seed = seed
+		return c
+	}
+}
+
+// WithMinSize sets the min size of the clusters of Agglomerative and Communities, members of smaller ones are
+// left in no cluster. It is DefaultMinSize by default so single embeddings aren't clusters.
+func WithMinSize(n int) Option {
+	return func(c *config) *config {
+		c.minSize = n
+		return c
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{iterations: DefaultIterations, seed: 1, minSize: DefaultMinSize}
+	for _, opt := range opts {
+		c = opt(c)
+	}
+	if c.iterations <= 0 {
+		c.iterations = DefaultIterations
+	}
+	if c.minSize <= 0 {
+		c.minSize = 1
+	}
+	return c
+}
+
+// newClustering builds the clusters of the normalized vecs from their assignments to k groups.
+// Groups smaller than minSize are dropped, clusters are sorted by decreasing size then by their first member.
+func newClustering(vecs []model.Embedding, assign []int, k, minSize int) Clustering {
+	groups := make([][]int, k)
+	for i, g := range assign {
+		if g >= 0 {
+			groups[g] = append(groups[g], i)
+		}
+	}
+	var clusters []Cluster
+	for _, members := range groups {
+		if len(members) == 0 || len(members) < minSize {
+			continue
+		}
+		centroid := mean(vecs, members)
+		sims := make(map[int]float32, len(members))
+		for _, i := range members {
+			sims[i] = search.Dot(centroid, vecs[i])
+		}
+		sort.SliceStable(members, func(a, b int) bool {
+			return sims[members[a]] > sims[members[b]]
+		})
+		clusters = append(clusters, Cluster{Members: members, Centroid: centroid})
+	}
+	sort.SliceStable(clusters, func(a, b int) bool {
+		return len(clusters[a].Members) > len(clusters[b].Members)
+	})
+	c := Clustering{Clusters: clusters, Assignments: make([]int, len(vecs))}
+	for i := range c.Assignments {
+		c.Assignments[i] = -1
+	}
+	for ci, cl := range clusters {
+		for _, i := range cl.Members {
+			c.Assignments[i] = ci
+		}
+	}
+	return c
+}
+
+// mean returns the normalized mean of the vectors of members
+func mean(vecs []model.Embedding, members []int) model.Embedding {
+	m := make(model.Embedding, len(vecs[members[0]]))
+	for _, i := range members {
+		for j, x := range vecs[i] {
+			m[j] += x
+		}
+	}
+	return search.Normalize(m)
+}
+
+// Silhouette returns the mean silhouette of the embeddings in a cluster, from -1 to 1, higher when clusters
+// are dense and well separated. Distances are cosine distances, 1 - cosine similarity.
+// The silhouette of an embedding alone in its cluster is 0, there must be at least 2 clusters.
+func Silhouette(embs []model.Embedding, assignments []int) (float32, error) {
+	if len(assignments) != len(embs) {
+		return 0, fmt.Errorf("mismatched assignment count %d for %d embeddings", len(assignments), len(embs))
+	}
+	vecs, err := similarity.Normalize(embs)
+	if err != nil {
+		return 0, err
+	}
+	k := 0
+	for _, g := range assignments {
+		k = max(k, g+1)
+	}
+	sizes := make([]int, k)
+	for _, g := range assignments {
+		if g >= 0 {
+			sizes[g]++
+		}
+	}
+	clusters := 0
+	for _, n := range sizes {
+		if n > 0 {
+			clusters++
+		}
+	}
+	if clusters < 2 {
+		return 0, fmt.Errorf("silhouette needs at least 2 clusters, got %d", clusters)
+	}
+	var total float64
+	var count int
+	dists := make([]float64, k)
+	for i, gi := range assignments {
+		if gi < 0 {
+			continue
+		}
+		count++
+		if sizes[gi] == 1 {
+			continue
+		}
+		for g := range dists {
+			dists[g] = 0
+		}
+		for j, gj := range assignments {
+			if gj >= 0 && j != i {
+				dists[gj] += float64(1 - search.Dot(vecs[i], vecs[j]))
+			}
+		}
+		a := dists[gi] / float64(sizes[gi]-1)
+		b := -1.0
+		for g, d := range dists {
+			if g != gi && sizes[g] > 0 && (b < 0 || d/float64(sizes[g]) < b) {
+				b = d / float64(sizes[g])
+			}
+		}
+		if m := max(a, b); m > 0 {
+			total += (b - a) / m
+		}
+	}
+	return float32(total / float64(count)), nil
+}
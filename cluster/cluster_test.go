@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+)
+
+// checkBlobs checks the embeddings of each center are in a cluster of their own
+func checkBlobs(t *testing.T, name string, c Clustering, k int) {
+	t.Helper()
+	if len(c.Clusters) != k {
+		t.Fatalf("Invalid %s Cluster Count - Want: %d, Got: %d", name, k, len(c.Clusters))
+	}
+	for i, g := range c.Assignments {
+		if g != c.Assignments[i%k] || g < 0 {
+			t.Fatalf("Invalid %s Assignment of %d - Want: %d, Got: %d", name, i, c.Assignments[i%k], g)
+		}
+	}
+}
+
+func TestClusterings(t *testing.T) {
+	embs := modeltest.Embeddings(1, 100, 16, 4, 0.2)
+	c, err := KMeans(embs, 4, WithSeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBlobs(t, "KMeans", c, 4)
+	for _, cl := range c.Clusters {
+		if len(cl.Members) != 25 || len(cl.Centroid) != 16 {
+			t.Errorf("Invalid Cluster - Got: %d members, %d dimensions", len(cl.Members), len(cl.Centroid))
+		}
+	}
+	s, err := Silhouette(embs, c.Assignments)
+	if err != nil || s < 0.7 {
+		t.Errorf("Invalid Silhouette - Want: >= 0.7, Got: %v %v", s, err)
+	}
+	c, err = Agglomerative(embs, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBlobs(t, "Agglomerative", c, 4)
+	c, err = Communities(embs, 0.7, WithMinSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBlobs(t, "Communities", c, 4)
+	c, _ = KMeans(embs, 2, WithSeed(2))
+	if worse, _ := Silhouette(embs, c.Assignments); worse >= s {
+		t.Errorf("Invalid Silhouette of 2 clusters - Want: < %v, Got: %v", s, worse)
+	}
+	if _, err := KMeans(embs, len(embs)+1); err == nil {
+		t.Errorf("Invalid Cluster Count - Want: error")
+	}
+}
+
+func TestAgglomerativeMinSize(t *testing.T) {
+	embs := []model.Embedding{{1, 0}, {0.9, 0.1}, {0, 1}, {-1, 0.1}, {0.1, 0.9}, {1, 0.05}}
+	c, err := Agglomerative(embs, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 0, 1, -1, 1, 0}; !reflect.DeepEqual(c.Assignments, want) {
+		t.Errorf("Invalid Assignments - Want: %v, Got: %v", want, c.Assignments)
+	}
+	if want := []int{5, 0, 1}; !reflect.DeepEqual(c.Clusters[0].Members, want) {
+		t.Errorf("Invalid Central Members - Want: %v, Got: %v", want, c.Clusters[0].Members)
+	}
+	c, _ = Agglomerative(embs, 0.9, WithMinSize(1))
+	if len(c.Clusters) != 3 || c.Assignments[3] != 2 {
+		t.Errorf("Invalid Singleton Cluster - Got: %v", c.Assignments)
+	}
+}
+
+func TestSilhouette(t *testing.T) {
+	embs := []model.Embedding{{1, 0}, {1, 0}, {0, 1}, {0, 1}, {-1, 0}}
+	s, err := Silhouette(embs, []int{0, 0, 1, 1, -1})
+	if err != nil || s != 1 {
+		t.Errorf("Invalid Silhouette - Want: 1, Got: %v %v", s, err)
+	}
+	// the silhouette of the embedding alone in cluster 2 is 0
+	s, _ = Silhouette(embs, []int{0, 0, 1, 1, 2})
+	if s != 0.8 {
+		t.Errorf("Invalid Silhouette - Want: 0.8, Got: %v", s)
+	}
+	if _, err := Silhouette(embs, []int{0, 0, 0, 0, -1}); err == nil {
+		t.Errorf("Invalid Single Cluster - Want: error")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	texts := []string{
+		"I can't reset my password",
+		"the app crashes on startup",
+		"password reset email never arrives",
+		"the app crashes when I open it",
+		"how do I reset a forgotten password?",
+		"I was charged twice for my subscription",
+		"refund a double charge on my subscription",
+		"!!!",
+	}
+	c := Clustering{Clusters: []Cluster{{Members: []int{4, 0, 2}}, {Members: []int{1, 3}}, {Members: []int{5, 6}}, {Members: []int{7}}}}
+	want := []string{"password reset", "app crashes", "subscription my", "!!!"}
+	if got, err := Labels(texts, c, 2, 2); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid Labels - Want: %v, Got: %v %v", want, got, err)
+	}
+	want = []string{"password reset i", "app crashes the", "subscription my", "!!!"}
+	if got, err := Labels(texts, c, 0, 0); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid Default Labels - Want: %v, Got: %v %v", want, got, err)
+	}
+	if _, err := Labels(texts[:7], c, 0, 0); err == nil {
+		t.Errorf("Invalid Error - Want: member out of the texts, Got: %v", err)
+	}
+}
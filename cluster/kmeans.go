@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+	"github.com/sunhailin-Leo/gobert/similarity"
+)
+
+// KMeans partitions embs into k clusters by spherical k-means: embeddings are assigned to the centroid of
+// highest cosine similarity and centroids are the normalized means of their members.
+// Initial centroids are chosen by k-means++, each with a probability proportional to its squared distance
+// to the nearest one already chosen. Every embedding is in a cluster, WithMinSize is ignored.
+func KMeans(embs []model.Embedding, k int, opts ...Option) (Clustering, error) {
+	if k <= 0 || k > len(embs) {
+		return Clustering{}, fmt.Errorf("invalid cluster count %d for %d embeddings", k, len(embs))
+	}
+	vecs, err := similarity.Normalize(embs)
+	if err != nil {
+		return Clustering{}, err
+	}
+	c := newConfig(opts)
+	centroids := seeds(vecs, k, rand.New(rand.NewSource(c.seed)))
+	assign := make([]int, len(vecs))
+	for i := range assign {
+		assign[i] = -1
+	}
+	for it := 0; it < c.iterations; it++ {
+		changed := false
+		for i, v := range vecs {
+			if g := nearest(v, centroids); g != assign[i] {
+				assign[i] = g
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		groups := make([][]int, k)
+		for i, g := range assign {
+			groups[g] = append(groups[g], i)
+		}
+		for g, members := range groups {
+			if len(members) > 0 {
+				centroids[g] = mean(vecs, members)
+				continue
+			}
+			// an empty cluster takes the embedding farthest from its centroid
+			far, low := 0, float32(2)
+			for i, v := range vecs {
+				if s := search.Dot(v, centroids[assign[i]]); s < low {
+					far, low = i, s
+				}
+			}
+			centroids[g] = vecs[far]
+			assign[far] = g
+		}
+	}
+	return newClustering(vecs, assign, k, 1), nil
+}
+
+// seeds chooses k centroids among vecs by k-means++
+func seeds(vecs []model.Embedding, k int, rng *rand.Rand) []model.Embedding {
+	centroids := []model.Embedding{vecs[rng.Intn(len(vecs))]}
+	dists := make([]float64, len(vecs))
+	for i, v := range vecs {
+		dists[i] = distance(v, centroids[0])
+	}
+	for len(centroids) < k {
+		var total float64
+		for _, d := range dists {
+			total += d
+		}
+		next := rng.Intn(len(vecs)) // when every vector is a centroid already
+		if total > 0 {
+			r := rng.Float64() * total
+			for i, d := range dists {
+				if d == 0 {
+					continue
+				}
+				next = i
+				if r -= d; r <= 0 {
+					break
+				}
+			}
+		}
+		centroids = append(centroids, vecs[next])
+		for i, v := range vecs {
+			dists[i] = min(dists[i], distance(v, vecs[next]))
+		}
+	}
+	return centroids
+}
+
+// distance is the squared euclidean distance of unit vectors, 2 - 2 * their cosine similarity
+func distance(x, y model.Embedding) float64 {
+	return max(0, 2-2*float64(search.Dot(x, y)))
+}
+
+// nearest returns the index of the centroid most similar to v
+func nearest(v model.Embedding, centroids []model.Embedding) int {
+	best, sim := 0, float32(-2)
+	for g, c := range centroids {
+		if s := search.Dot(v, c); s > sim {
+			best, sim = g, s
+		}
+	}
+	return best
+}
@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Defaults of the label suggestions
+const (
+	DefaultCentral    = 5
+	DefaultLabelTerms = 3
+)
+
+// Labels suggests a label for each cluster of c from the terms shared by more than half of its most central texts,
+// the first central members of the cluster. Terms found in more of them come first, then the rarest in texts,
+// the texts of the embeddings. A label is its top terms joined by spaces, or the most central text when none
+// are shared. It fails if a member of a cluster has no text.
+func Labels(texts []string, c Clustering, central, terms int) ([]string, error) {
+	if len(c.Assignments) > len(texts) {
+		return nil, fmt.Errorf("%d texts for %d assignments", len(texts), len(c.Assignments))
+	}
+	for _, cl := range c.Clusters {
+		for _, i := range cl.Members {
+			if i < 0 || i >= len(texts) {
+				return nil, fmt.Errorf("member %d of a cluster out of the %d texts", i, len(texts))
+			}
+		}
+	}
+	if central <= 0 {
+		central = DefaultCentral
+	}
+	if terms <= 0 {
+		terms = DefaultLabelTerms
+	}
+	tkz := tokenize.NewBasic()
+	docTerms := make([][]string, len(texts))
+	df := map[string]int{}
+	for i, text := range texts {
+		seen := map[string]bool{}
+		for _, t := range tkz.Tokenize(text) {
+			if !isWord(t) {
+				continue
+			}
+			docTerms[i] = append(docTerms[i], t)
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+	labels := make([]string, len(c.Clusters))
+	for ci, cl := range c.Clusters {
+		members := cl.Members[:min(central, len(cl.Members))]
+		found := map[string]int{}
+		for _, i := range members {
+			seen := map[string]bool{}
+			for _, t := range docTerms[i] {
+				if !seen[t] {
+					seen[t] = true
+					found[t]++
+				}
+			}
+		}
+		var ranked []string
+		for t, n := range found {
+			if n > len(members)/2 {
+				ranked = append(ranked, t)
+			}
+		}
+		idf := func(t string) float64 {
+			return math.Log(float64(len(texts)) / float64(df[t]))
+		}
+		sort.Slice(ranked, func(a, b int) bool {
+			ta, tb := ranked[a], ranked[b]
+			switch {
+			case found[ta] != found[tb]:
+				return found[ta] > found[tb]
+			case idf(ta) != idf(tb):
+				return idf(ta) > idf(tb)
+			}
+			return ta < tb
+		})
+		if len(ranked) == 0 {
+			labels[ci] = texts[cl.Members[0]]
+			continue
+		}
+		labels[ci] = strings.Join(ranked[:min(terms, len(ranked))], " ")
+	}
+	return labels, nil
+}
+
+// isWord reports whether the token t has a letter or a digit
+func isWord(t string) bool {
+	for _, r := range t {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// Package main serves the models of a registry config over HTTP, with Prometheus metrics on /metrics
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sunhailin-Leo/gobert/metrics"
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Using a convention for this project that _* is a cmdline arg
+var (
+	_addr            string
+	_grpcAddr        string
+	_configPath      string
+	_maxTexts        int
+	_shutdownTimeout time.Duration
+	_drainDelay      time.Duration
+	_readTimeout     time.Duration
+	_writeTimeout    time.Duration
+	_logLevel        slog.Level
+	_otlpURL         string
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gobert-server:\nArgs: CONFIGPATH\n")
+		flag.PrintDefaults()
+	}
+	flag.StringVar(&_addr, "addr", ":8080", "Address to listen on")
+	flag.StringVar(&_grpcAddr, "grpc-addr", "", "Address to serve gRPC on, disabled if empty")
+	flag.IntVar(&_maxTexts, "max-texts", server.DefaultMaxTexts, "Max number of texts in a request")
+	flag.DurationVar(&_shutdownTimeout, "shutdown-timeout", 30*time.Second, "Time to drain requests on shutdown")
+	flag.DurationVar(&_drainDelay, "drain-delay", 5*time.Second, "Time to keep serving once not ready on shutdown, so load balancers stop routing requests")
+	flag.DurationVar(&_readTimeout, "read-timeout", 30*time.Second, "Max time to read an HTTP request")
+	flag.DurationVar(&_writeTimeout, "write-timeout", 60*time.Second, "Max time to handle an HTTP request and write its response")
+	flag.StringVar(&_otlpURL, "otlp-url", "", "OTLP/HTTP endpoint to export traces to, ex http://localhost:4318, disabled if empty")
+	flag.TextVar(&_logLevel, "log-level", slog.LevelInfo, "Log level, debug logs every predicted batch")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) != 1 {
+		exit("Error: Incorrect args, requires exactly 1 - ", args)
+	}
+	_configPath = args[0]
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves the models until a signal or a serving error and returns the exit code, deferred cleanups run before exiting
+func run() int {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: _logLevel}))
+	met, err := metrics.New(prometheus.DefaultRegisterer)
+	if err != nil {
+		return fail("Error:", err)
+	}
+	opts := []model.BertOption{model.WithLogger(logger), model.WithObserver(met)}
+	sopts := []server.Option{server.WithMaxTexts(_maxTexts), server.WithLogger(logger)}
+	if _otlpURL != "" {
+		tp, err := tracerProvider(context.Background(), _otlpURL)
+		if err != nil {
+			return fail("Error:", err)
+		}
+		defer tp.Shutdown(context.Background()) // flushes pending spans
+		opts = append(opts, model.WithTracerProvider(tp))
+		sopts = append(sopts, server.WithTracerProvider(tp))
+	}
+	reg := model.NewRegistry(model.WithWrapper(met.WrapCache))
+	if err := reg.LoadFile(_configPath, opts...); err != nil {
+		reg.Close()
+		return fail("Error:", err)
+	}
+	srv := server.New(reg, sopts...)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/", srv)
+	hs := &http.Server{
+		Addr:              _addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       _readTimeout,
+		WriteTimeout:      _writeTimeout,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 2)
+	go func() {
+		errs <- hs.ListenAndServe()
+	}()
+	logger.Info("serving HTTP", slog.Int("models", len(reg.Entries())), slog.String("addr", _addr))
+	var gs *grpc.Server
+	hc := health.NewServer()
+	if _grpcAddr != "" {
+		lis, err := net.Listen("tcp", _grpcAddr)
+		if err != nil {
+			return fail("Error:", err)
+		}
+		gs = grpc.NewServer(srv.GRPCServerOptions()...)
+		srv.RegisterGRPC(gs)
+		grpc_health_v1.RegisterHealthServer(gs, hc)
+		go func() {
+			errs <- gs.Serve(lis)
+		}()
+		logger.Info("serving gRPC", slog.String("addr", _grpcAddr))
+	}
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		logger.Info("shutting down")
+		srv.SetReady(false)
+		hc.Shutdown()
+		time.Sleep(_drainDelay) // readiness probes see the server isn't ready while it still serves
+		sctx, cancel := context.WithTimeout(context.Background(), _shutdownTimeout)
+		defer cancel()
+		if gs != nil {
+			go func() {
+				<-sctx.Done()
+				gs.Stop() // cut off streams still open at the deadline
+			}()
+			gs.GracefulStop()
+		}
+		err = hs.Shutdown(sctx)
+	}
+	if cerr := reg.Close(); cerr != nil {
+		logger.Error("closing models", slog.Any("error", cerr))
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("serving", slog.Any("error", err))
+		return 1
+	}
+	return 0
+}
+
+// tracerProvider exports spans in batches to the OTLP/HTTP collector at url
+func tracerProvider(ctx context.Context, url string) (*sdktrace.TracerProvider, error) {
+	exp, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(url))
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "gobert-server"))),
+	), nil
+}
+
+func exit(msgs ...interface{}) {
+	os.Exit(fail(msgs...))
+}
+
+// fail prints the usage and msgs and returns the exit code of an error
+func fail(msgs ...interface{}) int {
+	flag.Usage()
+	fmt.Fprintln(os.Stderr, msgs...)
+	return 1
+}
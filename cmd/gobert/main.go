@@ -0,0 +1,184 @@
+// Package main is the gobert command line, its eval command evaluates an exported classifier on a labelled dataset
+// and its calibrate command fits the calibration of its probabilities
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/sunhailin-Leo/gobert/eval"
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+const usage = `Usage of gobert:
+  gobert eval [flags] MODELPATH DATASET
+    Evaluates a classifier exported by export_classifier on a .csv, .tsv or .jsonl dataset
+    with a label column and a text, or text_a and text_b, column
+  gobert calibrate [flags] MODELPATH DATASET
+    Fits the calibration of the probabilities of a classifier on a held-out dataset and saves it
+    to the export dir, where it is applied by model.NewBertClassifier
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "eval":
+		evalCmd(os.Args[2:])
+	case "calibrate":
+		calibrateCmd(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// classifierFlags are the flags of the commands loading a classifier and a dataset
+type classifierFlags struct {
+	fs        *flag.FlagSet
+	labels    string
+	vocabPath string
+	seqLen    int
+	batch     int
+	bins      int
+}
+
+func newClassifierFlags(name string) *classifierFlags {
+	f := &classifierFlags{fs: flag.NewFlagSet(name, flag.ExitOnError)}
+	f.fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gobert %s:\nArgs: MODELPATH DATASET\n", name)
+		f.fs.PrintDefaults()
+	}
+	f.fs.StringVar(&f.labels, "labels", "", "Comma separated class names in the order of the model outputs, they are named by index if empty")
+	f.fs.StringVar(&f.vocabPath, "vocab", "", "Path to the vocab, read from the export dir if empty")
+	f.fs.IntVar(&f.seqLen, "seqlen", model.ClassifierSeqLen, "Sequence length the model was exported with")
+	f.fs.IntVar(&f.batch, "batch", eval.DefaultBatchSize, "Number of examples predicted together")
+	f.fs.IntVar(&f.bins, "bins", eval.DefaultBins, "Number of confidence bins of the calibration error")
+	return f
+}
+
+// parse parses args, requiring the model path and dataset
+func (f *classifierFlags) parse(args []string) {
+	f.fs.Parse(args)
+	if f.fs.NArg() != 2 {
+		exit(f.fs, "Error: Incorrect args, requires exactly 2 - ", f.fs.Args())
+	}
+}
+
+// load reads the dataset and the classifier, returning an Evaluator of it
+func (f *classifierFlags) load(opts ...model.BertOption) (*eval.Evaluator, []eval.Example) {
+	examples, err := eval.ReadFile(f.fs.Arg(1))
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	m, err := model.NewBertClassifier(f.fs.Arg(0), f.vocabPath, append(opts, model.WithSeqLen(int32(f.seqLen)))...)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	eopts := []eval.Option{eval.WithBatchSize(f.batch), eval.WithBins(f.bins)}
+	if f.labels != "" {
+		eopts = append(eopts, eval.WithClasses(strings.Split(f.labels, ",")...))
+	}
+	return eval.New(m, eopts...), examples
+}
+
+func evalCmd(args []string) {
+	f := newClassifierFlags("eval")
+	format := f.fs.String("format", "text", "Output format, text or json")
+	uncalibrated := f.fs.Bool("uncalibrated", false, "Evaluate the probabilities of the model without its calibration")
+	f.parse(args)
+	if *format != "text" && *format != "json" {
+		exit(f.fs, "Error: unknown format", *format)
+	}
+	var opts []model.BertOption
+	if *uncalibrated {
+		opts = append(opts, model.WithCalibration(nil))
+	}
+	e, examples := f.load(opts...)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	r, err := e.Run(ctx, examples)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	if *format == "json" {
+		err = r.WriteJSON(os.Stdout)
+	} else {
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+}
+
+func calibrateCmd(args []string) {
+	f := newClassifierFlags("calibrate")
+	method := f.fs.String("method", model.TemperatureScaling, "Calibration method, temperature or isotonic")
+	out := f.fs.String("out", "", "Path to save the calibration to, "+model.CalibrationFile+" in the export dir if empty")
+	f.parse(args)
+	fit := map[string]func([][]float32, []int) (*model.Calibration, error){
+		model.TemperatureScaling: model.FitTemperature,
+		model.IsotonicRegression: model.FitIsotonic,
+	}[*method]
+	if fit == nil {
+		exit(f.fs, "Error: unknown method", *method)
+	}
+	if *out == "" {
+		dir, err := model.ExportDir(f.fs.Arg(0))
+		if err != nil {
+			exit(f.fs, "Error:", err)
+		}
+		*out = filepath.Join(dir, model.CalibrationFile)
+	}
+	e, examples := f.load(model.WithCalibration(nil)) // fitted on the raw probabilities
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	texts := make([]string, len(examples))
+	for i, ex := range examples {
+		texts[i] = ex.Text
+	}
+	probs, err := e.Predict(ctx, texts...)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	classes := e.Classes(probs)
+	labels, err := eval.Labels(examples, classes)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	c, err := fit(probs, labels)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	before, err := eval.Compute(classes, probs, labels, f.bins)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	after, err := eval.Compute(classes, c.CalibrateAll(probs), labels, f.bins)
+	if err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	if err := c.SaveFile(*out); err != nil {
+		exit(f.fs, "Error:", err)
+	}
+	fmt.Printf("%s calibration saved to %s\n", c.Method, *out)
+	if c.Method == model.TemperatureScaling {
+		fmt.Printf("temperature %.4f\n", c.Temperature)
+	}
+	fmt.Printf("on the dataset, ece %.4f -> %.4f, accuracy %.4f -> %.4f\n", before.ECE, after.ECE, before.Accuracy, after.Accuracy)
+}
+
+func exit(fs *flag.FlagSet, msgs ...interface{}) {
+	fs.Usage()
+	fmt.Fprintln(os.Stderr, msgs...)
+	os.Exit(1)
+}
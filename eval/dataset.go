@@ -0,0 +1,140 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Example is a labelled text, Label is the name or the index of its class.
+// The sentences of a pair are joined by tokenize.SequenceSeparator.
+type Example struct {
+	Text  string
+	Label string
+}
+
+// ReadFile reads a dataset by its extension: .csv, .tsv or .jsonl
+func ReadFile(path string) ([]Example, error) {
+	var read func(r io.Reader) ([]Example, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		read = func(r io.Reader) ([]Example, error) { return ReadCSV(r, ',') }
+	case ".tsv":
+		read = func(r io.Reader) ([]Example, error) { return ReadCSV(r, '\t') }
+	case ".jsonl":
+		read = ReadJSONL
+	default:
+		return nil, fmt.Errorf("unknown dataset format %q, expected .csv, .tsv or .jsonl", filepath.Ext(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// ReadCSV reads a dataset with a header naming its label column "label" and its text column "text" or "text_a".
+// The text of an optional "text_b" column is the second sentence of a pair.
+func ReadCSV(r io.Reader, comma rune) ([]Example, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	text, ok := col["text"]
+	if !ok {
+		text, ok = col["text_a"]
+	}
+	label, hasLabel := col["label"]
+	if !ok || !hasLabel {
+		return nil, fmt.Errorf("dataset header %v needs text and label columns", header)
+	}
+	textB, pair := col["text_b"]
+	var examples []Example
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return examples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := Example{Text: rec[text], Label: rec[label]}
+		if pair {
+			e.Text += tokenize.SequenceSeparator + rec[textB]
+		}
+		examples = append(examples, e)
+	}
+}
+
+// ReadJSONL reads a dataset of a JSON object per line with the fields of ReadCSV, labels can be strings or numbers
+func ReadJSONL(r io.Reader) ([]Example, error) {
+	var examples []Example
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<24)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		var rec struct {
+			Text  string          `json:"text"`
+			TextA string          `json:"text_a"`
+			TextB *string         `json:"text_b"`
+			Label json.RawMessage `json:"label"`
+		}
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		e := Example{Text: rec.Text}
+		if e.Text == "" {
+			e.Text = rec.TextA
+		}
+		if rec.TextB != nil {
+			e.Text += tokenize.SequenceSeparator + *rec.TextB
+		}
+		if err := json.Unmarshal(rec.Label, &e.Label); err != nil {
+			var n json.Number
+			if err := json.Unmarshal(rec.Label, &n); err != nil {
+				return nil, fmt.Errorf("line %d: label %s is neither a string nor a number", line, rec.Label)
+			}
+			e.Label = n.String()
+		}
+		examples = append(examples, e)
+	}
+	return examples, s.Err()
+}
+
+// Labels returns the class index of the label of each example, a label is the name of a class or its index
+func Labels(examples []Example, classes []string) ([]int, error) {
+	index := make(map[string]int, len(classes))
+	for c, name := range classes {
+		index[name] = c
+	}
+	labels := make([]int, len(examples))
+	for i, e := range examples {
+		c, ok := index[e.Label]
+		if !ok {
+			n, err := strconv.Atoi(e.Label)
+			if err != nil || n < 0 || n >= len(classes) {
+				return nil, fmt.Errorf("example %d: unknown label %q, classes are %v", i, e.Label, classes)
+			}
+			c = n
+		}
+		labels[i] = c
+	}
+	return labels, nil
+}
@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+func TestReadCSV(t *testing.T) {
+	examples, err := ReadCSV(strings.NewReader("label,text\npos,\"good, really\"\nneg,bad\n"), ',')
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	want := []Example{{Text: "good, really", Label: "pos"}, {Text: "bad", Label: "neg"}}
+	if !reflect.DeepEqual(examples, want) {
+		t.Errorf("Invalid Examples - Want: %v, Got: %v", want, examples)
+	}
+
+	examples, err = ReadCSV(strings.NewReader("text_a\ttext_b\tlabel\na cat\ta pet\t1\n"), '\t')
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	want = []Example{{Text: "a cat" + tokenize.SequenceSeparator + "a pet", Label: "1"}}
+	if !reflect.DeepEqual(examples, want) {
+		t.Errorf("Invalid Examples - Want: %v, Got: %v", want, examples)
+	}
+
+	if _, err := ReadCSV(strings.NewReader("sentence,class\na,b\n"), ','); err == nil {
+		t.Errorf("Invalid Error - Want: missing columns, Got: %v", err)
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	data := `{"text": "good", "label": "pos"}
+
+{"text_a": "a cat", "text_b": "a pet", "label": 1}
+`
+	examples, err := ReadJSONL(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	want := []Example{{Text: "good", Label: "pos"}, {Text: "a cat" + tokenize.SequenceSeparator + "a pet", Label: "1"}}
+	if !reflect.DeepEqual(examples, want) {
+		t.Errorf("Invalid Examples - Want: %v, Got: %v", want, examples)
+	}
+	if _, err := ReadJSONL(strings.NewReader(`{"text": "a", "label": [1]}`)); err == nil {
+		t.Errorf("Invalid Error - Want: invalid label, Got: %v", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dev.tsv")
+	if err := os.WriteFile(path, []byte("text\tlabel\nbad\tneg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	examples, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if want := []Example{{Text: "bad", Label: "neg"}}; !reflect.DeepEqual(examples, want) {
+		t.Errorf("Invalid Examples - Want: %v, Got: %v", want, examples)
+	}
+	if _, err := ReadFile(filepath.Join(dir, "dev.txt")); err == nil {
+		t.Errorf("Invalid Error - Want: unknown format, Got: %v", err)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	examples := []Example{{Label: "pos"}, {Label: "0"}, {Label: "neg"}}
+	labels, err := Labels(examples, []string{"neg", "pos"})
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if want := []int{1, 0, 0}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("Invalid Labels - Want: %v, Got: %v", want, labels)
+	}
+	if _, err := Labels([]Example{{Label: "2"}}, []string{"neg", "pos"}); err == nil {
+		t.Errorf("Invalid Error - Want: unknown label, Got: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package eval evaluates classifiers at inference time on labelled datasets: accuracy, per class
+// precision, recall and F1, a confusion matrix, ROC-AUC and the expected calibration error.
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/estimator"
+)
+
+// DefaultBatchSize is the number of examples predicted together
+const DefaultBatchSize = 32
+
+// Evaluator runs a classifier, ex from model.NewBertClassifier, on labelled examples and reports its scores.
+// The classifier outputs the probability of each class.
+type Evaluator struct {
+	m       model.Predictor
+	classes []string
+	batch   int
+	bins    int
+}
+
+var _ estimator.Evaluator = (*Evaluator)(nil)
+
+// Option configures an Evaluator
+type Option func(e *Evaluator) *Evaluator
+
+// WithClasses names the classes in the order of the outputs of the classifier, they are named by their index by default
+func WithClasses(classes ...string) Option {
+	return func(e *Evaluator) *Evaluator {
+		e.classes = classes
+		return e
+	}
+}
+
+// WithBatchSize sets the number of examples predicted together
+func WithBatchSize(n int) Option {
+	return func(e *Evaluator) *Evaluator {
+		e.batch = n
+		return e
+	}
+}
+
+// WithBins sets the number of confidence bins of the calibration error, DefaultBins by default
+func WithBins(n int) Option {
+	return func(e *Evaluator) *Evaluator {
+		e.bins = n
+		return e
+	}
+}
+
+// New returns an Evaluator of m, a classifier
+func New(m model.Predictor, opts ...Option) *Evaluator {
+	e := &Evaluator{m: m, batch: DefaultBatchSize, bins: DefaultBins}
+	for _, opt := range opts {
+		e = opt(e)
+	}
+	if e.batch <= 0 {
+		e.batch = DefaultBatchSize
+	}
+	return e
+}
+
+// Run predicts the examples in batches and reports the scores of the classifier
+func (e *Evaluator) Run(ctx context.Context, examples []Example) (*Report, error) {
+	texts := make([]string, len(examples))
+	for i, ex := range examples {
+		texts[i] = ex.Text
+	}
+	probs, err := e.Predict(ctx, texts...)
+	if err != nil {
+		return nil, err
+	}
+	classes := e.Classes(probs)
+	labels, err := Labels(examples, classes)
+	if err != nil {
+		return nil, err
+	}
+	return Compute(classes, probs, labels, e.bins)
+}
+
+// Evaluate implements estimator.Evaluator, labels are class indexes
+func (e *Evaluator) Evaluate(ctx context.Context, inputs []string, labels []int) (map[string]float64, error) {
+	probs, err := e.Predict(ctx, inputs...)
+	if err != nil {
+		return nil, err
+	}
+	r, err := Compute(e.Classes(probs), probs, labels, e.bins)
+	if err != nil {
+		return nil, err
+	}
+	return r.Metrics(), nil
+}
+
+// Predict returns the class probabilities of texts, predicted in batches
+func (e *Evaluator) Predict(ctx context.Context, texts ...string) ([][]float32, error) {
+	probs := make([][]float32, 0, len(texts))
+	for from := 0; from < len(texts); from += e.batch {
+		to := min(from+e.batch, len(texts))
+		vals, err := e.m.PredictValuesContext(ctx, texts[from:to]...)
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("model returned no outputs")
+		}
+		batch, ok := vals[0].Value().([][]float32)
+		if !ok {
+			return nil, fmt.Errorf("expected class probabilities [][]float32, got %T", vals[0].Value())
+		}
+		if len(batch) != to-from {
+			return nil, fmt.Errorf("mismatched prediction count %d for %d texts", len(batch), to-from)
+		}
+		probs = append(probs, batch...)
+	}
+	return probs, nil
+}
+
+// Classes returns the names of the classes set by WithClasses, or the indexes of the classes of probs
+func (e *Evaluator) Classes(probs [][]float32) []string {
+	if len(e.classes) > 0 || len(probs) == 0 {
+		return e.classes
+	}
+	classes := make([]string, len(probs[0]))
+	for c := range classes {
+		classes[c] = strconv.Itoa(c)
+	}
+	return classes
+}
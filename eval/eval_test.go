@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+)
+
+// sentiment predicts texts with "good" as positive
+func sentiment() *modeltest.Predictor {
+	return modeltest.New(func(texts []string) interface{} {
+		probs := make([][]float32, len(texts))
+		for i, text := range texts {
+			probs[i] = []float32{0.75, 0.25}
+			if strings.Contains(text, "good") {
+				probs[i] = []float32{0.25, 0.75}
+			}
+		}
+		return probs
+	})
+}
+
+func near(x, y float64) bool {
+	return math.Abs(x-y) < 1e-6
+}
+
+func TestCompute(t *testing.T) {
+	probs := [][]float32{{0.875, 0.125}, {0.75, 0.25}, {0.375, 0.625}, {0.4375, 0.5625}, {0.25, 0.75}}
+	labels := []int{0, 1, 1, 0, 1}
+	r, err := Compute([]string{"neg", "pos"}, probs, labels, 4)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if r.Examples != 5 || !near(r.Accuracy, 0.6) {
+		t.Errorf("Invalid Accuracy - Want: %v, Got: %v", 0.6, r.Accuracy)
+	}
+	if want := [][]int{{1, 1}, {1, 2}}; !reflect.DeepEqual(r.Confusion, want) {
+		t.Errorf("Invalid Confusion - Want: %v, Got: %v", want, r.Confusion)
+	}
+	want := []ClassReport{
+		{Class: "neg", Support: 2, Precision: 0.5, Recall: 0.5, F1: 0.5, AUC: 5.0 / 6},
+		{Class: "pos", Support: 3, Precision: 2.0 / 3, Recall: 2.0 / 3, F1: 2.0 / 3, AUC: 5.0 / 6},
+	}
+	for c, w := range want {
+		got := r.Classes[c]
+		if got.Class != w.Class || got.Support != w.Support || !near(got.Precision, w.Precision) ||
+			!near(got.Recall, w.Recall) || !near(got.F1, w.F1) || !near(got.AUC, w.AUC) {
+			t.Errorf("Invalid Class - Want: %+v, Got: %+v", w, got)
+		}
+	}
+	if !near(r.MacroF1, (0.5+2.0/3)/2) {
+		t.Errorf("Invalid MacroF1 - Want: %v, Got: %v", (0.5+2.0/3)/2, r.MacroF1)
+	}
+	if !near(r.AUC, 5.0/6) {
+		t.Errorf("Invalid AUC - Want: %v, Got: %v", 5.0/6, r.AUC)
+	}
+	// [0.75, 1]: 3 examples, accuracy 2/3, confidence 0.791667; [0.5, 0.75): 2 examples, accuracy 0.5, confidence 0.59375
+	if !near(r.ECE, 3.0/5*0.125+2.0/5*0.09375) {
+		t.Errorf("Invalid ECE - Want: %v, Got: %v", 0.1125, r.ECE)
+	}
+	if len(r.Calibration) != 4 || r.Calibration[3].Count != 3 || r.Calibration[2].Count != 2 || r.Calibration[0].Count != 0 {
+		t.Errorf("Invalid Calibration - Got: %+v", r.Calibration)
+	}
+	m := r.Metrics()
+	if m["accuracy"] != r.Accuracy || m["f1/pos"] != r.Classes[1].F1 || m["examples"] != 5 {
+		t.Errorf("Invalid Metrics - Got: %v", m)
+	}
+	text := r.String()
+	for _, s := range []string{"accuracy 0.6000", "1 pos", "[0.75, 1.00)"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("Invalid String - Want: %q in, Got: %s", s, text)
+		}
+	}
+}
+
+func TestComputeErrors(t *testing.T) {
+	classes := []string{"a", "b"}
+	if _, err := Compute(classes, [][]float32{{1, 0}}, []int{0, 1}, 0); err == nil {
+		t.Errorf("Invalid Error - Want: mismatched count, Got: %v", err)
+	}
+	if _, err := Compute(classes, [][]float32{{1, 0, 0}}, []int{0}, 0); err == nil {
+		t.Errorf("Invalid Error - Want: mismatched classes, Got: %v", err)
+	}
+	if _, err := Compute(classes, [][]float32{{1, 0}}, []int{2}, 0); err == nil {
+		t.Errorf("Invalid Error - Want: label out of classes, Got: %v", err)
+	}
+}
+
+func TestROCAUC(t *testing.T) {
+	probs := [][]float32{{0.5}, {0.5}, {0.9}, {0.1}}
+	// positives 0.5 and 0.9 against negatives 0.5 and 0.1, the tie counts half
+	if auc, ok := rocAUC(probs, []int{0, 1, 0, 1}, 0); !ok || !near(auc, 3.5/4) {
+		t.Errorf("Invalid AUC - Want: %v, Got: %v", 3.5/4, auc)
+	}
+	if _, ok := rocAUC(probs, []int{0, 0, 0, 0}, 0); ok {
+		t.Errorf("Invalid AUC - Want: undefined, Got: %v", ok)
+	}
+}
+
+func TestEvaluator(t *testing.T) {
+	m := sentiment()
+	e := New(m, WithClasses("neg", "pos"), WithBatchSize(2), WithBins(4))
+	examples := []Example{
+		{Text: "good film", Label: "pos"},
+		{Text: "bad film", Label: "neg"},
+		{Text: "so good", Label: "1"},
+		{Text: "not great", Label: "pos"},
+		{Text: "awful", Label: "0"},
+	}
+	r, err := e.Run(context.Background(), examples)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if m.Calls() != 3 {
+		t.Errorf("Invalid Batches - Want: %v, Got: %v", 3, m.Calls())
+	}
+	if want := [][]int{{2, 0}, {1, 2}}; !reflect.DeepEqual(r.Confusion, want) {
+		t.Errorf("Invalid Confusion - Want: %v, Got: %v", want, r.Confusion)
+	}
+	if !near(r.Accuracy, 0.8) {
+		t.Errorf("Invalid Accuracy - Want: %v, Got: %v", 0.8, r.Accuracy)
+	}
+
+	metrics, err := New(m).Evaluate(context.Background(), []string{"good", "bad"}, []int{1, 0})
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if metrics["accuracy"] != 1 || metrics["recall/1"] != 1 {
+		t.Errorf("Invalid Metrics - Got: %v", metrics)
+	}
+
+	if _, err := e.Run(context.Background(), []Example{{Text: "good", Label: "meh"}}); err == nil {
+		t.Errorf("Invalid Error - Want: unknown label, Got: %v", err)
+	}
+	boom := errors.New("boom")
+	m.SetErr(boom)
+	if _, err := e.Run(context.Background(), examples); err != boom {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", boom, err)
+	}
+}
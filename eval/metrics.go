@@ -0,0 +1,241 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultBins is the number of confidence bins of the calibration error
+const DefaultBins = 10
+
+// ClassReport is the scores of a class, AUC is the one-vs-rest ROC-AUC of its probability.
+// AUC is 0 when the class has no examples or every example is of the class.
+type ClassReport struct {
+	Class     string  `json:"class"`
+	Support   int     `json:"support"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	AUC       float64 `json:"auc"`
+}
+
+// Bin is a confidence bin of a reliability diagram, the examples predicted with a confidence in [Lower, Upper)
+type Bin struct {
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Count      int     `json:"count"`
+	Accuracy   float64 `json:"accuracy"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Report is the evaluation of a classifier on labelled examples.
+// Confusion[i][j] counts the examples of class i predicted as class j.
+// AUC is the ROC-AUC of the positive class of a binary classifier, else the mean one-vs-rest AUC of the classes.
+// ECE is the expected calibration error, the mean gap between confidence and accuracy of the Calibration bins.
+type Report struct {
+	Examples    int           `json:"examples"`
+	Accuracy    float64       `json:"accuracy"`
+	MacroF1     float64       `json:"macro_f1"`
+	AUC         float64       `json:"auc"`
+	ECE         float64       `json:"ece"`
+	Classes     []ClassReport `json:"classes"`
+	Confusion   [][]int       `json:"confusion"`
+	Calibration []Bin         `json:"calibration"`
+}
+
+// Compute evaluates the probabilities of classes predicted for examples against their labels, the indexes of
+// their classes. The predicted class of an example is its most probable one, its probability is the confidence.
+func Compute(classes []string, probs [][]float32, labels []int, bins int) (*Report, error) {
+	if len(probs) != len(labels) {
+		return nil, fmt.Errorf("mismatched prediction count %d for %d labels", len(probs), len(labels))
+	}
+	if bins <= 0 {
+		bins = DefaultBins
+	}
+	n := len(classes)
+	r := &Report{Examples: len(labels), Confusion: make([][]int, n), Classes: make([]ClassReport, n)}
+	for c := range r.Confusion {
+		r.Confusion[c] = make([]int, n)
+	}
+	r.Calibration = make([]Bin, bins)
+	for b := range r.Calibration {
+		r.Calibration[b].Lower = float64(b) / float64(bins)
+		r.Calibration[b].Upper = float64(b+1) / float64(bins)
+	}
+	correct := 0
+	for i, p := range probs {
+		if len(p) != n {
+			return nil, fmt.Errorf("mismatched probability count %d for %d classes", len(p), n)
+		}
+		if labels[i] < 0 || labels[i] >= n {
+			return nil, fmt.Errorf("label %d out of the %d classes", labels[i], n)
+		}
+		pred := argmax(p)
+		r.Confusion[labels[i]][pred]++
+		conf := float64(p[pred])
+		bin := &r.Calibration[min(bins-1, max(0, int(conf*float64(bins))))]
+		bin.Count++
+		bin.Confidence += conf
+		if pred == labels[i] {
+			correct++
+			bin.Accuracy++
+		}
+	}
+	if r.Examples > 0 {
+		r.Accuracy = float64(correct) / float64(r.Examples)
+	}
+	for b := range r.Calibration {
+		bin := &r.Calibration[b]
+		if bin.Count == 0 {
+			continue
+		}
+		bin.Accuracy /= float64(bin.Count)
+		bin.Confidence /= float64(bin.Count)
+		r.ECE += float64(bin.Count) / float64(r.Examples) * math.Abs(bin.Accuracy-bin.Confidence)
+	}
+	var aucs []float64
+	for c, name := range classes {
+		cr := ClassReport{Class: name}
+		predicted := 0
+		for l := range r.Confusion {
+			cr.Support += r.Confusion[c][l]
+			predicted += r.Confusion[l][c]
+		}
+		tp := float64(r.Confusion[c][c])
+		cr.Precision = ratio(tp, float64(predicted))
+		cr.Recall = ratio(tp, float64(cr.Support))
+		cr.F1 = ratio(2*cr.Precision*cr.Recall, cr.Precision+cr.Recall)
+		if auc, ok := rocAUC(probs, labels, c); ok {
+			cr.AUC = auc
+			aucs = append(aucs, auc)
+		}
+		r.MacroF1 += cr.F1 / float64(n)
+		r.Classes[c] = cr
+	}
+	switch {
+	case n == 2:
+		r.AUC = r.Classes[1].AUC
+	case len(aucs) > 0:
+		for _, auc := range aucs {
+			r.AUC += auc / float64(len(aucs))
+		}
+	}
+	return r, nil
+}
+
+// Metrics returns the scores of the report by name, per class scores are named after the class, ex "f1/spam"
+func (r *Report) Metrics() map[string]float64 {
+	m := map[string]float64{
+		"examples": float64(r.Examples),
+		"accuracy": r.Accuracy,
+		"macro_f1": r.MacroF1,
+		"auc":      r.AUC,
+		"ece":      r.ECE,
+	}
+	for _, c := range r.Classes {
+		m["precision/"+c.Class] = c.Precision
+		m["recall/"+c.Class] = c.Recall
+		m["f1/"+c.Class] = c.F1
+		m["auc/"+c.Class] = c.AUC
+	}
+	return m
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report as tables
+func (r *Report) WriteText(w io.Writer) error {
+	_, err := io.WriteString(w, r.String())
+	return err
+}
+
+// String formats the report as tables
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "examples %d  accuracy %.4f  macro f1 %.4f  auc %.4f  ece %.4f\n\n", r.Examples, r.Accuracy, r.MacroF1, r.AUC, r.ECE)
+	width := len("class")
+	for _, c := range r.Classes {
+		width = max(width, len(c.Class))
+	}
+	fmt.Fprintf(&b, "%-*s  %9s  %9s  %9s  %9s  %7s\n", width, "class", "precision", "recall", "f1", "auc", "support")
+	for _, c := range r.Classes {
+		fmt.Fprintf(&b, "%-*s  %9.4f  %9.4f  %9.4f  %9.4f  %7d\n", width, c.Class, c.Precision, c.Recall, c.F1, c.AUC, c.Support)
+	}
+	fmt.Fprintf(&b, "\nconfusion (rows are labels, columns are predictions)\n%-*s", width, "")
+	for c := range r.Classes {
+		fmt.Fprintf(&b, "  %7d", c)
+	}
+	for c, row := range r.Confusion {
+		fmt.Fprintf(&b, "\n%-*s", width, fmt.Sprintf("%d %s", c, r.Classes[c].Class))
+		for _, count := range row {
+			fmt.Fprintf(&b, "  %7d", count)
+		}
+	}
+	b.WriteString("\n\ncalibration\n")
+	for _, bin := range r.Calibration {
+		if bin.Count > 0 {
+			fmt.Fprintf(&b, "[%.2f, %.2f)  count %6d  accuracy %.4f  confidence %.4f\n", bin.Lower, bin.Upper, bin.Count, bin.Accuracy, bin.Confidence)
+		}
+	}
+	return b.String()
+}
+
+// rocAUC returns the ROC-AUC of the probabilities of class c to separate its examples from the others,
+// by the Mann-Whitney U statistic with tied probabilities ranked by their mean rank.
+// It is undefined when there are no examples of c or only examples of c.
+func rocAUC(probs [][]float32, labels []int, c int) (float64, bool) {
+	idx := make([]int, len(probs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		return probs[idx[a]][c] < probs[idx[b]][c]
+	})
+	var pos, neg, rankSum float64
+	for i := 0; i < len(idx); {
+		j := i
+		for j < len(idx) && probs[idx[j]][c] == probs[idx[i]][c] {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // mean of the ranks i+1..j
+		for _, k := range idx[i:j] {
+			if labels[k] == c {
+				pos++
+				rankSum += rank
+			} else {
+				neg++
+			}
+		}
+		i = j
+	}
+	if pos == 0 || neg == 0 {
+		return 0, false
+	}
+	return (rankSum - pos*(pos+1)/2) / (pos * neg), true
+}
+
+func argmax(p []float32) int {
+	best := 0
+	for i, x := range p {
+		if x > p[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func ratio(x, y float64) float64 {
+	if y == 0 {
+		return 0
+	}
+	return x / y
+}
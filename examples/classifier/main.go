@@ -34,6 +34,7 @@ func main() {
 	probs := res[0].Value().([][]float32)
 	for i, text := range texts {
 		pairs := strings.Split(text, " ||| ")
+		// probabilities are calibrated when the export dir has a calibration.json, see gobert calibrate
 		same := probs[i][1]
 		msg := "Unsure"
 		if same > 0.8 {
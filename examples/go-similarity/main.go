@@ -1,12 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/sunhailin-Leo/gobert/model"
-	"gonum.org/v1/gonum/mat"
-	"gonum.org/v1/gonum/stat"
+	"github.com/sunhailin-Leo/gobert/similarity"
 )
 
 func main() {
@@ -23,42 +23,37 @@ func main() {
 		"in 1492 columbus sailed the ocean blue",
 		"champa is coloring some stuff",
 		"patrick took a train across europe",
+		"my dog is very hairy",
 	}
-	for n := 0; n < 5; n++ {
-		fmt.Println("Prediting Vals...")
-		res, err := m.PredictValues(texts...)
-		fmt.Println("Done Predicting.")
-		if err != nil {
-			panic(err)
-		}
-		vals := res[0].Value().([][][]float32)
-		fmt.Println("Pooling...")
-		embs := make([]mat.Vector, len(vals))
-		for s, sent := range vals {
-			vec := pool(sent)
-			embs[s] = vec
-		}
-		fmt.Println("Done Pooling.")
-		for i := 1; i < len(vals); i++ {
-			fmt.Printf("%q, %q -> %v\n", texts[0], texts[i], cosSim(embs[0], embs[i]))
-		}
+	fmt.Println("Predicting Embeddings...")
+	embs, err := model.Embed(context.Background(), m, model.MeanPooling, texts...)
+	if err != nil {
+		panic(err)
 	}
-}
-
-func pool(toks [][]float32) mat.Vector {
-	c := len(toks[0])
-	vec := mat.NewVecDense(c, nil)
-	x := make([]float64, c)
-	for i := range x {
-		for j, tok := range toks {
-			x[j] = float64(tok[i])
+	sims, err := similarity.Matrix(embs)
+	if err != nil {
+		panic(err)
+	}
+	for i := 1; i < len(texts); i++ {
+		fmt.Printf("%q, %q -> %.3f\n", texts[0], texts[i], sims[0][i])
+	}
+	fmt.Println("\nMost Similar Pairs:")
+	pairs, err := similarity.MinePairs(embs, 3)
+	if err != nil {
+		panic(err)
+	}
+	for _, p := range pairs {
+		fmt.Printf("%q, %q -> %.3f\n", texts[p.I], texts[p.J], p.Score)
+	}
+	dups, err := similarity.NearDuplicates(embs, 0.9)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("\nNear Duplicates:")
+	for _, g := range similarity.Groups(len(texts), dups) {
+		for _, i := range g {
+			fmt.Printf("\t%q\n", texts[i])
 		}
-		vec.SetVec(i, stat.Mean(x, nil))
+		fmt.Println()
 	}
-	return vec
-}
-
-func cosSim(x, y mat.Vector) float64 {
-	return (mat.Dot(x, y)) / (mat.Norm(x, 2) * mat.Norm(y, 2))
-
 }
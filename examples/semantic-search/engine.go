@@ -1,119 +1,136 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/sunhailin-Leo/gobert/model"
-	"golang.org/x/sync/errgroup"
-	"gonum.org/v1/gonum/mat"
-	"gonum.org/v1/gonum/stat"
+	"github.com/sunhailin-Leo/gobert/search"
+	"github.com/sunhailin-Leo/gobert/search/embfile"
 )
 
-// engine is a simple semantic search engine for demonstrating using a BERT model
-// It is not exported because it is not meant to be used outside of demonstration purposes
-type engine struct {
-	mod  model.Bert
-	recs []map[string]string
-	vecs []mat.Vector
-}
-
-func newEngine(modelPath string, seqlen int32) (*engine, error) {
+// newEngine loads the model and indexes the records of the CSV.
+// If indexPath is set, embeddings are read from that file and only new records are embedded and appended to it.
+func newEngine(modelPath string, seqlen int32, csvPath string, d rune, indexPath string) (*search.Engine, error) {
 	mod, err := model.NewEmbeddings(modelPath,
 		model.WithSeqLen(seqlen),
+		model.WithConcurrency(_workerCount),
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &engine{
-		mod: mod,
-	}, nil
-}
-
-func (e *engine) loadCSV(csvPath string, d rune) error {
 	recs, err := readCSV(csvPath, d)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	tc := 0
-	texts := make([]string, len(recs))
+	docs := make([]search.Document, len(recs))
 	for i, rec := range recs {
-		texts[i] = rec[TextHeader]
+		docs[i] = search.Document{ID: strconv.Itoa(i), Text: rec[TextHeader], Metadata: rec}
 		tc += len(strings.Split(rec[TextHeader], " "))
 	}
-	log.Println("Average Token Per Text Estimate:", tc/len(texts))
-	bsize := _batch                 // TODO batch from flag
-	type rng struct{ from, to int } // [from, to)
-	ranges := make(chan rng)
-	go func() {
-		var from, to int
-		for from = 0; to < len(texts)-bsize; from = to {
-			to += bsize
-			ranges <- rng{from: from, to: to}
+	if len(docs) > 0 {
+		log.Println("Average Token Per Text Estimate:", tc/len(docs))
+	}
+	opts := []search.Option{search.WithBatchSize(_batch), search.WithWorkers(_workerCount)}
+	if _hybrid {
+		opts = append(opts, search.WithLexicalIndex(search.NewBM25()))
+	}
+	e := search.New(mod, opts...)
+	if indexPath == "" {
+		if err := e.Add(context.Background(), docs...); err != nil {
+			return nil, err
 		}
-		ranges <- rng{from: to, to: len(texts)} // final batch
-		close(ranges)
-	}()
-	vecs := make([]mat.Vector, len(texts))
-	var workers errgroup.Group
-	for i := 0; i < _workerCount; i++ {
-		w := i
-		workers.Go(func() error {
-			for b := range ranges {
-				log.Printf("Worker %d - Predicting Batch Size %d [%d,%d)", w, bsize, b.from, b.to)
-				batch := texts[b.from:b.to]
-				res, err := e.mod.PredictValues(batch...)
-				if err != nil {
-					return err
-				}
-				vals := res[0].Value().([][][]float32)
-				for i, v := range vals {
-					vecs[i+b.from] = meanPool(v)
-				}
-			}
-			return nil
-		})
-	}
-	if err := workers.Wait(); err != nil {
-		return err
+		log.Printf("Encoded %d texts, %+v", len(docs), mod.PoolStats())
+		return e, nil
+	}
+	if err := loadIndex(e, modelPath, seqlen, indexPath, docs); err != nil {
+		return nil, err
 	}
-	e.vecs = append(e.vecs, vecs...)
-	e.recs = append(e.recs, recs...)
-	return nil
+	log.Printf("Loaded %d texts from %s, %+v", e.Len(), indexPath, mod.PoolStats())
+	return e, nil
 }
 
-func (e *engine) search(text string) (map[string]string, float64, error) {
-	res, err := e.mod.PredictValues(text)
+// loadIndex adds the embeddings of the index file to e, then embeds the docs missing from it and appends them.
+// Docs are identified by their row, so a row whose text changed since it was indexed is embedded again.
+func loadIndex(e *search.Engine, modelPath string, seqlen int32, indexPath string, docs []search.Document) error {
+	fp, err := model.Fingerprint(modelPath, "")
+	if err != nil {
+		return err
+	}
+	// the seq len changes the embeddings of long texts
+	h := embfile.Header{Fingerprint: fmt.Sprintf("%s/%d", fp, seqlen), Pooling: model.MeanPooling}
+	f, err := embfile.Open(indexPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// created once the dimension is known
+	case err != nil:
+		return err
+	default:
+		defer f.Close()
+		if err := f.Header().Check(h); err != nil {
+			return err
+		}
+		if err := addCurrent(e, f, docs); err != nil {
+			return err
+		}
+	}
+	var missing []search.Document
+	for _, doc := range docs {
+		if !e.Has(doc.ID) {
+			missing = append(missing, doc)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	vecs, err := e.Embed(context.Background(), missing...)
 	if err != nil {
-		return nil, 0.0, err
-	}
-	qvec := meanPool(res[0].Value().([][][]float32)[0])
-	idx := -1
-	score := 0.0
-	for i, vec := range e.vecs {
-		sim := cosSim(qvec, vec)
-		if sim > score {
-			idx = i
-			score = sim
+		return err
+	}
+	if f == nil {
+		h.Dim = len(vecs[0])
+		if f, err = embfile.Create(indexPath, h); err != nil {
+			return err
 		}
+		defer f.Close()
 	}
-	return e.recs[idx], score, nil
+	if err := f.Append(missing, vecs); err != nil {
+		return err
+	}
+	log.Printf("Encoded %d new texts", len(missing))
+	return e.AddEmbedded(missing, vecs)
 }
 
-// TODO extract this into a reusable package
-func meanPool(toks [][]float32) mat.Vector {
-	c := len(toks[0])
-	vec := mat.NewVecDense(c, nil)
-	x := make([]float64, c)
-	for i := range x {
-		for j, tok := range toks {
-			x[j] = float64(tok[i])
+// addCurrent adds the records of f that are still in docs to e, with the metadata of the CSV.
+// Records of rows that were removed or whose text changed are skipped, they stay in the file as it is append only.
+func addCurrent(e *search.Engine, f *embfile.File, docs []search.Document) error {
+	byID := make(map[string]search.Document, len(docs))
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+	var current []search.Document
+	var vecs []model.Embedding
+	for i := 0; i < f.Len(); i++ {
+		rec, err := f.Document(i)
+		if err != nil {
+			return err
+		}
+		doc, ok := byID[rec.ID]
+		if !ok || doc.Text != rec.Text {
+			continue
 		}
-		vec.SetVec(i, stat.Mean(x, nil))
+		delete(byID, rec.ID) // a row whose text changed back has several matching records
+		current = append(current, doc)
+		vecs = append(vecs, f.Vector(i))
 	}
-	return vec
-}
-
-func cosSim(x, y mat.Vector) float64 {
-	return (mat.Dot(x, y)) / (mat.Norm(x, 2) * mat.Norm(y, 2))
+	if stale := f.Len() - len(current); stale > 0 {
+		log.Printf("Skipped %d stale records of the index", stale)
+	}
+	return e.AddEmbedded(current, vecs)
 }
@@ -3,11 +3,15 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
 )
 
 // ExitText is the keyword to type from STDIN to exit the query loop
@@ -20,6 +24,10 @@ var (
 	_delim       string
 	_d           rune
 	_workerCount int
+	_k           int
+	_hybrid      bool
+	_rerankPath  string
+	_indexPath   string
 	_modelPath   string
 	_csvPath     string
 )
@@ -32,7 +40,11 @@ func init() {
 	flag.IntVar(&_batch, "b", 32, "Size of batch to encode")
 	flag.IntVar(&_seqlen, "seqlen", 16, "Max sequence length")
 	flag.StringVar(&_delim, "d", ",", `CSV delimiter char, ex -d=\t`)
-	flag.IntVar(&_workerCount, "w", runtime.NumCPU(), "Number of workers for prediction")
+	flag.IntVar(&_k, "k", 3, "Number of results per query")
+	flag.BoolVar(&_hybrid, "hybrid", false, "Fuse the results of the semantic search with a BM25 keyword search")
+	flag.StringVar(&_rerankPath, "rerank", "", "Export dir of a pair classifier reranking the top results, ex from run_classifier")
+	flag.StringVar(&_indexPath, "index", "", "Embedding file to load and append new texts to, ex go-faq.emb, texts are embedded on every run if empty")
+	flag.IntVar(&_workerCount, "w", runtime.NumCPU(), "Number of concurrent session runs for prediction")
 	flag.Parse()
 	args := flag.Args()
 	if len(args) != 2 {
@@ -50,12 +62,17 @@ func init() {
 }
 
 func main() {
-	e, err := newEngine(_modelPath, int32(_seqlen))
+	e, err := newEngine(_modelPath, int32(_seqlen), _csvPath, _d, _indexPath)
 	if err != nil {
 		exit("Error:", err)
 	}
-	if err = e.loadCSV(_csvPath, _d); err != nil {
-		exit("Error:", err)
+	var reranker *model.Reranker
+	if _rerankPath != "" {
+		m, err := model.NewBertClassifier(_rerankPath, "", model.WithConcurrency(_workerCount))
+		if err != nil {
+			exit("Error:", err)
+		}
+		reranker = model.NewReranker(m, model.WithRerankBatch(_batch), model.WithRerankWorkers(_workerCount))
 	}
 	stdin := bufio.NewScanner(os.Stdin)
 	log.Printf("Engine Initialized\n\n")
@@ -68,13 +85,22 @@ func main() {
 			return
 		case "":
 		default:
-			res, score, err := e.search(q)
+			if _hybrid {
+				hybridSearch(e, q)
+				break
+			}
+			res, err := searchAndRerank(e, reranker, q)
 			if err != nil {
 				exit("Error:", err)
 			}
-			fmt.Printf("-> %s\n", res[TextHeader])
-			fmt.Printf("\tSimilarity Score (%.2f)\n", score)
-			if score > 0.89 {
+			if len(res) == 0 {
+				fmt.Println("No results, the index is empty")
+			}
+			for _, r := range res {
+				fmt.Printf("-> %s\n", r.Text)
+				fmt.Printf("\tScore (%.2f)\n", r.Score)
+			}
+			if len(res) > 0 && res[0].Score > 0.89 {
 				fmt.Println("\tLGTM")
 			} else {
 				fmt.Println("\tNot so sure about that, might need to look somewhere else...")
@@ -87,6 +113,38 @@ func main() {
 	}
 }
 
+// searchAndRerank returns the top results of q, when reranker is set it reorders 4 times as many results
+// retrieved by the engine and keeps the top ones
+func searchAndRerank(e *search.Engine, reranker *model.Reranker, q string) ([]search.Result, error) {
+	if reranker == nil {
+		return e.Search(context.Background(), q, _k)
+	}
+	res, err := e.Search(context.Background(), q, 4*_k)
+	if err != nil {
+		return nil, err
+	}
+	if res, err = search.Rerank(context.Background(), reranker, q, res); err != nil {
+		return nil, err
+	}
+	return res[:min(_k, len(res))], nil
+}
+
+// hybridSearch prints the fused results of q with the rank and score of each search
+func hybridSearch(e *search.Engine, q string) {
+	res, err := e.HybridSearch(context.Background(), q, _k, search.RRF(search.DefaultRRFK))
+	if err != nil {
+		exit("Error:", err)
+	}
+	if len(res) == 0 {
+		fmt.Println("No results, the index is empty")
+	}
+	for _, r := range res {
+		fmt.Printf("-> %s\n", r.Text)
+		fmt.Printf("\tFused Score (%.4f) Semantic #%d (%.2f) Keywords #%d (%.2f)\n",
+			r.Score, r.Semantic.Rank, r.Semantic.Score, r.Lexical.Rank, r.Lexical.Score)
+	}
+}
+
 func exit(msgs ...interface{}) {
 	flag.Usage()
 	fmt.Fprintln(os.Stderr, msgs...)
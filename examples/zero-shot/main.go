@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+/*
+1. Download base model
+2. Fine tune w/ run_classifier --task_name=MNLI
+3. export_classifier $MODEL_DIR $EXPORT_DIR 3
+4. MODEL_PATH=$EXPORT_DIR go run main.go
+
+*/
+func main() {
+	path := os.Getenv("MODEL_PATH")
+	m, err := model.NewBertClassifier(path, path+"/vocab.txt")
+	if err != nil {
+		panic(err)
+	}
+	labels := []string{"billing", "login", "performance", "feature request"}
+	texts := []string{
+		"I was charged twice this month",
+		"the reset password link doesn't work",
+		"the dashboard takes a minute to load",
+		"please add a dark mode",
+	}
+	z, err := model.NewZeroShot(m, model.WithHypothesisTemplate("This ticket is about {label}."))
+	if err != nil {
+		panic(err)
+	}
+	res, err := z.Classify(context.Background(), labels, texts...)
+	if err != nil {
+		panic(err)
+	}
+	for i, text := range texts {
+		fmt.Printf("%q\n", text)
+		for _, ls := range res[i] {
+			fmt.Printf("\t%s: %.2f\n", ls.Label, ls.Score)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package fewshot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelReport is the leave-one-out accuracy of the examples of a label
+type LabelReport struct {
+	Label    string
+	Examples int
+	Correct  int
+}
+
+// Report is the leave-one-out accuracy of a Classifier
+type Report struct {
+	Examples int
+	Correct  int
+	Labels   []LabelReport
+}
+
+// Accuracy is the fraction of examples predicted correctly
+func (r Report) Accuracy() float32 {
+	if r.Examples == 0 {
+		return 0
+	}
+	return float32(r.Correct) / float32(r.Examples)
+}
+
+// String reports the accuracy of every label
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "accuracy %.3f (%d/%d)", r.Accuracy(), r.Correct, r.Examples)
+	for _, l := range r.Labels {
+		fmt.Fprintf(&b, "\n\t%s: %d/%d", l.Label, l.Correct, l.Examples)
+	}
+	return b.String()
+}
+
+// LeaveOneOut predicts each example from all the other examples and reports how many get their own label.
+// It runs on the stored embeddings, the model isn't called. Examples alone in their label are always wrong
+// with prototypes, add examples to the labels with the most mistakes.
+func (c *Classifier) LeaveOneOut() Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r := Report{Examples: len(c.examples), Labels: make([]LabelReport, len(c.labels))}
+	for l, label := range c.labels {
+		r.Labels[l].Label = label
+	}
+	for i, e := range c.examples {
+		r.Labels[e.label].Examples++
+		if c.prediction(c.scores(e.vec, i)).Label == e.Label {
+			r.Correct++
+			r.Labels[e.label].Correct++
+		}
+	}
+	return r
+}
@@ -0,0 +1,275 @@
+// Package fewshot classifies texts from a handful of labelled examples per class, without fine-tuning.
+// Examples are embedded by an embedding model, ex from model.NewEmbeddings, and a text gets the label of the
+// nearest class prototype, the mean of the embeddings of its examples, or of its k nearest examples.
+package fewshot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// Defaults of a Classifier
+const (
+	DefaultTemperature = 0.05
+)
+
+// Example is a text labelled with its class
+type Example struct {
+	Label string
+	Text  string
+}
+
+// Prediction is the label of a text, Confidence is the probability of the label.
+// Scores are the probabilities of every label by decreasing score.
+type Prediction struct {
+	Label      string
+	Confidence float32
+	Scores     []model.LabelScore
+}
+
+// example is an Example with its normalized embedding
+type example struct {
+	Example
+	label int
+	vec   model.Embedding
+}
+
+// Classifier labels texts by the similarity of their embeddings to the ones of labelled examples.
+// It is safe for concurrent use.
+type Classifier struct {
+	m           model.Predictor
+	pooling     model.Pooling
+	k           int
+	temperature float32
+	fingerprint string
+	unknown     bool // whether examples without a fingerprint or saved without one are loaded
+
+	mu       sync.RWMutex
+	labels   []string // in the order they were first added
+	index    map[string]int
+	sums     []model.Embedding // sum of the normalized embeddings of the examples of each label
+	examples []example
+}
+
+// Option configures a Classifier
+type Option func(c *Classifier) *Classifier
+
+// WithPooling sets how token vectors are pooled into embeddings, MeanPooling by default
+func WithPooling(p model.Pooling) Option {
+	return func(c *Classifier) *Classifier {
+		c.pooling = p
+		return c
+	}
+}
+
+// WithKNN labels texts by a vote of their k most similar examples weighted by similarity instead of by prototypes.
+// It suits classes whose examples are spread over different topics.
+func WithKNN(k int) Option {
+	return func(c *Classifier) *Classifier {
+		c.k = k
+		return c
+	}
+}
+
+// WithTemperature sets the temperature of the softmax of the scores of the labels into probabilities,
+// DefaultTemperature by default. Cosine similarities are close so a low temperature spreads them.
+func WithTemperature(t float32) Option {
+	return func(c *Classifier) *Classifier {
+		c.temperature = t
+		return c
+	}
+}
+
+// WithFingerprint sets the fingerprint of the model saved with the examples, ex from model.Fingerprint.
+// Examples saved with another fingerprint are refused by Load.
+func WithFingerprint(fp string) Option {
+	return func(c *Classifier) *Classifier {
+		c.fingerprint = fp
+		return c
+	}
+}
+
+// WithUnknownFingerprint makes Load accept examples when the classifier or the examples have no fingerprint,
+// they are refused by default since they may come from another model
+func WithUnknownFingerprint() Option {
+	return func(c *Classifier) *Classifier {
+		c.unknown = true
+		return c
+	}
+}
+
+// New returns a Classifier without examples embedding texts with m, an embedding model
+func New(m model.Predictor, opts ...Option) *Classifier {
+	c := &Classifier{m: m, pooling: model.MeanPooling, temperature: DefaultTemperature, index: map[string]int{}}
+	for _, opt := range opts {
+		c = opt(c)
+	}
+	if c.temperature <= 0 {
+		c.temperature = DefaultTemperature
+	}
+	return c
+}
+
+// Add embeds examples and adds them to the classifier
+func (c *Classifier) Add(ctx context.Context, examples ...Example) error {
+	texts := make([]string, len(examples))
+	for i, e := range examples {
+		texts[i] = e.Text
+	}
+	vecs, err := model.Embed(ctx, c.m, c.pooling, texts...)
+	if err != nil {
+		return err
+	}
+	return c.AddEmbedded(examples, vecs)
+}
+
+// AddEmbedded adds examples with their precomputed embeddings, they must have been embedded by the same model
+// and pooling as the classifier
+func (c *Classifier) AddEmbedded(examples []Example, vecs []model.Embedding) error {
+	if len(examples) != len(vecs) {
+		return fmt.Errorf("mismatched embedding count %d for %d examples", len(vecs), len(examples))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dim := 0
+	if len(c.examples) > 0 {
+		dim = len(c.examples[0].vec)
+	}
+	for i, e := range examples {
+		if e.Label == "" {
+			return fmt.Errorf("example %d has no label", i)
+		}
+		if dim == 0 {
+			dim = len(vecs[i])
+		}
+		if len(vecs[i]) == 0 || len(vecs[i]) != dim {
+			return fmt.Errorf("mismatched embedding dimension %d, expected %d", len(vecs[i]), dim)
+		}
+	}
+	for i, e := range examples {
+		l, ok := c.index[e.Label]
+		if !ok {
+			l = len(c.labels)
+			c.index[e.Label] = l
+			c.labels = append(c.labels, e.Label)
+			c.sums = append(c.sums, make(model.Embedding, dim))
+		}
+		vec := search.Normalize(vecs[i])
+		for j, x := range vec {
+			c.sums[l][j] += x
+		}
+		c.examples = append(c.examples, example{Example: e, label: l, vec: vec})
+	}
+	return nil
+}
+
+// Predict returns the prediction of each text, there must be examples of at least one label
+func (c *Classifier) Predict(ctx context.Context, texts ...string) ([]Prediction, error) {
+	vecs, err := model.Embed(ctx, c.m, c.pooling, texts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.PredictEmbedded(vecs)
+}
+
+// PredictEmbedded returns the prediction of each embedding
+func (c *Classifier) PredictEmbedded(vecs []model.Embedding) ([]Prediction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.labels) == 0 {
+		return nil, fmt.Errorf("no examples to classify with")
+	}
+	preds := make([]Prediction, len(vecs))
+	for i, v := range vecs {
+		if len(v) != len(c.sums[0]) {
+			return nil, fmt.Errorf("mismatched embedding dimension %d, expected %d", len(v), len(c.sums[0]))
+		}
+		preds[i] = c.prediction(c.scores(search.Normalize(v), -1))
+	}
+	return preds, nil
+}
+
+// Labels returns the labels of the examples in the order they were first added
+func (c *Classifier) Labels() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.labels...)
+}
+
+// Len returns the number of examples
+func (c *Classifier) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.examples)
+}
+
+// scores returns the score of each label for the normalized v, leaving out the example skip if it isn't -1.
+// A label without examples scores -Inf.
+func (c *Classifier) scores(v model.Embedding, skip int) []float32 {
+	scores := make([]float32, len(c.labels))
+	if c.k > 0 {
+		for l := range scores {
+			scores[l] = float32(math.Inf(-1))
+		}
+		top := search.NewTopK(c.k)
+		for i, e := range c.examples {
+			if i != skip {
+				top.Push(search.Hit{ID: i, Score: search.Dot(v, e.vec)})
+			}
+		}
+		for _, h := range top.Sorted() {
+			l := c.examples[h.ID].label
+			if math.IsInf(float64(scores[l]), -1) {
+				scores[l] = 0
+			}
+			scores[l] += h.Score / float32(c.k)
+		}
+		return scores
+	}
+	for l, sum := range c.sums {
+		proto := sum
+		if skip >= 0 && c.examples[skip].label == l {
+			proto = make(model.Embedding, len(sum))
+			for j, x := range sum {
+				proto[j] = x - c.examples[skip].vec[j]
+			}
+		}
+		scores[l] = float32(math.Inf(-1))
+		if n := search.Normalize(proto); search.Dot(n, n) > 0 {
+			scores[l] = search.Dot(v, n)
+		}
+	}
+	return scores
+}
+
+// prediction converts the scores of the labels to probabilities by a softmax,
+// there is no label when no label has a score
+func (c *Classifier) prediction(scores []float32) Prediction {
+	best := float32(math.Inf(-1))
+	for _, s := range scores {
+		best = max(best, s)
+	}
+	if math.IsInf(float64(best), -1) {
+		return Prediction{}
+	}
+	probs := make([]model.LabelScore, len(scores))
+	var total float64
+	for l, s := range scores {
+		p := math.Exp(float64((s - best) / c.temperature))
+		probs[l] = model.LabelScore{Label: c.labels[l], Score: float32(p)}
+		total += p
+	}
+	for l := range probs {
+		probs[l].Score = float32(float64(probs[l].Score) / total)
+	}
+	sort.SliceStable(probs, func(a, b int) bool {
+		return probs[a].Score > probs[b].Score
+	})
+	return Prediction{Label: probs[0].Label, Confidence: probs[0].Score, Scores: probs}
+}
@@ -0,0 +1,155 @@
+package fewshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+)
+
+// fakeModel embeds a text to the sum of the vectors of its words
+func fakeModel() *modeltest.Predictor {
+	return modeltest.New(func(texts []string) interface{} {
+		vals := make([][][]float32, len(texts))
+		for i, text := range texts {
+			vec := []float32{0.01, 0.01, 0.01}
+			for _, w := range strings.Fields(text) {
+				for j, x := range words[w] {
+					vec[j] += x
+				}
+			}
+			vals[i] = [][]float32{vec}
+		}
+		return vals
+	})
+}
+
+var words = map[string][]float32{
+	"invoice":  {1, 0, 0},
+	"charged":  {0.9, 0.2, 0},
+	"refund":   {0.8, 0, 0.3},
+	"password": {0, 1, 0},
+	"login":    {0.1, 0.9, 0},
+	"slow":     {0, 0, 1},
+	"lag":      {0.1, 0.1, 0.9},
+}
+
+var examples = []Example{
+	{"billing", "wrong invoice"},
+	{"billing", "charged twice"},
+	{"billing", "refund please"},
+	{"account", "forgot password"},
+	{"account", "login fails"},
+	{"performance", "so slow"},
+}
+
+func predictedLabels(t *testing.T, c *Classifier, texts ...string) []string {
+	t.Helper()
+	preds, err := c.Predict(context.Background(), texts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, p := range preds {
+		labels = append(labels, p.Label)
+		var total float32
+		for _, s := range p.Scores {
+			total += s.Score
+		}
+		if total < 0.999 || total > 1.001 || p.Confidence != p.Scores[0].Score || p.Label != p.Scores[0].Label {
+			t.Errorf("Invalid Prediction - Got: %+v", p)
+		}
+	}
+	return labels
+}
+
+func TestPredict(t *testing.T) {
+	texts := []string{"invoice refund", "password login", "lag", "charged slow slow"}
+	want := []string{"billing", "account", "performance", "performance"}
+	for _, opts := range [][]Option{nil, {WithKNN(2)}} {
+		c := New(fakeModel(), opts...)
+		if _, err := c.Predict(context.Background(), "invoice"); err == nil {
+			t.Errorf("Invalid Empty Classifier - Want: error")
+		}
+		if err := c.Add(context.Background(), examples...); err != nil {
+			t.Fatal(err)
+		}
+		if got := predictedLabels(t, c, texts...); !reflect.DeepEqual(got, want) {
+			t.Errorf("Invalid Labels with %d options - Want: %v, Got: %v", len(opts), want, got)
+		}
+	}
+	c := New(fakeModel())
+	c.Add(context.Background(), examples...)
+	preds, _ := c.Predict(context.Background(), "invoice")
+	if preds[0].Confidence < 0.99 {
+		t.Errorf("Invalid Confidence - Want: >= 0.99, Got: %v", preds[0].Confidence)
+	}
+	if want := []string{"billing", "account", "performance"}; !reflect.DeepEqual(c.Labels(), want) || c.Len() != 6 {
+		t.Errorf("Invalid Labels - Want: %v, Got: %v %d", want, c.Labels(), c.Len())
+	}
+	if err := c.Add(context.Background(), Example{Text: "invoice"}); err == nil || c.Len() != 6 {
+		t.Errorf("Invalid Unlabelled Example - Want: error, Got: %v %d", err, c.Len())
+	}
+}
+
+func TestLeaveOneOut(t *testing.T) {
+	c := New(fakeModel())
+	c.Add(context.Background(), examples...)
+	r := c.LeaveOneOut()
+	// the only performance example has no prototype left
+	want := []LabelReport{{"billing", 3, 3}, {"account", 2, 2}, {"performance", 1, 0}}
+	if r.Examples != 6 || r.Correct != 5 || !reflect.DeepEqual(r.Labels, want) {
+		t.Errorf("Invalid Report - Want: 5/6 %v, Got: %+v", want, r)
+	}
+	if a := r.Accuracy(); a < 0.83 || a > 0.84 {
+		t.Errorf("Invalid Accuracy - Want: 0.833, Got: %v", a)
+	}
+	c = New(fakeModel(), WithKNN(1))
+	c.Add(context.Background(), examples...)
+	if r := c.LeaveOneOut(); r.Correct != 5 {
+		t.Errorf("Invalid KNN Report - Want: 5/6, Got: %v", r)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	c := New(fakeModel(), WithFingerprint("abc"))
+	c.Add(context.Background(), examples...)
+	path := filepath.Join(t.TempDir(), "examples.json")
+	if err := c.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New(fakeModel(), WithFingerprint("abc"))
+	if err := loaded.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	texts := []string{"invoice refund", "password login", "lag"}
+	if want, got := predictedLabels(t, c, texts...), predictedLabels(t, loaded, texts...); !reflect.DeepEqual(got, want) || loaded.Len() != c.Len() {
+		t.Errorf("Invalid Loaded Labels - Want: %v, Got: %v", want, got)
+	}
+	var buf bytes.Buffer
+	c.Save(&buf)
+	data := buf.Bytes()
+	if err := New(fakeModel(), WithFingerprint("xyz")).Load(bytes.NewReader(data)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Invalid Fingerprint Error - Want: %v, Got: %v", ErrMismatch, err)
+	}
+	if err := New(fakeModel(), WithFingerprint("abc"), WithPooling(model.CLSPooling)).Load(bytes.NewReader(data)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Invalid Pooling Error - Want: %v, Got: %v", ErrMismatch, err)
+	}
+	if err := New(fakeModel()).Load(bytes.NewReader(data)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Invalid Unknown Fingerprint Error - Want: %v, Got: %v", ErrMismatch, err)
+	}
+	if err := New(fakeModel(), WithUnknownFingerprint()).Load(bytes.NewReader(data)); err != nil {
+		t.Errorf("Invalid Allowed Unknown Fingerprint Error - Want: %v, Got: %v", nil, err)
+	}
+	buf.Reset()
+	New(fakeModel()).Save(&buf)
+	if err := New(fakeModel(), WithFingerprint("abc")).Load(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Invalid Unfingerprinted Examples Error - Want: %v, Got: %v", ErrMismatch, err)
+	}
+}
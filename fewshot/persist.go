@@ -0,0 +1,83 @@
+package fewshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sunhailin-Leo/gobert/internal/fileutil"
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// version of the saved examples
+const version = 1
+
+// ErrMismatch is returned when loading examples embedded by another model or pooling than the classifier
+var ErrMismatch = errors.New("examples embedded by another model")
+
+// saved is the JSON form of the examples of a Classifier
+type saved struct {
+	Version     int            `json:"version"`
+	Fingerprint string         `json:"fingerprint,omitempty"`
+	Pooling     model.Pooling  `json:"pooling"`
+	Examples    []savedExample `json:"examples"`
+}
+
+type savedExample struct {
+	Label  string          `json:"label"`
+	Text   string          `json:"text"`
+	Vector model.Embedding `json:"vector"`
+}
+
+// Save writes the examples with their embeddings to w as JSON, they are loaded without calling the model
+func (c *Classifier) Save(w io.Writer) error {
+	c.mu.RLock()
+	s := saved{Version: version, Fingerprint: c.fingerprint, Pooling: c.pooling, Examples: make([]savedExample, len(c.examples))}
+	for i, e := range c.examples {
+		s.Examples[i] = savedExample{Label: e.Label, Text: e.Text, Vector: e.vec}
+	}
+	c.mu.RUnlock()
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Load adds the examples saved by Save to the classifier. They are refused with ErrMismatch when they were
+// pooled differently or with a different model fingerprint, a missing one is a mismatch without WithUnknownFingerprint.
+func (c *Classifier) Load(r io.Reader) error {
+	var s saved
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != version {
+		return fmt.Errorf("unsupported examples version %d", s.Version)
+	}
+	if s.Pooling != c.pooling {
+		return fmt.Errorf("%w: pooling %s, classifier pools with %s", ErrMismatch, s.Pooling, c.pooling)
+	}
+	if !model.SameFingerprint(s.Fingerprint, c.fingerprint, c.unknown) {
+		return fmt.Errorf("%w: fingerprint %q, classifier has %q", ErrMismatch, s.Fingerprint, c.fingerprint)
+	}
+	examples := make([]Example, len(s.Examples))
+	vecs := make([]model.Embedding, len(s.Examples))
+	for i, e := range s.Examples {
+		examples[i] = Example{Label: e.Label, Text: e.Text}
+		vecs[i] = e.Vector
+	}
+	return c.AddEmbedded(examples, vecs)
+}
+
+// SaveFile saves the examples to path, replacing it only once they are fully written
+func (c *Classifier) SaveFile(path string) error {
+	return fileutil.WriteFile(path, c.Save)
+}
+
+// LoadFile adds the examples saved to path
+func (c *Classifier) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.Load(f)
+}
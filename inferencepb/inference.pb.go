@@ -0,0 +1,1123 @@
+// Inference service for models served by gobert, mirrors the JSON API of the server package
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: inferencepb/inference.proto
+
+package inferencepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ModelRef selects a registered model, an empty version uses the newest one
+type ModelRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelRef) Reset() {
+	*x = ModelRef{}
+	mi := &file_inferencepb_inference_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelRef) ProtoMessage() {}
+
+func (x *ModelRef) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelRef.ProtoReflect.Descriptor instead.
+func (*ModelRef) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ModelRef) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ModelRef) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+// Limits are per-request constraints on the texts being encoded
+type Limits struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// max_seq_len rejects texts with more tokens than this, including [CLS] and [SEP]
+	MaxSeqLen     int32 `protobuf:"varint,1,opt,name=max_seq_len,json=maxSeqLen,proto3" json:"max_seq_len,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Limits) Reset() {
+	*x = Limits{}
+	mi := &file_inferencepb_inference_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Limits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Limits) ProtoMessage() {}
+
+func (x *Limits) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Limits.ProtoReflect.Descriptor instead.
+func (*Limits) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Limits) GetMaxSeqLen() int32 {
+	if x != nil {
+		return x.MaxSeqLen
+	}
+	return 0
+}
+
+type ModelInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	SeqLen        int32                  `protobuf:"varint,3,opt,name=seq_len,json=seqLen,proto3" json:"seq_len,omitempty"`
+	Labels        []string               `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelInfo) Reset() {
+	*x = ModelInfo{}
+	mi := &file_inferencepb_inference_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelInfo) ProtoMessage() {}
+
+func (x *ModelInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelInfo.ProtoReflect.Descriptor instead.
+func (*ModelInfo) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ModelInfo) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *ModelInfo) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetSeqLen() int32 {
+	if x != nil {
+		return x.SeqLen
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type ListModelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsRequest) Reset() {
+	*x = ListModelsRequest{}
+	mi := &file_inferencepb_inference_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsRequest) ProtoMessage() {}
+
+func (x *ListModelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsRequest.ProtoReflect.Descriptor instead.
+func (*ListModelsRequest) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{3}
+}
+
+type ListModelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Models        []*ModelInfo           `protobuf:"bytes,1,rep,name=models,proto3" json:"models,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsResponse) Reset() {
+	*x = ListModelsResponse{}
+	mi := &file_inferencepb_inference_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsResponse) ProtoMessage() {}
+
+func (x *ListModelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsResponse.ProtoReflect.Descriptor instead.
+func (*ListModelsResponse) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListModelsResponse) GetModels() []*ModelInfo {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+type Embedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Embedding) Reset() {
+	*x = Embedding{}
+	mi := &file_inferencepb_inference_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Embedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Embedding) ProtoMessage() {}
+
+func (x *Embedding) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Embedding.ProtoReflect.Descriptor instead.
+func (*Embedding) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Embedding) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type EmbedRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ref    *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Limits *Limits                `protobuf:"bytes,2,opt,name=limits,proto3" json:"limits,omitempty"`
+	Texts  []string               `protobuf:"bytes,3,rep,name=texts,proto3" json:"texts,omitempty"`
+	// pooling is one of mean, cls or max, defaults to mean
+	Pooling       string `protobuf:"bytes,4,opt,name=pooling,proto3" json:"pooling,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedRequest) Reset() {
+	*x = EmbedRequest{}
+	mi := &file_inferencepb_inference_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedRequest) ProtoMessage() {}
+
+func (x *EmbedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedRequest.ProtoReflect.Descriptor instead.
+func (*EmbedRequest) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EmbedRequest) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *EmbedRequest) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *EmbedRequest) GetTexts() []string {
+	if x != nil {
+		return x.Texts
+	}
+	return nil
+}
+
+func (x *EmbedRequest) GetPooling() string {
+	if x != nil {
+		return x.Pooling
+	}
+	return ""
+}
+
+type EmbedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Embeddings    []*Embedding           `protobuf:"bytes,2,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedResponse) Reset() {
+	*x = EmbedResponse{}
+	mi := &file_inferencepb_inference_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedResponse) ProtoMessage() {}
+
+func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
+func (*EmbedResponse) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EmbedResponse) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetEmbeddings() []*Embedding {
+	if x != nil {
+		return x.Embeddings
+	}
+	return nil
+}
+
+type TextPair struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	First         string                 `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	Second        string                 `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextPair) Reset() {
+	*x = TextPair{}
+	mi := &file_inferencepb_inference_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextPair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextPair) ProtoMessage() {}
+
+func (x *TextPair) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextPair.ProtoReflect.Descriptor instead.
+func (*TextPair) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TextPair) GetFirst() string {
+	if x != nil {
+		return x.First
+	}
+	return ""
+}
+
+func (x *TextPair) GetSecond() string {
+	if x != nil {
+		return x.Second
+	}
+	return ""
+}
+
+type ClassifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Limits        *Limits                `protobuf:"bytes,2,opt,name=limits,proto3" json:"limits,omitempty"`
+	Texts         []string               `protobuf:"bytes,3,rep,name=texts,proto3" json:"texts,omitempty"`
+	Pairs         []*TextPair            `protobuf:"bytes,4,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClassifyRequest) Reset() {
+	*x = ClassifyRequest{}
+	mi := &file_inferencepb_inference_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClassifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClassifyRequest) ProtoMessage() {}
+
+func (x *ClassifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClassifyRequest.ProtoReflect.Descriptor instead.
+func (*ClassifyRequest) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ClassifyRequest) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *ClassifyRequest) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *ClassifyRequest) GetTexts() []string {
+	if x != nil {
+		return x.Texts
+	}
+	return nil
+}
+
+func (x *ClassifyRequest) GetPairs() []*TextPair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+type Classification struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
+	Probabilities []float32              `protobuf:"fixed32,3,rep,packed,name=probabilities,proto3" json:"probabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Classification) Reset() {
+	*x = Classification{}
+	mi := &file_inferencepb_inference_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Classification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Classification) ProtoMessage() {}
+
+func (x *Classification) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Classification.ProtoReflect.Descriptor instead.
+func (*Classification) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Classification) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Classification) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Classification) GetProbabilities() []float32 {
+	if x != nil {
+		return x.Probabilities
+	}
+	return nil
+}
+
+type ClassifyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Labels        []string               `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"`
+	Results       []*Classification      `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClassifyResponse) Reset() {
+	*x = ClassifyResponse{}
+	mi := &file_inferencepb_inference_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClassifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClassifyResponse) ProtoMessage() {}
+
+func (x *ClassifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClassifyResponse.ProtoReflect.Descriptor instead.
+func (*ClassifyResponse) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ClassifyResponse) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *ClassifyResponse) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ClassifyResponse) GetResults() []*Classification {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type TokenizeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Texts         []string               `protobuf:"bytes,2,rep,name=texts,proto3" json:"texts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenizeRequest) Reset() {
+	*x = TokenizeRequest{}
+	mi := &file_inferencepb_inference_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenizeRequest) ProtoMessage() {}
+
+func (x *TokenizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenizeRequest.ProtoReflect.Descriptor instead.
+func (*TokenizeRequest) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TokenizeRequest) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *TokenizeRequest) GetTexts() []string {
+	if x != nil {
+		return x.Texts
+	}
+	return nil
+}
+
+type Tokenization struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tokens        []string               `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	Ids           []int32                `protobuf:"varint,2,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	TypeIds       []int32                `protobuf:"varint,3,rep,packed,name=type_ids,json=typeIds,proto3" json:"type_ids,omitempty"`
+	Truncated     int32                  `protobuf:"varint,4,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tokenization) Reset() {
+	*x = Tokenization{}
+	mi := &file_inferencepb_inference_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tokenization) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tokenization) ProtoMessage() {}
+
+func (x *Tokenization) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tokenization.ProtoReflect.Descriptor instead.
+func (*Tokenization) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Tokenization) GetTokens() []string {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+func (x *Tokenization) GetIds() []int32 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *Tokenization) GetTypeIds() []int32 {
+	if x != nil {
+		return x.TypeIds
+	}
+	return nil
+}
+
+func (x *Tokenization) GetTruncated() int32 {
+	if x != nil {
+		return x.Truncated
+	}
+	return 0
+}
+
+type TokenizeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *ModelRef              `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	SeqLen        int32                  `protobuf:"varint,2,opt,name=seq_len,json=seqLen,proto3" json:"seq_len,omitempty"`
+	Results       []*Tokenization        `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenizeResponse) Reset() {
+	*x = TokenizeResponse{}
+	mi := &file_inferencepb_inference_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenizeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenizeResponse) ProtoMessage() {}
+
+func (x *TokenizeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenizeResponse.ProtoReflect.Descriptor instead.
+func (*TokenizeResponse) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TokenizeResponse) GetRef() *ModelRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *TokenizeResponse) GetSeqLen() int32 {
+	if x != nil {
+		return x.SeqLen
+	}
+	return 0
+}
+
+func (x *TokenizeResponse) GetResults() []*Tokenization {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type EmbedStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is chosen by the client to match responses to requests
+	Id            uint64        `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Request       *EmbedRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedStreamRequest) Reset() {
+	*x = EmbedStreamRequest{}
+	mi := &file_inferencepb_inference_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedStreamRequest) ProtoMessage() {}
+
+func (x *EmbedStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedStreamRequest.ProtoReflect.Descriptor instead.
+func (*EmbedStreamRequest) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *EmbedStreamRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *EmbedStreamRequest) GetRequest() *EmbedRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type EmbedStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Response      *EmbedResponse         `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedStreamResponse) Reset() {
+	*x = EmbedStreamResponse{}
+	mi := &file_inferencepb_inference_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedStreamResponse) ProtoMessage() {}
+
+func (x *EmbedStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inferencepb_inference_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedStreamResponse.ProtoReflect.Descriptor instead.
+func (*EmbedStreamResponse) Descriptor() ([]byte, []int) {
+	return file_inferencepb_inference_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *EmbedStreamResponse) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *EmbedStreamResponse) GetResponse() *EmbedResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+var File_inferencepb_inference_proto protoreflect.FileDescriptor
+
+const file_inferencepb_inference_proto_rawDesc = "" +
+	"\n" +
+	"\x1binferencepb/inference.proto\x12\tgobert.v1\":\n" +
+	"\bModelRef\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\"(\n" +
+	"\x06Limits\x12\x1e\n" +
+	"\vmax_seq_len\x18\x01 \x01(\x05R\tmaxSeqLen\"w\n" +
+	"\tModelInfo\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x17\n" +
+	"\aseq_len\x18\x03 \x01(\x05R\x06seqLen\x12\x16\n" +
+	"\x06labels\x18\x04 \x03(\tR\x06labels\"\x13\n" +
+	"\x11ListModelsRequest\"B\n" +
+	"\x12ListModelsResponse\x12,\n" +
+	"\x06models\x18\x01 \x03(\v2\x14.gobert.v1.ModelInfoR\x06models\"#\n" +
+	"\tEmbedding\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\"\x90\x01\n" +
+	"\fEmbedRequest\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x12)\n" +
+	"\x06limits\x18\x02 \x01(\v2\x11.gobert.v1.LimitsR\x06limits\x12\x14\n" +
+	"\x05texts\x18\x03 \x03(\tR\x05texts\x12\x18\n" +
+	"\apooling\x18\x04 \x01(\tR\apooling\"l\n" +
+	"\rEmbedResponse\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x124\n" +
+	"\n" +
+	"embeddings\x18\x02 \x03(\v2\x14.gobert.v1.EmbeddingR\n" +
+	"embeddings\"8\n" +
+	"\bTextPair\x12\x14\n" +
+	"\x05first\x18\x01 \x01(\tR\x05first\x12\x16\n" +
+	"\x06second\x18\x02 \x01(\tR\x06second\"\xa4\x01\n" +
+	"\x0fClassifyRequest\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x12)\n" +
+	"\x06limits\x18\x02 \x01(\v2\x11.gobert.v1.LimitsR\x06limits\x12\x14\n" +
+	"\x05texts\x18\x03 \x03(\tR\x05texts\x12)\n" +
+	"\x05pairs\x18\x04 \x03(\v2\x13.gobert.v1.TextPairR\x05pairs\"b\n" +
+	"\x0eClassification\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score\x12$\n" +
+	"\rprobabilities\x18\x03 \x03(\x02R\rprobabilities\"\x86\x01\n" +
+	"\x10ClassifyResponse\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x12\x16\n" +
+	"\x06labels\x18\x02 \x03(\tR\x06labels\x123\n" +
+	"\aresults\x18\x03 \x03(\v2\x19.gobert.v1.ClassificationR\aresults\"N\n" +
+	"\x0fTokenizeRequest\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x12\x14\n" +
+	"\x05texts\x18\x02 \x03(\tR\x05texts\"q\n" +
+	"\fTokenization\x12\x16\n" +
+	"\x06tokens\x18\x01 \x03(\tR\x06tokens\x12\x10\n" +
+	"\x03ids\x18\x02 \x03(\x05R\x03ids\x12\x19\n" +
+	"\btype_ids\x18\x03 \x03(\x05R\atypeIds\x12\x1c\n" +
+	"\ttruncated\x18\x04 \x01(\x05R\ttruncated\"\x85\x01\n" +
+	"\x10TokenizeResponse\x12%\n" +
+	"\x03ref\x18\x01 \x01(\v2\x13.gobert.v1.ModelRefR\x03ref\x12\x17\n" +
+	"\aseq_len\x18\x02 \x01(\x05R\x06seqLen\x121\n" +
+	"\aresults\x18\x03 \x03(\v2\x17.gobert.v1.TokenizationR\aresults\"W\n" +
+	"\x12EmbedStreamRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x121\n" +
+	"\arequest\x18\x02 \x01(\v2\x17.gobert.v1.EmbedRequestR\arequest\"[\n" +
+	"\x13EmbedStreamResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x124\n" +
+	"\bresponse\x18\x02 \x01(\v2\x18.gobert.v1.EmbedResponseR\bresponse2\xee\x02\n" +
+	"\tInference\x12I\n" +
+	"\n" +
+	"ListModels\x12\x1c.gobert.v1.ListModelsRequest\x1a\x1d.gobert.v1.ListModelsResponse\x12:\n" +
+	"\x05Embed\x12\x17.gobert.v1.EmbedRequest\x1a\x18.gobert.v1.EmbedResponse\x12C\n" +
+	"\bClassify\x12\x1a.gobert.v1.ClassifyRequest\x1a\x1b.gobert.v1.ClassifyResponse\x12C\n" +
+	"\bTokenize\x12\x1a.gobert.v1.TokenizeRequest\x1a\x1b.gobert.v1.TokenizeResponse\x12P\n" +
+	"\vEmbedStream\x12\x1d.gobert.v1.EmbedStreamRequest\x1a\x1e.gobert.v1.EmbedStreamResponse(\x010\x01B-Z+github.com/sunhailin-Leo/gobert/inferencepbb\x06proto3"
+
+var (
+	file_inferencepb_inference_proto_rawDescOnce sync.Once
+	file_inferencepb_inference_proto_rawDescData []byte
+)
+
+func file_inferencepb_inference_proto_rawDescGZIP() []byte {
+	file_inferencepb_inference_proto_rawDescOnce.Do(func() {
+		file_inferencepb_inference_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_inferencepb_inference_proto_rawDesc), len(file_inferencepb_inference_proto_rawDesc)))
+	})
+	return file_inferencepb_inference_proto_rawDescData
+}
+
+var file_inferencepb_inference_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_inferencepb_inference_proto_goTypes = []any{
+	(*ModelRef)(nil),            // 0: gobert.v1.ModelRef
+	(*Limits)(nil),              // 1: gobert.v1.Limits
+	(*ModelInfo)(nil),           // 2: gobert.v1.ModelInfo
+	(*ListModelsRequest)(nil),   // 3: gobert.v1.ListModelsRequest
+	(*ListModelsResponse)(nil),  // 4: gobert.v1.ListModelsResponse
+	(*Embedding)(nil),           // 5: gobert.v1.Embedding
+	(*EmbedRequest)(nil),        // 6: gobert.v1.EmbedRequest
+	(*EmbedResponse)(nil),       // 7: gobert.v1.EmbedResponse
+	(*TextPair)(nil),            // 8: gobert.v1.TextPair
+	(*ClassifyRequest)(nil),     // 9: gobert.v1.ClassifyRequest
+	(*Classification)(nil),      // 10: gobert.v1.Classification
+	(*ClassifyResponse)(nil),    // 11: gobert.v1.ClassifyResponse
+	(*TokenizeRequest)(nil),     // 12: gobert.v1.TokenizeRequest
+	(*Tokenization)(nil),        // 13: gobert.v1.Tokenization
+	(*TokenizeResponse)(nil),    // 14: gobert.v1.TokenizeResponse
+	(*EmbedStreamRequest)(nil),  // 15: gobert.v1.EmbedStreamRequest
+	(*EmbedStreamResponse)(nil), // 16: gobert.v1.EmbedStreamResponse
+}
+var file_inferencepb_inference_proto_depIdxs = []int32{
+	0,  // 0: gobert.v1.ModelInfo.ref:type_name -> gobert.v1.ModelRef
+	2,  // 1: gobert.v1.ListModelsResponse.models:type_name -> gobert.v1.ModelInfo
+	0,  // 2: gobert.v1.EmbedRequest.ref:type_name -> gobert.v1.ModelRef
+	1,  // 3: gobert.v1.EmbedRequest.limits:type_name -> gobert.v1.Limits
+	0,  // 4: gobert.v1.EmbedResponse.ref:type_name -> gobert.v1.ModelRef
+	5,  // 5: gobert.v1.EmbedResponse.embeddings:type_name -> gobert.v1.Embedding
+	0,  // 6: gobert.v1.ClassifyRequest.ref:type_name -> gobert.v1.ModelRef
+	1,  // 7: gobert.v1.ClassifyRequest.limits:type_name -> gobert.v1.Limits
+	8,  // 8: gobert.v1.ClassifyRequest.pairs:type_name -> gobert.v1.TextPair
+	0,  // 9: gobert.v1.ClassifyResponse.ref:type_name -> gobert.v1.ModelRef
+	10, // 10: gobert.v1.ClassifyResponse.results:type_name -> gobert.v1.Classification
+	0,  // 11: gobert.v1.TokenizeRequest.ref:type_name -> gobert.v1.ModelRef
+	0,  // 12: gobert.v1.TokenizeResponse.ref:type_name -> gobert.v1.ModelRef
+	13, // 13: gobert.v1.TokenizeResponse.results:type_name -> gobert.v1.Tokenization
+	6,  // 14: gobert.v1.EmbedStreamRequest.request:type_name -> gobert.v1.EmbedRequest
+	7,  // 15: gobert.v1.EmbedStreamResponse.response:type_name -> gobert.v1.EmbedResponse
+	3,  // 16: gobert.v1.Inference.ListModels:input_type -> gobert.v1.ListModelsRequest
+	6,  // 17: gobert.v1.Inference.Embed:input_type -> gobert.v1.EmbedRequest
+	9,  // 18: gobert.v1.Inference.Classify:input_type -> gobert.v1.ClassifyRequest
+	12, // 19: gobert.v1.Inference.Tokenize:input_type -> gobert.v1.TokenizeRequest
+	15, // 20: gobert.v1.Inference.EmbedStream:input_type -> gobert.v1.EmbedStreamRequest
+	4,  // 21: gobert.v1.Inference.ListModels:output_type -> gobert.v1.ListModelsResponse
+	7,  // 22: gobert.v1.Inference.Embed:output_type -> gobert.v1.EmbedResponse
+	11, // 23: gobert.v1.Inference.Classify:output_type -> gobert.v1.ClassifyResponse
+	14, // 24: gobert.v1.Inference.Tokenize:output_type -> gobert.v1.TokenizeResponse
+	16, // 25: gobert.v1.Inference.EmbedStream:output_type -> gobert.v1.EmbedStreamResponse
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_inferencepb_inference_proto_init() }
+func file_inferencepb_inference_proto_init() {
+	if File_inferencepb_inference_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_inferencepb_inference_proto_rawDesc), len(file_inferencepb_inference_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_inferencepb_inference_proto_goTypes,
+		DependencyIndexes: file_inferencepb_inference_proto_depIdxs,
+		MessageInfos:      file_inferencepb_inference_proto_msgTypes,
+	}.Build()
+	File_inferencepb_inference_proto = out.File
+	file_inferencepb_inference_proto_goTypes = nil
+	file_inferencepb_inference_proto_depIdxs = nil
+}
@@ -0,0 +1,26 @@
+// Package fileutil has the file helpers shared by the packages saving models, indexes and examples
+package fileutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes path with write, replacing it only once write succeeded and the file is closed.
+// The file is written to a temporary file in the same directory then renamed, so readers never see a partial file.
+func WriteFile(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // noop once renamed
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
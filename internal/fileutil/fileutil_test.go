@@ -0,0 +1,33 @@
+package fileutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.bin")
+	if err := WriteFile(path, func(w io.Writer) error {
+		_, err := w.Write([]byte("v1"))
+		return err
+	}); err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	fail := errors.New("fail")
+	if err := WriteFile(path, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return fail
+	}); err != fail {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", fail, err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "v1" {
+		t.Errorf("Invalid File - Want: %v, Got: %s", "v1", b)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Invalid Temporary Files - Want: %v, Got: %v", 1, len(entries))
+	}
+}
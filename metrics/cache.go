@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/cache"
+)
+
+// cacheCollector exports the Stats of model caches when scraped, labelled by model
+type cacheCollector struct {
+	mu     sync.Mutex
+	caches map[string]*cache.Cache
+
+	hits        *prometheus.Desc
+	storeHits   *prometheus.Desc
+	misses      *prometheus.Desc
+	storeErrors *prometheus.Desc
+	evictions   *prometheus.Desc
+	entries     *prometheus.Desc
+	bytes       *prometheus.Desc
+}
+
+func newCacheCollector() *cacheCollector {
+	labels := []string{"model"}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "cache", name), help, labels, nil)
+	}
+	return &cacheCollector{
+		caches:      map[string]*cache.Cache{},
+		hits:        desc("hits_total", "Number of texts found in the cache, including the store."),
+		storeHits:   desc("store_hits_total", "Number of texts found in the persistent store of the cache."),
+		misses:      desc("misses_total", "Number of texts predicted as they weren't cached."),
+		storeErrors: desc("store_errors_total", "Number of failed reads and writes of the persistent store."),
+		evictions:   desc("evictions_total", "Number of texts evicted from memory to fit the limits."),
+		entries:     desc("entries", "Number of texts held in memory."),
+		bytes:       desc("bytes", "Estimated size of the outputs held in memory."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.hits, c.storeHits, c.misses, c.storeErrors, c.evictions, c.entries, c.bytes} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.caches))
+	for name := range c.caches {
+		names = append(names, name)
+	}
+	caches := make([]*cache.Cache, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		caches[i] = c.caches[name]
+	}
+	c.mu.Unlock()
+	for i, name := range names {
+		s := caches[i].Stats()
+		for _, m := range []struct {
+			d *prometheus.Desc
+			t prometheus.ValueType
+			v float64
+		}{
+			{c.hits, prometheus.CounterValue, float64(s.Hits)},
+			{c.storeHits, prometheus.CounterValue, float64(s.StoreHits)},
+			{c.misses, prometheus.CounterValue, float64(s.Misses)},
+			{c.storeErrors, prometheus.CounterValue, float64(s.StoreErrors)},
+			{c.evictions, prometheus.CounterValue, float64(s.Evictions)},
+			{c.entries, prometheus.GaugeValue, float64(s.Entries)},
+			{c.bytes, prometheus.GaugeValue, float64(s.Bytes)},
+		} {
+			ch <- prometheus.MustNewConstMetric(m.d, m.t, m.v, name)
+		}
+	}
+}
+
+// ObserveCache exports the stats of c labelled by model, replacing any cache observed for the same model
+func (m *Metrics) ObserveCache(model string, c *cache.Cache) {
+	m.caches.mu.Lock()
+	defer m.caches.mu.Unlock()
+	m.caches.caches[model] = c
+}
+
+// WrapCache is a model.Wrapper caching models as cache.Wrap does and observing their caches
+func (m *Metrics) WrapCache(mc model.ModelConfig, p model.Predictor) (model.Predictor, error) {
+	wrapped, err := cache.Wrap(mc, p)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := wrapped.(*cache.Cache); ok {
+		m.ObserveCache(mc.ID(), c)
+	}
+	return wrapped, nil
+}
@@ -0,0 +1,128 @@
+// Package metrics exports the stats of model predictions to Prometheus
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Namespace prefixes the name of every metric
+const Namespace = "gobert"
+
+// Metrics is a model.Observer recording batch stats labelled by model
+type Metrics struct {
+	tokenize  *prometheus.HistogramVec
+	tensors   *prometheus.HistogramVec
+	run       *prometheus.HistogramVec
+	batchSize *prometheus.HistogramVec
+	seqLen    *prometheus.HistogramVec
+	texts     *prometheus.CounterVec
+	truncated *prometheus.CounterVec
+	tokens    *prometheus.CounterVec
+	unknown   *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+	caches    *cacheCollector
+}
+
+// New registers the metrics with reg, ex prometheus.DefaultRegisterer
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	labels := []string{"model"}
+	m := &Metrics{
+		tokenize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "tokenize_seconds",
+			Help:      "Time to tokenize a batch of texts.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}, labels),
+		tensors: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "tensor_build_seconds",
+			Help:      "Time to build the input tensors of a batch.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}, labels),
+		run: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "session_run_seconds",
+			Help:      "Time to run a batch through the session, including the wait for a session slot.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, labels),
+		batchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "batch_size",
+			Help:      "Number of texts in a batch.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		}, labels),
+		seqLen: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "sequence_length",
+			Help:      "Number of tokens of a text before truncation.",
+			Buckets:   prometheus.ExponentialBuckets(4, 2, 9),
+		}, labels),
+		texts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "texts_total",
+			Help:      "Number of texts predicted.",
+		}, labels),
+		truncated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "truncated_texts_total",
+			Help:      "Number of texts truncated to fit the seq len.",
+		}, labels),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tokens_total",
+			Help:      "Number of wordpiece tokens predicted.",
+		}, labels),
+		unknown: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "unknown_tokens_total",
+			Help:      "Number of [UNK] tokens predicted, divide by tokens_total for the rate.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "errors_total",
+			Help:      "Number of failed batches.",
+		}, labels),
+		caches: newCacheCollector(),
+	}
+	for _, c := range []prometheus.Collector{
+		m.tokenize, m.tensors, m.run, m.batchSize, m.seqLen,
+		m.texts, m.truncated, m.tokens, m.unknown, m.errors, m.caches,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ObserveBatch records the stats of a batch, durations of stages that didn't run are skipped
+func (m *Metrics) ObserveBatch(s model.BatchStats) {
+	if s.Err != nil {
+		m.errors.WithLabelValues(s.Model).Inc()
+	}
+	for _, d := range []struct {
+		h *prometheus.HistogramVec
+		v float64
+	}{
+		{m.tokenize, s.Tokenize.Seconds()},
+		{m.tensors, s.Tensors.Seconds()},
+		{m.run, s.Run.Seconds()},
+	} {
+		if d.v > 0 {
+			d.h.WithLabelValues(s.Model).Observe(d.v)
+		}
+	}
+	if s.SeqLens == nil { // failed before tokenization
+		return
+	}
+	m.batchSize.WithLabelValues(s.Model).Observe(float64(s.Size))
+	seqLen := m.seqLen.WithLabelValues(s.Model)
+	for _, n := range s.SeqLens {
+		seqLen.Observe(float64(n))
+	}
+	m.texts.WithLabelValues(s.Model).Add(float64(s.Size))
+	m.truncated.WithLabelValues(s.Model).Add(float64(s.Truncated))
+	m.tokens.WithLabelValues(s.Model).Add(float64(s.Tokens))
+	m.unknown.WithLabelValues(s.Model).Add(float64(s.Unknown))
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+)
+
+func TestObserveBatch(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.ObserveBatch(model.BatchStats{
+		Model:     "faq",
+		Size:      2,
+		Tokenize:  time.Millisecond,
+		Run:       10 * time.Millisecond,
+		SeqLens:   []int{4, 20},
+		Truncated: 1,
+		Tokens:    14,
+		Unknown:   2,
+	})
+	m.ObserveBatch(model.BatchStats{Model: "faq", Size: 1, Err: errors.New("fail")})
+	for _, test := range []struct {
+		c    prometheus.Collector
+		want float64
+	}{
+		{m.texts, 2},
+		{m.truncated, 1},
+		{m.tokens, 14},
+		{m.unknown, 2},
+		{m.errors, 1},
+	} {
+		if got := testutil.ToFloat64(test.c.(*prometheus.CounterVec).WithLabelValues("faq")); got != test.want {
+			t.Errorf("Invalid Counter - Want: %v, Got: %v", test.want, got)
+		}
+	}
+	want := `
+# HELP gobert_sequence_length Number of tokens of a text before truncation.
+# TYPE gobert_sequence_length histogram
+gobert_sequence_length_bucket{model="faq",le="4"} 1
+gobert_sequence_length_bucket{model="faq",le="8"} 1
+gobert_sequence_length_bucket{model="faq",le="16"} 1
+gobert_sequence_length_bucket{model="faq",le="32"} 2
+gobert_sequence_length_bucket{model="faq",le="64"} 2
+gobert_sequence_length_bucket{model="faq",le="128"} 2
+gobert_sequence_length_bucket{model="faq",le="256"} 2
+gobert_sequence_length_bucket{model="faq",le="512"} 2
+gobert_sequence_length_bucket{model="faq",le="1024"} 2
+gobert_sequence_length_bucket{model="faq",le="+Inf"} 2
+gobert_sequence_length_sum{model="faq"} 24
+gobert_sequence_length_count{model="faq"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "gobert_sequence_length"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(m.tensors); n != 0 {
+		t.Errorf("Invalid Tensor Build Observations - Want: 0, Got: %d", n)
+	}
+}
+
+// lenModel outputs the length of each text
+func lenModel() *modeltest.Predictor {
+	return modeltest.New(func(texts []string) interface{} {
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			out[i] = []float32{float32(len(text))}
+		}
+		return out
+	})
+}
+
+func TestWrapCache(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := m.WrapCache(model.ModelConfig{Name: "faq", Cache: &model.CacheConfig{}}, lenModel())
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	p.PredictValues("a", "b")
+	p.PredictValues("a")
+	want := `
+# HELP gobert_cache_hits_total Number of texts found in the cache, including the store.
+# TYPE gobert_cache_hits_total counter
+gobert_cache_hits_total{model="faq"} 1
+# HELP gobert_cache_misses_total Number of texts predicted as they weren't cached.
+# TYPE gobert_cache_misses_total counter
+gobert_cache_misses_total{model="faq"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "gobert_cache_hits_total", "gobert_cache_misses_total"); err != nil {
+		t.Error(err)
+	}
+	other := lenModel()
+	if p, err := m.WrapCache(model.ModelConfig{Name: "other"}, other); err != nil || p != model.Predictor(other) {
+		t.Errorf("Invalid Uncached Model - Want: %v, Got: %v %v", other, p, err)
+	}
+}
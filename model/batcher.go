@@ -0,0 +1,266 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Default batching values
+const (
+	DefaultMaxBatch   = 32
+	DefaultMaxWait    = 5 * time.Millisecond
+	DefaultQueueDepth = 1024
+)
+
+// ErrQueueFull is returned when a Batcher has too many requests waiting to be batched
+var ErrQueueFull = errors.New("batch queue is full")
+
+// Batcher gathers concurrent predictions into batched runs of the wrapped model.
+// A batch runs once it has max batch texts or the first request has waited max wait,
+// while a batch runs the next one fills up so batches grow with the load.
+// Results are split back to each caller in order.
+type Batcher struct {
+	p          Predictor
+	maxBatch   int
+	maxWait    time.Duration
+	queueDepth int
+	timeout    time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *batchRequest
+	done   chan struct{}
+}
+
+// batchRequest is a single caller's texts waiting in the queue, with their features if they are tokenized
+type batchRequest struct {
+	ctx   context.Context
+	texts []string
+	fs    []tokenize.Feature
+	res   chan batchResult
+}
+
+type batchResult struct {
+	vals []ValueProvider
+	err  error
+}
+
+// BatchOption configures a Batcher
+type BatchOption func(b *Batcher) *Batcher
+
+// WithMaxBatch sets the max number of texts run together, a larger request is run alone
+func WithMaxBatch(n int) BatchOption {
+	return func(b *Batcher) *Batcher {
+		b.maxBatch = n
+		return b
+	}
+}
+
+// WithMaxWait sets how long a request waits for others to fill its batch
+func WithMaxWait(d time.Duration) BatchOption {
+	return func(b *Batcher) *Batcher {
+		b.maxWait = d
+		return b
+	}
+}
+
+// WithQueueDepth sets the number of requests that can wait for a batch, more are rejected with ErrQueueFull
+func WithQueueDepth(n int) BatchOption {
+	return func(b *Batcher) *Batcher {
+		b.queueDepth = n
+		return b
+	}
+}
+
+// WithBatchTimeout bounds the time a request waits in the queue and runs, a zero timeout only uses the caller's ctx
+func WithBatchTimeout(d time.Duration) BatchOption {
+	return func(b *Batcher) *Batcher {
+		b.timeout = d
+		return b
+	}
+}
+
+// NewBatcher starts batching predictions for p, Close stops it
+func NewBatcher(p Predictor, opts ...BatchOption) *Batcher {
+	b := &Batcher{
+		p:          p,
+		maxBatch:   DefaultMaxBatch,
+		maxWait:    DefaultMaxWait,
+		queueDepth: DefaultQueueDepth,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		b = opt(b)
+	}
+	if b.maxBatch <= 0 {
+		b.maxBatch = 1
+	}
+	b.queue = make(chan *batchRequest, b.queueDepth)
+	go b.run()
+	return b
+}
+
+// SeqLen returns the seq len of the wrapped model, or 0 when it doesn't report one
+func (b *Batcher) SeqLen() int32 {
+	if s, ok := b.p.(interface{ SeqLen() int32 }); ok {
+		return s.SeqLen()
+	}
+	return 0
+}
+
+// Features will tokenize texts with the wrapped model
+func (b *Batcher) Features(texts ...string) []tokenize.Feature {
+	return b.p.Features(texts...)
+}
+
+// PredictValues queues texts to be run in the next batch
+func (b *Batcher) PredictValues(texts ...string) ([]ValueProvider, error) {
+	return b.PredictValuesContext(context.Background(), texts...)
+}
+
+// PredictValuesContext queues texts to be run in the next batch, returning ctx.Err() if ctx is done first.
+// Requests that are done before their batch runs are left out of it.
+func (b *Batcher) PredictValuesContext(ctx context.Context, texts ...string) ([]ValueProvider, error) {
+	return b.wait(ctx, &batchRequest{texts: texts})
+}
+
+// PredictFeaturesContext queues features from Features to be run in the next batch, like PredictValuesContext.
+// A batch of features only is run without tokenizing them again if the wrapped model is a FeaturePredictor.
+func (b *Batcher) PredictFeaturesContext(ctx context.Context, fs ...tokenize.Feature) ([]ValueProvider, error) {
+	texts := make([]string, len(fs))
+	for i, f := range fs {
+		texts[i] = f.Text
+	}
+	return b.wait(ctx, &batchRequest{texts: texts, fs: fs})
+}
+
+// wait queues req and waits for its result
+func (b *Batcher) wait(ctx context.Context, req *batchRequest) ([]ValueProvider, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	req.ctx, req.res = ctx, make(chan batchResult, 1)
+	if err := b.enqueue(req); err != nil {
+		return nil, err
+	}
+	select {
+	case res := <-req.res:
+		return res.vals, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Batcher) enqueue(req *batchRequest) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+	select {
+	case b.queue <- req:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close runs the requests already queued, then closes the wrapped model if it is an io.Closer
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	<-b.done
+	if c, ok := b.p.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// run gathers and runs batches until the queue is closed
+func (b *Batcher) run() {
+	defer close(b.done)
+	var next *batchRequest // carried over when it didn't fit in the previous batch
+	for {
+		if next == nil {
+			req, ok := <-b.queue
+			if !ok {
+				return
+			}
+			next = req
+		}
+		batch, n := []*batchRequest{next}, len(next.texts)
+		next = nil
+		timer := time.NewTimer(b.maxWait)
+	gather:
+		for n < b.maxBatch {
+			select {
+			case req, ok := <-b.queue:
+				if !ok {
+					break gather
+				}
+				if n+len(req.texts) > b.maxBatch {
+					next = req
+					break gather
+				}
+				batch, n = append(batch, req), n+len(req.texts)
+			case <-timer.C:
+				break gather
+			}
+		}
+		timer.Stop()
+		b.predict(batch)
+	}
+}
+
+// predict runs the requests of a batch that are still waiting as a single prediction
+func (b *Batcher) predict(batch []*batchRequest) {
+	live := batch[:0]
+	var texts []string
+	var fs []tokenize.Feature
+	tokenized := true // every request has its features
+	for _, req := range batch {
+		if req.ctx.Err() == nil {
+			live = append(live, req)
+			texts = append(texts, req.texts...)
+			fs = append(fs, req.fs...)
+			tokenized = tokenized && req.fs != nil
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+	ctx := live[0].ctx
+	if len(live) > 1 {
+		ctx = context.Background() // callers still return when their own ctx is done
+	}
+	var vals []ValueProvider
+	var err error
+	if fp, ok := b.p.(FeaturePredictor); ok && tokenized {
+		vals, err = fp.PredictFeaturesContext(ctx, fs...)
+	} else {
+		vals, err = b.p.PredictValuesContext(ctx, texts...)
+	}
+	from := 0
+	for _, req := range live {
+		to := from + len(req.texts)
+		res := batchResult{err: err}
+		if err == nil {
+			res.vals, res.err = sliceValues(vals, from, to)
+		}
+		req.res <- res
+		from = to
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcherGathers(t *testing.T) {
+	p := &fakePredictor{}
+	b := NewBatcher(newFakeBert(p), WithMaxBatch(4), WithMaxWait(100*time.Millisecond))
+	defer b.Close()
+	texts := []string{"a", "b", "c", "d", "e", "f", "g"}
+	got := make([]interface{}, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			vals, err := b.PredictValues(text)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got[i] = vals[0].Value()
+		}(i, text)
+	}
+	wg.Wait()
+	for i := range texts {
+		if want := [][]float32{{float32(i + 1)}}; !reflect.DeepEqual(got[i], want) {
+			t.Errorf("Invalid Values for %s - Want: %v, Got: %v", texts[i], want, got[i])
+		}
+	}
+	// 4 texts fill a batch, the remaining 3 run after max wait
+	if p.calls != 2 {
+		t.Errorf("Invalid Session Runs - Want: 2, Got: %d", p.calls)
+	}
+}
+
+func TestBatcherMaxWait(t *testing.T) {
+	p := &fakePredictor{}
+	b := NewBatcher(newFakeBert(p), WithMaxBatch(8), WithMaxWait(10*time.Millisecond))
+	defer b.Close()
+	vals, err := b.PredictValues("c", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{3}, {1}}; !reflect.DeepEqual(vals[0].Value(), want) {
+		t.Errorf("Invalid Values - Want: %v, Got: %v", want, vals[0].Value())
+	}
+}
+
+func TestBatcherErrors(t *testing.T) {
+	p := &fakePredictor{delay: 50 * time.Millisecond}
+	b := NewBatcher(newFakeBert(p), WithMaxBatch(1), WithQueueDepth(1), WithBatchTimeout(20*time.Millisecond))
+	if _, err := b.PredictValues("a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", context.DeadlineExceeded, err)
+	}
+	b.Close()
+
+	// the first request is running and the second fills the queue
+	p = &fakePredictor{started: make(chan struct{}), release: make(chan struct{})}
+	b = NewBatcher(newFakeBert(p), WithMaxBatch(1), WithQueueDepth(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.PredictValuesContext(ctx, "a")
+	<-p.started
+	go b.PredictValuesContext(ctx, "a")
+	for len(b.queue) == 0 {
+		runtime.Gosched()
+	}
+	if _, err := b.PredictValues("b"); err != ErrQueueFull {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", ErrQueueFull, err)
+	}
+	cancel()
+	close(p.release)
+	b.Close()
+	if _, err := b.PredictValues("a"); err != ErrClosed {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", ErrClosed, err)
+	}
+}
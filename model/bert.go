@@ -2,13 +2,17 @@
 package model
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/sunhailin-Leo/gobert/model/estimator"
 	"github.com/sunhailin-Leo/gobert/tokenize"
 	"github.com/sunhailin-Leo/gobert/tokenize/vocab"
 	tf "github.com/tensorflow/tensorflow/tensorflow/go"
 	"github.com/valyala/bytebufferpool"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Operation names
@@ -36,26 +40,69 @@ type ValueProvider interface {
 	Value() interface{}
 }
 
+// Predictor runs texts through a model, it is implemented by Bert and the wrappers composed around it
+type Predictor interface {
+	Features(texts ...string) []tokenize.Feature
+	PredictValues(texts ...string) ([]ValueProvider, error)
+	PredictValuesContext(ctx context.Context, texts ...string) ([]ValueProvider, error)
+}
+
+// FeaturePredictor is a Predictor that can run features already tokenized by its Features, see PredictFeatures
+type FeaturePredictor interface {
+	PredictFeaturesContext(ctx context.Context, fs ...tokenize.Feature) ([]ValueProvider, error)
+}
+
+// PredictFeatures runs m on features from its Features, without tokenizing their texts again if m is a FeaturePredictor
+func PredictFeatures(ctx context.Context, m Predictor, fs []tokenize.Feature) ([]ValueProvider, error) {
+	if fp, ok := m.(FeaturePredictor); ok {
+		return fp.PredictFeaturesContext(ctx, fs...)
+	}
+	texts := make([]string, len(fs))
+	for i, f := range fs {
+		texts[i] = f.Text
+	}
+	return m.PredictValuesContext(ctx, texts...)
+}
+
 // Bert is a model that translates features to values from an exported model. It processes as follows:
 // Pipeline: text -> FeatureFactory -> TensorFunc -> InputFunc -> ModelFunc -> Value
 type Bert struct {
 	m          *tf.SavedModel
-	p          estimator.Predictor
+	p          estimator.ContextPredictor
 	factory    *tokenize.FeatureFactory
 	modelFunc  estimator.ModelFunc
 	inputFunc  TensorInputFunc
 	tensorFunc FeatureTensorFunc
-	verbose    bool
+	batchSize  int
+	loader     loader
+	name       string
+	logger     *slog.Logger
+	observer   Observer
+
+	tracerProvider trace.TracerProvider
+	calibration    *Calibration
+
+	concurrency int
+	failFast    bool
+	pool        *estimator.Pool
 }
 
+// ErrSaturated is returned by fail-fast models when every session slot is in use
+var ErrSaturated = estimator.ErrSaturated
+
 // NewBert will create a new default BERT model from the exported model and vocab.
+// The vocab file isn't read if one is supplied with WithVocab.
 // Generally used for producing embeddings
 func NewBert(m *tf.SavedModel, vocabPath string, opts ...BertOption) (Bert, error) {
-	voc, err := vocab.FromFile(vocabPath)
-	if err != nil {
-		return Bert{}, err
+	voc := loaderFrom(opts).vocab
+	if voc == nil {
+		v, err := vocab.FromFile(vocabPath)
+		if err != nil {
+			return Bert{}, err
+		}
+		voc = &v
 	}
-	tkz := tokenize.NewTokenizer(voc, bytebufferpool.Get())
+	tkz := tokenize.NewTokenizer(*voc, bytebufferpool.Get())
 	b := Bert{
 		m:          m,
 		factory:    &tokenize.FeatureFactory{Tokenizer: tkz, SeqLen: DefaultSeqLen},
@@ -81,11 +128,25 @@ func NewBert(m *tf.SavedModel, vocabPath string, opts ...BertOption) (Bert, erro
 	for _, opt := range opts {
 		b = opt(b)
 	}
-	b.p = estimator.NewPredictor(m, b.modelFunc)
+	b.pool = estimator.NewPool(b.concurrency, b.failFast)
+	b.p = estimator.NewPredictor(m, b.modelFunc, estimator.WithPool(b.pool))
 	return b, nil
 
 }
 
+// SeqLen returns the sequence length features are padded or truncated to
+func (b Bert) SeqLen() int32 {
+	return b.factory.SeqLen
+}
+
+// PoolStats reports the session runs of the model and the time they waited for a slot
+func (b Bert) PoolStats() estimator.PoolStats {
+	if b.pool == nil {
+		return estimator.PoolStats{}
+	}
+	return b.pool.Stats()
+}
+
 // Features will tokenize a text
 func (b Bert) Features(texts ...string) []tokenize.Feature {
 	return b.factory.Features(texts...)
@@ -94,31 +155,163 @@ func (b Bert) Features(texts ...string) []tokenize.Feature {
 // PredictValues will run the BERT model on the provided texts.
 // The returned values are in the same order as the provided texts.
 func (b Bert) PredictValues(texts ...string) ([]ValueProvider, error) {
-	b.println("Building Features...")
-	fs := b.factory.Features(texts...)
+	return b.PredictValuesContext(context.Background(), texts...)
+}
+
+// PredictValuesContext will run the BERT model on the provided texts, returning ctx.Err() if ctx is done first.
+// Texts are split into sub-batches when a batch size is set, ctx is checked between each of them.
+// The returned values are in the same order as the provided texts.
+// A span is recorded for the prediction with a child span for each stage of each sub-batch, see WithTracerProvider.
+func (b Bert) PredictValuesContext(ctx context.Context, texts ...string) ([]ValueProvider, error) {
+	return b.predict(ctx, len(texts), func(ctx context.Context, from, to int) ([]ValueProvider, error) {
+		return b.predictBatch(ctx, texts[from:to], nil)
+	})
+}
+
+// PredictFeaturesContext runs the model on features already tokenized by Features, like PredictValuesContext
+// without tokenizing the texts again, ex when the features are also needed to pool embeddings
+func (b Bert) PredictFeaturesContext(ctx context.Context, fs ...tokenize.Feature) ([]ValueProvider, error) {
+	return b.predict(ctx, len(fs), func(ctx context.Context, from, to int) ([]ValueProvider, error) {
+		return b.predictBatch(ctx, nil, fs[from:to])
+	})
+}
+
+// predict splits n inputs into sub-batches predicted by batch in order and joins their values
+func (b Bert) predict(ctx context.Context, n int, batch func(ctx context.Context, from, to int) ([]ValueProvider, error)) (vals []ValueProvider, err error) {
+	ctx, span := b.startSpan(ctx, SpanPredict, n)
+	defer func() { endSpan(span, err) }()
+	size := b.batchSize
+	if size <= 0 || size > n {
+		size = n
+	}
+	if size == 0 {
+		size = 1
+	}
+	for from := 0; from == 0 || from < n; from += size {
+		to := from + size
+		if to > n {
+			to = n
+		}
+		res, err := batch(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if vals, err = concatValues(vals, res); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// predictBatch runs a single batch through the pipeline, checking ctx between each stage.
+// The texts are tokenized unless their features fs are given.
+func (b Bert) predictBatch(ctx context.Context, texts []string, fs []tokenize.Feature) (vals []ValueProvider, err error) {
+	n := len(texts)
+	if fs != nil {
+		n = len(fs)
+	}
+	stats := BatchStats{Model: b.name, Size: n}
+	defer func() {
+		stats.Err = err
+		b.observe(ctx, stats)
+	}()
+	start := time.Now()
+	sctx, span := b.startSpan(ctx, SpanFeatures, n)
+	if fs == nil {
+		fs, err = b.factory.FeaturesContext(sctx, texts...)
+	}
+	if err == nil {
+		stats.countTokens(fs)
+		span.SetAttributes(AttrTruncated.Int(stats.Truncated), AttrUnknown.Int(stats.Unknown))
+	}
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	stats.Tokenize = time.Since(start)
+	start = time.Now()
+	_, span = b.startSpan(ctx, SpanTensors, n)
 	inputs, err := b.tensorFunc(fs...)
+	endSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
-	b.println("Done Building")
-	b.println("Predicting...")
-	res, err := b.p.Predict(b.inputFunc(inputs))
+	stats.Tensors = time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	sctx, span = b.startSpan(ctx, SpanRun, n)
+	feed := b.inputFunc(inputs)
+	res, err := b.p.PredictContext(sctx, func(m *tf.SavedModel) map[tf.Output]*tf.Tensor {
+		_, span := b.startSpan(sctx, SpanInput, n)
+		defer span.End()
+		return feed(m)
+	})
+	endSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
-	b.println("Done Predicting")
-	vals := make([]ValueProvider, len(res))
+	stats.Run = time.Since(start)
+	_, span = b.startSpan(ctx, SpanValues, n)
+	vals = make([]ValueProvider, len(res))
 	for i, t := range res {
 		vals[i] = ValueProvider(t)
 	}
-	b.println("Done Value Casting")
+	if b.calibration != nil && len(vals) > 0 {
+		probs, ok := vals[0].Value().([][]float32)
+		if !ok {
+			err = fmt.Errorf("can't calibrate %T, expected class probabilities [][]float32", vals[0].Value())
+			endSpan(span, err)
+			return nil, err
+		}
+		if len(probs) > 0 {
+			if err = b.calibration.CheckClasses(len(probs[0])); err != nil {
+				endSpan(span, err)
+				return nil, err
+			}
+		}
+		vals[0] = value{v: b.calibration.CalibrateAll(probs)}
+	}
+	span.End()
 	return vals, nil
 }
 
-func (b Bert) println(msg ...interface{}) {
-	if b.verbose {
-		fmt.Println(msg...)
+// Calibration returns the calibration of the class probabilities of the model, nil if they are uncalibrated
+func (b Bert) Calibration() *Calibration {
+	return b.calibration
+}
+
+// observe reports the stats of a batch to the observer and logger
+func (b Bert) observe(ctx context.Context, s BatchStats) {
+	if b.observer != nil {
+		b.observer.ObserveBatch(s)
+	}
+	if b.logger == nil || !b.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []interface{}{
+		slog.String("model", s.Model),
+		slog.Int("size", s.Size),
+		slog.Duration("tokenize", s.Tokenize),
+		slog.Duration("tensors", s.Tensors),
+		slog.Duration("run", s.Run),
+		slog.Int("truncated", s.Truncated),
+		slog.Int("unknown", s.Unknown),
+	}
+	if s.Err != nil {
+		b.logger.DebugContext(ctx, "batch failed", append(attrs, slog.Any("error", s.Err))...)
+		return
+	}
+	b.logger.DebugContext(ctx, "predicted batch", attrs...)
+}
+
+// Close releases the tensorflow session of the model, it can't be used afterwards
+func (b Bert) Close() error {
+	if b.m == nil {
+		return nil
 	}
+	return b.m.Session.Close()
 }
 
 // Print is a utility for printing the operations in a saved model
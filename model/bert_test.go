@@ -0,0 +1,103 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/model/estimator"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+	"github.com/sunhailin-Leo/gobert/tokenize/vocab"
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+	"github.com/valyala/bytebufferpool"
+)
+
+// fakePredictor stands in for a session, each output row is the id of the first token of a text
+type fakePredictor struct {
+	delay time.Duration
+	calls int32
+	err   error
+	// started and release hold calls in flight when set, started is sent to as a call starts
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *fakePredictor) Predict(fn estimator.InputFunc) ([]*tf.Tensor, error) {
+	return p.PredictContext(context.Background(), fn)
+}
+
+func (p *fakePredictor) PredictContext(ctx context.Context, fn estimator.InputFunc) ([]*tf.Tensor, error) {
+	atomic.AddInt32(&p.calls, 1)
+	if p.started != nil {
+		p.started <- struct{}{}
+		<-p.release
+	}
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	ids := fn(nil)[tf.Output{}].Value().([][]int32)
+	out := make([][]float32, len(ids))
+	for i, row := range ids {
+		out[i] = []float32{float32(row[1])}
+	}
+	t, err := tf.NewTensor(out)
+	return []*tf.Tensor{t}, err
+}
+
+// testVocab ids match the letters, ex "c" -> 3
+var testVocab = []string{"[CLS]", "a", "b", "c", "d", "e", "f", "g", "[SEP]", "[UNK]"}
+
+// newFakeBert returns a Bert wired to p instead of a tensorflow session
+func newFakeBert(p estimator.ContextPredictor, opts ...BertOption) Bert {
+	voc := vocab.New(testVocab)
+	b := Bert{
+		p:          p,
+		factory:    &tokenize.FeatureFactory{Tokenizer: tokenize.NewTokenizer(voc, bytebufferpool.Get()), SeqLen: 8},
+		tensorFunc: tensors,
+		inputFunc: func(inputs map[string]*tf.Tensor) estimator.InputFunc {
+			return func(*tf.SavedModel) map[tf.Output]*tf.Tensor {
+				return map[tf.Output]*tf.Tensor{{}: inputs[InputIDsOp]}
+			}
+		},
+	}
+	for _, opt := range opts {
+		b = opt(b)
+	}
+	return b
+}
+
+func TestPredictValuesContextBatches(t *testing.T) {
+	p := &fakePredictor{}
+	b := newFakeBert(p, WithBatchSize(2))
+	vals, err := b.PredictValuesContext(context.Background(), "a", "b", "c", "d", "e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float32{{1}, {2}, {3}, {4}, {5}}
+	if got := vals[0].Value(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid Values - Want: %v, Got: %v", want, got)
+	}
+	if p.calls != 3 {
+		t.Errorf("Invalid Batch Count - Want: 3, Got: %d", p.calls)
+	}
+}
+
+func TestPredictValuesContextDeadline(t *testing.T) {
+	p := &fakePredictor{delay: 20 * time.Millisecond}
+	b := newFakeBert(p, WithBatchSize(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := b.PredictValuesContext(ctx, "a", "b", "c", "d"); err != context.DeadlineExceeded {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", context.DeadlineExceeded, err)
+	}
+	if p.calls >= 4 {
+		t.Errorf("Expected remaining batches to be abandoned, Got %d calls", p.calls)
+	}
+}
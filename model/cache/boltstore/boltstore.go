@@ -0,0 +1,63 @@
+// Package boltstore is a cache.Store persisted to a local bbolt file
+package boltstore
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultBucket holds the entries unless another bucket is set with Open
+const DefaultBucket = "gobert"
+
+// Store is a cache.Store backed by a bbolt database file
+type Store struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// Open opens or creates the database at path, entries go to bucket or DefaultBucket if empty.
+// A file can only be opened by one process at a time, Open waits up to a second for it.
+func Open(path, bucket string) (*Store, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db, bucket: []byte(bucket)}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns a copy of the value of key
+func (s *Store) Get(key string) ([]byte, bool, error) {
+	var val []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(s.bucket).Get([]byte(key)); v != nil {
+			val = append([]byte{}, v...) // only valid during the transaction
+		}
+		return nil
+	})
+	return val, val != nil, err
+}
+
+// Set writes the value of key, concurrent calls are committed together
+func (s *Store) Set(key string, val []byte) error {
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(key), val)
+	})
+}
+
+// Close closes the database file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
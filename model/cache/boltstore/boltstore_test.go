@@ -0,0 +1,37 @@
+package boltstore
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	s, err := Open(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("a", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Open(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, test := range []struct {
+		key string
+		val string
+		ok  bool
+	}{
+		{"a", "x", true},
+		{"b", "", false},
+	} {
+		val, ok, err := s.Get(test.key)
+		if err != nil || ok != test.ok || string(val) != test.val {
+			t.Errorf("Invalid Get %s - Want: %q %v, Got: %q %v %v", test.key, test.val, test.ok, val, ok, err)
+		}
+	}
+}
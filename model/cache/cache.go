@@ -0,0 +1,330 @@
+// Package cache memoizes model predictions by text so repeated texts skip the session run
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// DefaultMaxEntries is the number of texts held in memory unless set with WithMaxEntries
+const DefaultMaxEntries = 10000
+
+// Cache is a model.Predictor that returns cached outputs for texts it has already predicted.
+// Lookups go to the in-memory LRU, then the persistent store if one is set, and only the misses of a batch
+// are predicted. Keys are the model ID, seq len and normalized text so models can share a store.
+// Cached outputs are shared between calls and must not be modified. It is safe for concurrent use.
+type Cache struct {
+	p         model.Predictor
+	id        string
+	seqLen    int32
+	normalize func(string) string
+	lru       *lru
+	store     Store
+	ttl       time.Duration
+
+	hits      int64
+	storeHits int64
+	misses    int64
+	errors    int64
+}
+
+// Stats are the lookups of a Cache, a hit in the store also counts as a hit
+type Stats struct {
+	Hits      int64
+	StoreHits int64
+	Misses    int64
+	// StoreErrors counts failed store reads and writes, they are treated as misses
+	StoreErrors int64
+	Entries     int
+	Bytes       int64
+	Evictions   int64
+}
+
+// HitRate is the fraction of lookups that were hits
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Option configures a Cache
+type Option func(c *Cache) *Cache
+
+// WithMaxEntries limits the number of texts held in memory, 0 disables the in-memory cache
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) *Cache {
+		c.lru.maxEntries = n
+		return c
+	}
+}
+
+// WithMaxBytes limits the estimated size of the outputs held in memory, 0 is unlimited
+func WithMaxBytes(n int64) Option {
+	return func(c *Cache) *Cache {
+		c.lru.maxBytes = n
+		return c
+	}
+}
+
+// WithTTL expires entries d after they are predicted, 0 never expires them
+func WithTTL(d time.Duration) Option {
+	return func(c *Cache) *Cache {
+		c.ttl = d
+		return c
+	}
+}
+
+// WithStore persists entries to s, it is checked when a text isn't in memory
+func WithStore(s Store) Option {
+	return func(c *Cache) *Cache {
+		c.store = s
+		return c
+	}
+}
+
+// WithNormalizer replaces Normalize for building keys, ex to also fold case for uncased models
+func WithNormalizer(fn func(string) string) Option {
+	return func(c *Cache) *Cache {
+		c.normalize = fn
+		return c
+	}
+}
+
+// New caches the predictions of p, id identifies the model and its version in keys
+func New(p model.Predictor, id string, opts ...Option) *Cache {
+	c := &Cache{
+		p:         p,
+		id:        id,
+		normalize: Normalize,
+		lru:       newLRU(DefaultMaxEntries),
+	}
+	if s, ok := p.(interface{ SeqLen() int32 }); ok {
+		c.seqLen = s.SeqLen()
+	}
+	for _, opt := range opts {
+		c = opt(c)
+	}
+	return c
+}
+
+// Wrap is a model.Wrapper caching the models whose config sets a cache, keyed by their ID.
+// The cache is in memory only, a store is set with New.
+func Wrap(mc model.ModelConfig, p model.Predictor) (model.Predictor, error) {
+	if mc.Cache == nil {
+		return p, nil
+	}
+	var opts []Option
+	if mc.Cache.MaxEntries > 0 {
+		opts = append(opts, WithMaxEntries(mc.Cache.MaxEntries))
+	}
+	if mc.Cache.MaxBytes > 0 {
+		opts = append(opts, WithMaxBytes(mc.Cache.MaxBytes))
+	}
+	if mc.Cache.TTLSeconds > 0 {
+		opts = append(opts, WithTTL(time.Duration(mc.Cache.TTLSeconds)*time.Second))
+	}
+	return New(p, mc.ID(), opts...), nil
+}
+
+// Normalize trims and collapses whitespace, texts that only differ by it tokenize the same
+func Normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// SeqLen returns the seq len of the cached model
+func (c *Cache) SeqLen() int32 {
+	return c.seqLen
+}
+
+// Features will tokenize texts with the cached model
+func (c *Cache) Features(texts ...string) []tokenize.Feature {
+	return c.p.Features(texts...)
+}
+
+// PredictValues returns cached outputs of texts, predicting the ones that aren't cached
+func (c *Cache) PredictValues(texts ...string) ([]model.ValueProvider, error) {
+	return c.PredictValuesContext(context.Background(), texts...)
+}
+
+// PredictValuesContext returns cached outputs of texts, predicting the ones that aren't cached with ctx.
+// Repeated texts in a batch are predicted once.
+func (c *Cache) PredictValuesContext(ctx context.Context, texts ...string) ([]model.ValueProvider, error) {
+	return c.predict(ctx, texts, nil)
+}
+
+// PredictFeaturesContext returns cached outputs of features from Features, like PredictValuesContext.
+// The features that aren't cached are predicted without tokenizing them again, see model.PredictFeatures.
+func (c *Cache) PredictFeaturesContext(ctx context.Context, fs ...tokenize.Feature) ([]model.ValueProvider, error) {
+	texts := make([]string, len(fs))
+	for i, f := range fs {
+		texts[i] = f.Text
+	}
+	return c.predict(ctx, texts, fs)
+}
+
+// predict returns the outputs of texts, the features of the texts are used for misses if fs isn't nil
+func (c *Cache) predict(ctx context.Context, texts []string, fs []tokenize.Feature) ([]model.ValueProvider, error) {
+	rows := make([][]interface{}, len(texts)) // outputs of each text
+	keys := make([]string, len(texts))
+	missing := map[string][]int{} // key to indexes of texts
+	var misses []string
+	var missFs []tokenize.Feature
+	now := time.Now()
+	for i, text := range texts {
+		keys[i] = c.key(text)
+		if idx, ok := missing[keys[i]]; ok {
+			missing[keys[i]] = append(idx, i)
+			continue
+		}
+		if r, ok := c.lookup(keys[i], now); ok {
+			rows[i] = r
+			continue
+		}
+		missing[keys[i]] = []int{i}
+		misses = append(misses, text)
+		if fs != nil {
+			missFs = append(missFs, fs[i])
+		}
+	}
+	if len(misses) > 0 {
+		var vals []model.ValueProvider
+		var err error
+		if fs != nil {
+			vals, err = model.PredictFeatures(ctx, c.p, missFs)
+		} else {
+			vals, err = c.p.PredictValuesContext(ctx, misses...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j, text := range misses {
+			r, err := row(vals, j)
+			if err != nil {
+				return nil, err
+			}
+			key := c.key(text)
+			c.add(key, r, now)
+			for _, i := range missing[key] {
+				rows[i] = r
+			}
+		}
+	}
+	return join(rows)
+}
+
+// Stats returns the lookups so far and the size of the in-memory cache
+func (c *Cache) Stats() Stats {
+	entries, bytes, evictions := c.lru.stats()
+	return Stats{
+		Hits:        atomic.LoadInt64(&c.hits),
+		StoreHits:   atomic.LoadInt64(&c.storeHits),
+		Misses:      atomic.LoadInt64(&c.misses),
+		StoreErrors: atomic.LoadInt64(&c.errors),
+		Entries:     entries,
+		Bytes:       bytes,
+		Evictions:   evictions,
+	}
+}
+
+// Purge empties the in-memory cache, the store is left as is
+func (c *Cache) Purge() {
+	c.lru.purge()
+}
+
+// Close closes the cached model if it is an io.Closer, the store is closed by its owner
+func (c *Cache) Close() error {
+	if cl, ok := c.p.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+func (c *Cache) key(text string) string {
+	return c.id + "\x00" + strconv.Itoa(int(c.seqLen)) + "\x00" + c.normalize(text)
+}
+
+// lookup finds the outputs of key in memory then in the store, store hits are promoted to memory
+func (c *Cache) lookup(key string, now time.Time) ([]interface{}, bool) {
+	if r, ok := c.lru.get(key, now); ok {
+		atomic.AddInt64(&c.hits, 1)
+		return r, true
+	}
+	if c.store != nil {
+		e, ok, err := load(c.store, key)
+		if err != nil {
+			atomic.AddInt64(&c.errors, 1)
+		} else if ok && (e.Expires.IsZero() || now.Before(e.Expires)) {
+			atomic.AddInt64(&c.hits, 1)
+			atomic.AddInt64(&c.storeHits, 1)
+			c.lru.add(key, e.Rows, e.Expires)
+			return e.Rows, true
+		}
+	}
+	atomic.AddInt64(&c.misses, 1)
+	return nil, false
+}
+
+func (c *Cache) add(key string, r []interface{}, now time.Time) {
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = now.Add(c.ttl)
+	}
+	c.lru.add(key, r, expires)
+	if c.store != nil {
+		if err := save(c.store, key, entry{Rows: r, Expires: expires}); err != nil {
+			atomic.AddInt64(&c.errors, 1)
+		}
+	}
+}
+
+// value is a ValueProvider for outputs assembled from cached rows
+type value struct {
+	v interface{}
+}
+
+func (v value) Value() interface{} {
+	return v.v
+}
+
+// row returns the i-th row of each output
+func row(vals []model.ValueProvider, i int) ([]interface{}, error) {
+	r := make([]interface{}, len(vals))
+	for k, v := range vals {
+		x := reflect.ValueOf(v.Value())
+		if x.Kind() != reflect.Slice || x.Len() <= i {
+			return nil, fmt.Errorf("output %d can't be cached, %T has no row %d", k, v.Value(), i)
+		}
+		r[k] = x.Index(i).Interface()
+	}
+	return r, nil
+}
+
+// join stacks the rows of each text back into batched outputs
+func join(rows [][]interface{}) ([]model.ValueProvider, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	vals := make([]model.ValueProvider, len(rows[0]))
+	for k := range vals {
+		t := reflect.TypeOf(rows[0][k])
+		x := reflect.MakeSlice(reflect.SliceOf(t), len(rows), len(rows))
+		for i, r := range rows {
+			if len(r) != len(vals) || reflect.TypeOf(r[k]) != t {
+				return nil, fmt.Errorf("cached outputs of text %d don't match the model outputs", i)
+			}
+			x.Index(i).Set(reflect.ValueOf(r[k]))
+		}
+		vals[k] = value{v: x.Interface()}
+	}
+	return vals, nil
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+)
+
+// fakeModel outputs the length of each text
+func fakeModel() *modeltest.Predictor {
+	return modeltest.New(func(texts []string) interface{} {
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			out[i] = []float32{float32(len(text))}
+		}
+		return out
+	})
+}
+
+type mapStore struct {
+	sync.Mutex
+	m map[string][]byte
+}
+
+func (s *mapStore) Get(key string) ([]byte, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	v, ok := s.m[key]
+	return v, ok, nil
+}
+
+func (s *mapStore) Set(key string, val []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	s.m[key] = val
+	return nil
+}
+
+func TestCachePartialBatch(t *testing.T) {
+	m := fakeModel()
+	c := New(m, "faq/1")
+	if _, err := c.PredictValues("a", "bb"); err != nil {
+		t.Fatal(err)
+	}
+	vals, err := c.PredictValues("ccc", " a ", "bb", "ccc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{3}, {1}, {2}, {3}}; !reflect.DeepEqual(vals[0].Value(), want) {
+		t.Errorf("Invalid Values - Want: %v, Got: %v", want, vals[0].Value())
+	}
+	if want := []string{"a", "bb", "ccc"}; !reflect.DeepEqual(m.Predicted(), want) {
+		t.Errorf("Invalid Predicted Texts - Want: %v, Got: %v", want, m.Predicted())
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 3 || s.Entries != 3 {
+		t.Errorf("Invalid Stats - Got: %+v", s)
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	m := fakeModel()
+	c := New(m, "faq/1", WithMaxEntries(2), WithTTL(time.Hour))
+	c.PredictValues("a", "b", "c") // a is evicted
+	c.PredictValues("a", "c")
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(m.Predicted(), want) {
+		t.Errorf("Invalid Predicted Texts - Want: %v, Got: %v", want, m.Predicted())
+	}
+	if s := c.Stats(); s.Evictions != 2 || s.Entries != 2 {
+		t.Errorf("Invalid Stats - Got: %+v", s)
+	}
+	if _, ok := c.lru.get(c.key("a"), time.Now().Add(2*time.Hour)); ok {
+		t.Errorf("Invalid Expiry - Want: expired after TTL")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	m := fakeModel()
+	if p, err := Wrap(model.ModelConfig{Name: "faq"}, m); err != nil || p != model.Predictor(m) {
+		t.Errorf("Invalid Uncached Model - Want: %v, Got: %v %v", m, p, err)
+	}
+	p, err := Wrap(model.ModelConfig{Name: "faq", Version: "1", Cache: &model.CacheConfig{MaxEntries: 2, TTLSeconds: 60}}, m)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	c, ok := p.(*Cache)
+	if !ok {
+		t.Fatalf("Invalid Model - Want: %T, Got: %T", c, p)
+	}
+	if c.id != "faq:1" || c.lru.maxEntries != 2 || c.ttl != time.Minute {
+		t.Errorf("Invalid Cache - Want: %v %v %v, Got: %v %v %v", "faq:1", 2, time.Minute, c.id, c.lru.maxEntries, c.ttl)
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	m := fakeModel()
+	store := &mapStore{m: map[string][]byte{}}
+	c := New(m, "faq/1", WithStore(store))
+	c.PredictValues("a", "bb")
+	c.Purge()
+	vals, err := c.PredictValues("bb", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{2}, {1}}; !reflect.DeepEqual(vals[0].Value(), want) {
+		t.Errorf("Invalid Values - Want: %v, Got: %v", want, vals[0].Value())
+	}
+	if s := c.Stats(); s.StoreHits != 2 || len(m.Predicted()) != 2 {
+		t.Errorf("Invalid Stats - Got: %+v, predicted %v", s, m.Predicted())
+	}
+	// keys include the model id, another model doesn't share entries
+	other := New(m, "faq/2", WithStore(store))
+	other.PredictValues("a")
+	if len(m.Predicted()) != 3 {
+		t.Errorf("Invalid Predicted Texts - Got: %v", m.Predicted())
+	}
+}
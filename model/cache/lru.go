@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// lru is an in-memory cache evicting the least recently used entries over its limits
+type lru struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	evictions  int64
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type lruItem struct {
+	key     string
+	rows    []interface{}
+	size    int64
+	expires time.Time
+}
+
+func newLRU(maxEntries int) *lru {
+	return &lru{maxEntries: maxEntries, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// get returns the rows of key unless they expired before now
+func (c *lru) get(key string, now time.Time) ([]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	it := el.Value.(*lruItem)
+	if !it.expires.IsZero() && !now.Before(it.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return it.rows, true
+}
+
+func (c *lru) add(key string, rows []interface{}, expires time.Time) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	it := &lruItem{key: key, rows: rows, size: sizeOf(rows), expires: expires}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	c.items[key] = c.ll.PushFront(it)
+	c.bytes += it.size
+	for c.ll.Len() > c.maxEntries || (c.maxBytes > 0 && c.bytes > c.maxBytes && c.ll.Len() > 0) {
+		c.remove(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *lru) remove(el *list.Element) {
+	it := c.ll.Remove(el).(*lruItem)
+	delete(c.items, it.key)
+	c.bytes -= it.size
+}
+
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+	c.bytes = 0
+}
+
+func (c *lru) stats() (entries int, bytes, evictions int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len(), c.bytes, c.evictions
+}
+
+// sizeOf estimates the memory held by rows of numeric slices
+func sizeOf(rows []interface{}) int64 {
+	var n int64
+	for _, r := range rows {
+		n += valueSize(reflect.ValueOf(r))
+	}
+	return n
+}
+
+func valueSize(x reflect.Value) int64 {
+	switch x.Kind() {
+	case reflect.Slice:
+		n := int64(x.Type().Size()) // slice header
+		if x.Len() == 0 {
+			return n
+		}
+		if k := x.Type().Elem().Kind(); k != reflect.Slice && k != reflect.String {
+			return n + int64(x.Len())*int64(x.Type().Elem().Size())
+		}
+		for i := 0; i < x.Len(); i++ {
+			n += valueSize(x.Index(i))
+		}
+		return n
+	case reflect.String:
+		return int64(x.Type().Size()) + int64(x.Len())
+	}
+	return int64(x.Type().Size())
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// Store persists cached outputs, ex across restarts. Implementations must be safe for concurrent use.
+// Values are opaque encoded entries, Get reports false for keys that were never set.
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, val []byte) error
+}
+
+// entry is the encoded form of the outputs of a text
+type entry struct {
+	Rows    []interface{}
+	Expires time.Time
+}
+
+func init() {
+	// row types of the outputs of exported models
+	for _, v := range []interface{}{
+		[]float32{}, [][]float32{}, [][][]float32{},
+		[]float64{}, [][]float64{},
+		[]int32{}, [][]int32{},
+		[]int64{}, [][]int64{},
+		float32(0), int32(0), int64(0), "",
+	} {
+		gob.Register(v)
+	}
+}
+
+func load(s Store, key string) (entry, bool, error) {
+	b, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return entry{}, false, err
+	}
+	var e entry
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&e); err != nil {
+		return entry{}, false, err
+	}
+	return e, true, nil
+}
+
+func save(s Store, key string, e entry) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return err
+	}
+	return s.Set(key, buf.Bytes())
+}
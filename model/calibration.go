@@ -0,0 +1,315 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sunhailin-Leo/gobert/internal/fileutil"
+)
+
+// CalibrationFile is the file of the calibration of a classifier in its export dir, read by NewBertClassifier
+const CalibrationFile = "calibration.json"
+
+// Calibration methods
+const (
+	TemperatureScaling = "temperature"
+	IsotonicRegression = "isotonic"
+)
+
+// minProb bounds probabilities away from 0 before taking their log
+const minProb = 1e-12
+
+// Calibration maps the class probabilities of a classifier to calibrated ones, whose confidence matches the accuracy
+// of the predictions, so thresholds such as same > 0.8 mean what they say. It is fitted on held-out labelled examples
+// by FitTemperature or FitIsotonic.
+//
+// Temperature scaling divides the logits by a temperature, it keeps the predicted class and suits most models.
+// Isotonic regression maps the probability of each class by a fitted non-decreasing curve, it corrects any
+// monotonic distortion but needs more examples and may change the predicted class of multi-class models.
+type Calibration struct {
+	Method string `json:"method"`
+	// Classes is the number of classes of the model it was fitted on, 0 if unknown as in files saved before it was
+	Classes     int        `json:"classes,omitempty"`
+	Temperature float64    `json:"temperature,omitempty"`
+	Isotonic    []Isotonic `json:"isotonic,omitempty"`
+}
+
+// Isotonic is a non-decreasing curve through the points (X[i], Y[i]), linear between them and flat outside of them.
+// A binary classifier has the curve of its positive class only.
+type Isotonic struct {
+	X []float64 `json:"x"`
+	Y []float64 `json:"y"`
+}
+
+// Calibrate returns the calibrated probabilities of the classes of a prediction
+func (c *Calibration) Calibrate(probs []float32) []float32 {
+	res := make([]float32, len(probs))
+	switch c.Method {
+	case TemperatureScaling:
+		scaled := make([]float64, len(probs))
+		best := math.Inf(-1)
+		for i, p := range probs {
+			scaled[i] = math.Log(max(float64(p), minProb)) / c.Temperature
+			best = max(best, scaled[i])
+		}
+		var total float64
+		for i, s := range scaled {
+			scaled[i] = math.Exp(s - best)
+			total += scaled[i]
+		}
+		for i, s := range scaled {
+			res[i] = float32(s / total)
+		}
+	case IsotonicRegression:
+		if len(probs) == 2 && len(c.Isotonic) == 1 {
+			p := c.Isotonic[0].At(float64(probs[1]))
+			return []float32{float32(1 - p), float32(p)}
+		}
+		var total float64
+		scaled := make([]float64, len(probs))
+		for i, p := range probs {
+			if i < len(c.Isotonic) {
+				scaled[i] = c.Isotonic[i].At(float64(p))
+			}
+			total += scaled[i]
+		}
+		if total == 0 {
+			return append(res[:0], probs...)
+		}
+		for i, s := range scaled {
+			res[i] = float32(s / total)
+		}
+	default:
+		copy(res, probs)
+	}
+	return res
+}
+
+// CalibrateAll returns the calibrated probabilities of each prediction
+func (c *Calibration) CalibrateAll(probs [][]float32) [][]float32 {
+	res := make([][]float32, len(probs))
+	for i, p := range probs {
+		res[i] = c.Calibrate(p)
+	}
+	return res
+}
+
+// At returns the value of the curve at x
+func (iso Isotonic) At(x float64) float64 {
+	n := len(iso.X)
+	switch {
+	case n == 0:
+		return x
+	case x <= iso.X[0]:
+		return iso.Y[0]
+	case x >= iso.X[n-1]:
+		return iso.Y[n-1]
+	}
+	i := sort.SearchFloat64s(iso.X, x) // iso.X[i-1] < x <= iso.X[i]
+	x0, x1, y0, y1 := iso.X[i-1], iso.X[i], iso.Y[i-1], iso.Y[i]
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
+
+// FitTemperature fits the temperature minimizing the negative log likelihood of the labels of probs,
+// the class indexes of held-out examples. The logits are recovered from the probabilities up to a constant.
+func FitTemperature(probs [][]float32, labels []int) (*Calibration, error) {
+	if err := checkCalibrationSet(probs, labels); err != nil {
+		return nil, err
+	}
+	logs := make([][]float64, len(probs))
+	for i, p := range probs {
+		logs[i] = make([]float64, len(p))
+		for j, x := range p {
+			logs[i][j] = math.Log(max(float64(x), minProb))
+		}
+	}
+	// the likelihood is convex in 1/T, a golden section search over log(1/T) finds its minimum
+	nll := func(logBeta float64) float64 {
+		beta := math.Exp(logBeta)
+		var total float64
+		for i, l := range logs {
+			best := math.Inf(-1)
+			for _, x := range l {
+				best = max(best, beta*x)
+			}
+			var sum float64
+			for _, x := range l {
+				sum += math.Exp(beta*x - best)
+			}
+			total += best + math.Log(sum) - beta*l[labels[i]]
+		}
+		return total
+	}
+	ratio := (math.Sqrt(5) - 1) / 2
+	lo, hi := math.Log(1e-2), math.Log(1e2)
+	a, b := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	fa, fb := nll(a), nll(b)
+	for hi-lo > 1e-6 {
+		if fa < fb {
+			hi, b, fb = b, a, fa
+			a = hi - ratio*(hi-lo)
+			fa = nll(a)
+		} else {
+			lo, a, fa = a, b, fb
+			b = lo + ratio*(hi-lo)
+			fb = nll(b)
+		}
+	}
+	return &Calibration{Method: TemperatureScaling, Classes: len(probs[0]), Temperature: 1 / math.Exp((lo+hi)/2)}, nil
+}
+
+// FitIsotonic fits a curve per class by isotonic regression of whether examples are of the class on its
+// probability, labels are the class indexes of held-out examples. A binary classifier fits its positive class.
+func FitIsotonic(probs [][]float32, labels []int) (*Calibration, error) {
+	if err := checkCalibrationSet(probs, labels); err != nil {
+		return nil, err
+	}
+	classes := make([]int, len(probs[0]))
+	for c := range classes {
+		classes[c] = c
+	}
+	if len(classes) == 2 {
+		classes = classes[1:]
+	}
+	c := &Calibration{Method: IsotonicRegression, Classes: len(probs[0])}
+	for _, class := range classes {
+		xs := make([]float64, len(probs))
+		ys := make([]float64, len(probs))
+		for i, p := range probs {
+			xs[i] = float64(p[class])
+			if labels[i] == class {
+				ys[i] = 1
+			}
+		}
+		c.Isotonic = append(c.Isotonic, fitIsotonic(xs, ys))
+	}
+	return c, nil
+}
+
+// fitIsotonic fits the non-decreasing step function of x closest to y by pool adjacent violators.
+// Each block of pooled points is kept by its first and last x so the curve is flat over it.
+func fitIsotonic(xs, ys []float64) Isotonic {
+	idx := make([]int, len(xs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		return xs[idx[a]] < xs[idx[b]]
+	})
+	type block struct {
+		lo, hi, sum float64
+		n           int
+	}
+	var blocks []block
+	for _, i := range idx {
+		b := block{lo: xs[i], hi: xs[i], sum: ys[i], n: 1}
+		// tied x are pooled so they get the same value
+		for len(blocks) > 0 {
+			last := blocks[len(blocks)-1]
+			if last.hi != b.lo && last.sum/float64(last.n) < b.sum/float64(b.n) {
+				break
+			}
+			b = block{lo: last.lo, hi: b.hi, sum: last.sum + b.sum, n: last.n + b.n}
+			blocks = blocks[:len(blocks)-1]
+		}
+		blocks = append(blocks, b)
+	}
+	var iso Isotonic
+	for _, b := range blocks {
+		y := b.sum / float64(b.n)
+		iso.X = append(iso.X, b.lo)
+		iso.Y = append(iso.Y, y)
+		if b.hi != b.lo {
+			iso.X = append(iso.X, b.hi)
+			iso.Y = append(iso.Y, y)
+		}
+	}
+	return iso
+}
+
+func checkCalibrationSet(probs [][]float32, labels []int) error {
+	if len(probs) != len(labels) {
+		return fmt.Errorf("mismatched prediction count %d for %d labels", len(probs), len(labels))
+	}
+	if len(probs) == 0 {
+		return fmt.Errorf("no examples to calibrate on")
+	}
+	for i, p := range probs {
+		if len(p) < 2 || len(p) != len(probs[0]) {
+			return fmt.Errorf("prediction %d has %d classes, expected %d and at least 2", i, len(p), len(probs[0]))
+		}
+		if labels[i] < 0 || labels[i] >= len(p) {
+			return fmt.Errorf("label %d out of the %d classes", labels[i], len(p))
+		}
+	}
+	return nil
+}
+
+// LoadCalibration reads a calibration saved by SaveFile
+func LoadCalibration(path string) (*Calibration, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Calibration
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case c.Method == TemperatureScaling && c.Temperature > 0:
+	case c.Method == IsotonicRegression && len(c.Isotonic) > 0:
+	default:
+		return nil, fmt.Errorf("%s: invalid %q calibration", path, c.Method)
+	}
+	for _, iso := range c.Isotonic {
+		if len(iso.X) != len(iso.Y) || !sort.Float64sAreSorted(iso.X) {
+			return nil, fmt.Errorf("%s: invalid isotonic curve", path)
+		}
+	}
+	if c.Method == IsotonicRegression && c.Classes > 0 && len(c.Isotonic) != isotonicCurves(c.Classes) {
+		return nil, fmt.Errorf("%s: %d isotonic curves for %d classes", path, len(c.Isotonic), c.Classes)
+	}
+	return &c, nil
+}
+
+// CheckClasses returns an error if the calibration was fitted on a model with another number of classes
+func (c *Calibration) CheckClasses(classes int) error {
+	if c.Classes > 0 && classes != c.Classes {
+		return fmt.Errorf("calibration fitted on %d classes, the model outputs %d", c.Classes, classes)
+	}
+	return nil
+}
+
+// isotonicCurves is the number of curves fitted by FitIsotonic for a model of classes, binary ones have one
+func isotonicCurves(classes int) int {
+	if classes == 2 {
+		return 1
+	}
+	return classes
+}
+
+// SaveFile writes the calibration to path as JSON, ex CalibrationFile in the export dir of the model
+func (c *Calibration) SaveFile(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(path, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
+
+// findCalibration returns the calibration in the export dir of a model, or nil if it has none
+func findCalibration(dir string) (*Calibration, error) {
+	c, err := LoadCalibration(filepath.Join(dir, CalibrationFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return c, err
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model/estimator"
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+)
+
+// overconfident predicts the positive class with 0.95 for 100 examples, only 75 of which are positive
+func overconfident() ([][]float32, []int) {
+	probs := make([][]float32, 100)
+	labels := make([]int, 100)
+	for i := range probs {
+		probs[i] = []float32{0.05, 0.95}
+		if i < 75 {
+			labels[i] = 1
+		}
+	}
+	return probs, labels
+}
+
+func TestFitTemperature(t *testing.T) {
+	probs, labels := overconfident()
+	c, err := FitTemperature(probs, labels)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	// 1 / (1 + (0.05/0.95)^(1/T)) = 0.75
+	if c.Classes != 2 {
+		t.Errorf("Invalid Classes - Want: %v, Got: %v", 2, c.Classes)
+	}
+	if want := math.Log(19) / math.Log(3); math.Abs(c.Temperature-want) > 1e-3 {
+		t.Errorf("Invalid Temperature - Want: %v, Got: %v", want, c.Temperature)
+	}
+	got := c.Calibrate(probs[0])
+	if math.Abs(float64(got[1])-0.75) > 1e-4 || math.Abs(float64(got[0]+got[1])-1) > 1e-6 {
+		t.Errorf("Invalid Calibrated - Want: %v, Got: %v", []float32{0.25, 0.75}, got)
+	}
+	// the predicted class is kept
+	if got := c.Calibrate([]float32{0.2, 0.5, 0.3}); !(got[1] > got[2] && got[2] > got[0]) {
+		t.Errorf("Invalid Calibrated Order - Got: %v", got)
+	}
+
+	if _, err := FitTemperature(probs, labels[1:]); err == nil {
+		t.Errorf("Invalid Error - Want: mismatched count, Got: %v", err)
+	}
+	if _, err := FitTemperature([][]float32{{1, 0}}, []int{2}); err == nil {
+		t.Errorf("Invalid Error - Want: label out of classes, Got: %v", err)
+	}
+}
+
+func TestFitIsotonic(t *testing.T) {
+	probs := [][]float32{{0.9, 0.1}, {0.8, 0.2}, {0.7, 0.3}, {0.6, 0.4}, {0.2, 0.8}, {0.1, 0.9}}
+	labels := []int{0, 1, 0, 1, 1, 1}
+	c, err := FitIsotonic(probs, labels)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if len(c.Isotonic) != 1 {
+		t.Fatalf("Invalid Curves - Want: %v, Got: %v", 1, len(c.Isotonic))
+	}
+	// 0.2 and 0.3 are pooled as they violate the order, 0.4 to 0.9 are all positive
+	iso := c.Isotonic[0]
+	wantX := []float64{0.1, 0.2, 0.3, 0.4, 0.9}
+	wantY := []float64{0, 0.5, 0.5, 1, 1}
+	if len(iso.X) != len(wantX) {
+		t.Fatalf("Invalid Curve - Want: %v %v, Got: %v %v", wantX, wantY, iso.X, iso.Y)
+	}
+	for i := range wantX {
+		if math.Abs(iso.X[i]-wantX[i]) > 1e-6 || iso.Y[i] != wantY[i] {
+			t.Errorf("Invalid Curve - Want: %v %v, Got: %v %v", wantX, wantY, iso.X, iso.Y)
+			break
+		}
+	}
+	for x, want := range map[float64]float64{0.05: 0, 0.25: 0.5, 0.35: 0.75, 0.95: 1} {
+		if got := iso.At(x); math.Abs(got-want) > 1e-6 {
+			t.Errorf("Invalid At(%v) - Want: %v, Got: %v", x, want, got)
+		}
+	}
+	if got := c.Calibrate([]float32{0.65, 0.35}); math.Abs(float64(got[1])-0.75) > 1e-6 || math.Abs(float64(got[0])-0.25) > 1e-6 {
+		t.Errorf("Invalid Calibrated - Want: %v, Got: %v", []float32{0.25, 0.75}, got)
+	}
+
+	probs = [][]float32{{0.7, 0.2, 0.1}, {0.1, 0.8, 0.1}, {0.2, 0.2, 0.6}, {0.5, 0.4, 0.1}}
+	c, err = FitIsotonic(probs, []int{0, 1, 2, 1})
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if len(c.Isotonic) != 3 || c.Classes != 3 {
+		t.Fatalf("Invalid Curves - Want: %v, Got: %v %v", 3, len(c.Isotonic), c.Classes)
+	}
+	for _, p := range c.CalibrateAll(probs) {
+		if sum := p[0] + p[1] + p[2]; math.Abs(float64(sum)-1) > 1e-6 {
+			t.Errorf("Invalid Calibrated Sum - Want: %v, Got: %v", 1, sum)
+		}
+	}
+}
+
+func TestCalibrationFile(t *testing.T) {
+	dir := t.TempDir()
+	if c, err := findCalibration(dir); c != nil || err != nil {
+		t.Errorf("Invalid Missing Calibration - Want: %v, Got: %v %v", nil, c, err)
+	}
+	want := &Calibration{Method: IsotonicRegression, Isotonic: []Isotonic{{X: []float64{0.1, 0.9}, Y: []float64{0.2, 0.8}}}}
+	if err := want.SaveFile(filepath.Join(dir, CalibrationFile)); err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	got, err := findCalibration(dir)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid Calibration - Want: %+v, Got: %+v", want, got)
+	}
+	for _, invalid := range []string{
+		`{"method": "temperature"}`,
+		`{"method": "isotonic", "classes": 3, "isotonic": [{"x": [0.1], "y": [0.2]}]}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, CalibrationFile), []byte(invalid), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := findCalibration(dir); err == nil {
+			t.Errorf("Invalid Error for %s - Want: invalid calibration, Got: %v", invalid, err)
+		}
+	}
+}
+
+// probsPredictor stands in for the session of a binary classifier, always predicting 0.95 for the positive class
+type probsPredictor struct{}
+
+func (p probsPredictor) Predict(fn estimator.InputFunc) ([]*tf.Tensor, error) {
+	return p.PredictContext(context.Background(), fn)
+}
+
+func (p probsPredictor) PredictContext(ctx context.Context, fn estimator.InputFunc) ([]*tf.Tensor, error) {
+	ids := fn(nil)[tf.Output{}].Value().([][]int32)
+	out := make([][]float32, len(ids))
+	for i := range out {
+		out[i] = []float32{0.05, 0.95}
+	}
+	t, err := tf.NewTensor(out)
+	return []*tf.Tensor{t}, err
+}
+
+func TestWithCalibration(t *testing.T) {
+	b := newFakeBert(probsPredictor{}, WithBatchSize(1), WithCalibration(&Calibration{Method: TemperatureScaling, Temperature: math.Log(19) / math.Log(3)}))
+	vals, err := b.PredictValuesContext(context.Background(), "a", "b")
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	probs := vals[0].Value().([][]float32)
+	if len(probs) != 2 || math.Abs(float64(probs[1][1])-0.75) > 1e-4 {
+		t.Errorf("Invalid Calibrated - Want: %v, Got: %v", 0.75, probs)
+	}
+	if b.Calibration() == nil || !b.loader.calibrated {
+		t.Errorf("Invalid Calibration - Got: %+v", b.Calibration())
+	}
+	b = newFakeBert(probsPredictor{}, WithCalibration(nil))
+	vals, err = b.PredictValuesContext(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if probs := vals[0].Value().([][]float32); probs[0][1] != 0.95 {
+		t.Errorf("Invalid Uncalibrated - Want: %v, Got: %v", 0.95, probs)
+	}
+	b = newFakeBert(probsPredictor{}, WithCalibration(&Calibration{Method: TemperatureScaling, Classes: 3, Temperature: 2}))
+	if _, err := b.PredictValuesContext(context.Background(), "a"); err == nil {
+		t.Errorf("Invalid Error - Want: calibration of 3 classes for 2, Got: %v", err)
+	}
+}
@@ -12,17 +12,56 @@ const (
 )
 
 // NewBertClassifier returns a model configured for classification after being fine-tuned with run_classification.py
+// If vocabPath is empty, the vocab is read from the export dir unless set with WithVocabPath.
+// Options are applied after the classifier defaults, so WithSeqLen overrides ClassifierSeqLen.
+// Probabilities are calibrated by the CalibrationFile of the export dir when it has one, unless set with WithCalibration.
 func NewBertClassifier(path string, vocabPath string, opts ...BertOption) (Bert, error) {
-	m, err := tf.LoadSavedModel(path, []string{ClassifierModelTag}, nil)
+	l := loaderFrom(opts)
+	if l.vocabPath == "" {
+		l.vocabPath = vocabPath
+	}
+	m, dir, vocabPath, err := l.load(path, []string{ClassifierModelTag})
 	if err != nil {
 		return Bert{}, err
 	}
-	return NewBert(m, vocabPath, append(opts,
+	defaults := []BertOption{
 		WithSeqLen(ClassifierSeqLen),
 		WithModelFunc(func(m *tf.SavedModel) ([]tf.Output, []*tf.Operation) {
 			return []tf.Output{
 				m.Graph.Operation(ClassifierOutputOp).Output(0),
 			}, nil
 		}),
-	)...)
+	}
+	if !l.calibrated {
+		cal, err := findCalibration(dir)
+		if err != nil {
+			m.Session.Close()
+			return Bert{}, err
+		}
+		defaults = append(defaults, WithCalibration(cal))
+	}
+	b, err := newLoaded(m, vocabPath, append(defaults, opts...))
+	if err != nil {
+		return Bert{}, err
+	}
+	if err := checkCalibration(b, m); err != nil {
+		b.Close()
+		return Bert{}, err
+	}
+	return b, nil
+}
+
+// checkCalibration fails if the calibration of b was fitted on another number of classes than m outputs, when known
+func checkCalibration(b Bert, m *tf.SavedModel) error {
+	op := m.Graph.Operation(ClassifierOutputOp)
+	if b.calibration == nil || op == nil {
+		return nil
+	}
+	shape := op.Output(0).Shape()
+	if n := shape.NumDimensions(); n > 0 {
+		if classes := shape.Size(n - 1); classes > 0 {
+			return b.calibration.CheckClasses(int(classes))
+		}
+	}
+	return nil
 }
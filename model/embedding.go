@@ -1,21 +1,17 @@
 package model
 
-import (
-	tf "github.com/tensorflow/tensorflow/tensorflow/go"
-)
-
 // Embedding Defaults
 const (
 	EmbeddingModelTag = "bert-pretrained"
 	EmbeddingOp       = "embedding"
 )
 
-// NewEmbeddings returns a pre-trained model for text embeddings
+// NewEmbeddings returns a pre-trained model for text embeddings.
+// The vocab is read from the export dir unless set with WithVocabPath
 func NewEmbeddings(path string, opts ...BertOption) (Bert, error) {
-	vocabPath := path + "/" + DefaultVocabFile // TODO os.Join
-	m, err := tf.LoadSavedModel(path, []string{EmbeddingModelTag}, nil)
+	m, _, vocabPath, err := loaderFrom(opts).load(path, []string{EmbeddingModelTag})
 	if err != nil {
 		return Bert{}, err
 	}
-	return NewBert(m, vocabPath, opts...)
+	return newLoaded(m, vocabPath, opts)
 }
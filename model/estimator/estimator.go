@@ -4,6 +4,8 @@
 package estimator
 
 import (
+	"context"
+
 	tf "github.com/tensorflow/tensorflow/tensorflow/go"
 )
 
@@ -17,7 +19,6 @@ type ModelFunc func(m *tf.SavedModel) ([]tf.Output, []*tf.Operation)
 type Estimator interface {
 	/*
 		Trainer
-		Evaluator
 		Exporter
 	*/
 	Predictor
@@ -28,11 +29,19 @@ type Predictor interface {
 	Predict(InputFunc) ([]*tf.Tensor, error)
 }
 
-/*
+// ContextPredictor is a Predictor that can be cancelled or bounded by a deadline
+type ContextPredictor interface {
+	Predictor
+	PredictContext(context.Context, InputFunc) ([]*tf.Tensor, error)
+}
+
+// Evaluator evaluates a model at inference time, like Estimator.evaluate it returns metrics by name, ex "accuracy".
+// labels are the expected classes of inputs, see the eval package for an implementation over classifiers.
 type Evaluator interface {
-	Evaluate(InputFunc) ([]*tf.Tensor, error)
+	Evaluate(ctx context.Context, inputs []string, labels []int) (map[string]float64, error)
 }
 
+/*
 type Trainer interface {
 	Train(InputFunc) ([]*tf.Tensor, error)
 }
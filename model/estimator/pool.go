@@ -0,0 +1,109 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSaturated is returned by a fail-fast Pool when every slot is in use
+var ErrSaturated = errors.New("all session slots are in use")
+
+// Pool bounds the number of concurrent session runs of a model.
+// By default callers block until a slot is free, a fail-fast pool rejects them with ErrSaturated instead.
+type Pool struct {
+	slots    chan struct{}
+	failFast bool
+
+	waiting   int64
+	runs      int64
+	rejected  int64
+	waitNanos int64
+	maxWait   int64
+}
+
+// PoolStats is a snapshot of a Pool, wait times are measured from a call to a free slot
+type PoolStats struct {
+	Size      int
+	InFlight  int
+	Waiting   int
+	Runs      int64
+	Rejected  int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// MeanWait is the average time a run waited for a slot
+func (s PoolStats) MeanWait() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Runs)
+}
+
+// NewPool returns a pool of size slots, a size <= 0 uses GOMAXPROCS
+func NewPool(size int, failFast bool) *Pool {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+	return &Pool{slots: make(chan struct{}, size), failFast: failFast}
+}
+
+// Size is the number of slots
+func (p *Pool) Size() int {
+	return cap(p.slots)
+}
+
+// Acquire takes a slot, it must be released once the run is done
+func (p *Pool) Acquire(ctx context.Context) error {
+	start := time.Now()
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		if p.failFast {
+			atomic.AddInt64(&p.rejected, 1)
+			return ErrSaturated
+		}
+		atomic.AddInt64(&p.waiting, 1)
+		select {
+		case p.slots <- struct{}{}:
+			atomic.AddInt64(&p.waiting, -1)
+		case <-ctx.Done():
+			atomic.AddInt64(&p.waiting, -1)
+			return ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		<-p.slots
+		return err
+	}
+	wait := int64(time.Since(start))
+	atomic.AddInt64(&p.runs, 1)
+	atomic.AddInt64(&p.waitNanos, wait)
+	for max := atomic.LoadInt64(&p.maxWait); wait > max; max = atomic.LoadInt64(&p.maxWait) {
+		if atomic.CompareAndSwapInt64(&p.maxWait, max, wait) {
+			break
+		}
+	}
+	return nil
+}
+
+// Release frees a slot taken with Acquire
+func (p *Pool) Release() {
+	<-p.slots
+}
+
+// Stats returns a snapshot of the pool
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Size:      cap(p.slots),
+		InFlight:  len(p.slots),
+		Waiting:   int(atomic.LoadInt64(&p.waiting)),
+		Runs:      atomic.LoadInt64(&p.runs),
+		Rejected:  atomic.LoadInt64(&p.rejected),
+		TotalWait: time.Duration(atomic.LoadInt64(&p.waitNanos)),
+		MaxWait:   time.Duration(atomic.LoadInt64(&p.maxWait)),
+	}
+}
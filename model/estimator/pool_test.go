@@ -0,0 +1,47 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	p := NewPool(1, false)
+	if err := p.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Release()
+	}()
+	if err := p.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", context.DeadlineExceeded, err)
+	}
+	s := p.Stats()
+	if s.Size != 1 || s.InFlight != 1 || s.Waiting != 0 || s.Runs != 2 || s.MaxWait < 20*time.Millisecond || s.MeanWait() != s.TotalWait/2 {
+		t.Errorf("Invalid Stats - Got: %+v", s)
+	}
+}
+
+func TestPoolFailFast(t *testing.T) {
+	p := NewPool(1, true)
+	if err := p.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Acquire(context.Background()); err != ErrSaturated {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", ErrSaturated, err)
+	}
+	p.Release()
+	if err := p.Acquire(context.Background()); err != nil {
+		t.Errorf("Invalid Error - Want: nil, Got: %v", err)
+	}
+	if s := p.Stats(); s.Rejected != 1 || s.Runs != 2 {
+		t.Errorf("Invalid Stats - Got: %+v", s)
+	}
+}
@@ -3,6 +3,8 @@
 package estimator
 
 import (
+	"context"
+
 	tf "github.com/tensorflow/tensorflow/tensorflow/go"
 )
 
@@ -10,20 +12,65 @@ type predictor struct {
 	m       *tf.SavedModel
 	outputs []tf.Output
 	targets []*tf.Operation
+	pool    *Pool
+}
+
+// PredictorOption configures a Predictor
+type PredictorOption func(p *predictor) *predictor
+
+// WithPool bounds concurrent session runs with pool, it can be shared by predictors of the same model
+func WithPool(pool *Pool) PredictorOption {
+	return func(p *predictor) *predictor {
+		p.pool = pool
+		return p
+	}
 }
 
-// NewPredictor creates a new Predictor in lieu of a full estimator
-func NewPredictor(m *tf.SavedModel, fn ModelFunc) Predictor {
+// NewPredictor creates a new Predictor in lieu of a full estimator.
+// Session runs are unbounded unless a pool is set with WithPool.
+func NewPredictor(m *tf.SavedModel, fn ModelFunc, opts ...PredictorOption) ContextPredictor {
 	outputs, targets := fn(m)
-	return predictor{
+	p := &predictor{
 		m:       m,
 		outputs: outputs,
 		targets: targets,
 	}
+	for _, opt := range opts {
+		p = opt(p)
+	}
+	return p
 }
 
 // Predict Predictor will apply fn to the estimator model
-func (p predictor) Predict(fn InputFunc) ([]*tf.Tensor, error) {
-	inputs := fn(p.m)
-	return p.m.Session.Run(inputs, p.outputs, p.targets)
+func (p *predictor) Predict(fn InputFunc) ([]*tf.Tensor, error) {
+	return p.PredictContext(context.Background(), fn)
+}
+
+// PredictContext will apply fn to the estimator model, abandoning the call with ctx.Err() if ctx is done.
+// A session run can't be interrupted once started, so an abandoned run finishes in the background
+// and its results are dropped.
+func (p *predictor) PredictContext(ctx context.Context, fn InputFunc) ([]*tf.Tensor, error) {
+	if p.pool != nil {
+		if err := p.pool.Acquire(ctx); err != nil {
+			return nil, err
+		}
+	}
+	type result struct {
+		ts  []*tf.Tensor
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		if p.pool != nil {
+			defer p.pool.Release()
+		}
+		ts, err := p.m.Session.Run(fn(p.m), p.outputs, p.targets)
+		done <- result{ts: ts, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.ts, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
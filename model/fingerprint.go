@@ -0,0 +1,60 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// VariablesIndexFile is the index of the variable checkpoint of a SavedModel, it changes with the weights
+const VariablesIndexFile = "variables/variables.index"
+
+// Fingerprint identifies the graph, weights and vocab of the SavedModel at path, resolved like NewEmbeddings.
+// The vocab is found next to the model if vocabPath is empty. Outputs of models with the same fingerprint are interchangeable.
+func Fingerprint(path, vocabPath string) (string, error) {
+	dir, err := ExportDir(path)
+	if err != nil {
+		return "", err
+	}
+	if vocabPath == "" {
+		vocabPath = findVocab(path, dir)
+	}
+	graph := filepath.Join(dir, SavedModelFile)
+	if _, err := os.Stat(graph); err != nil {
+		graph = filepath.Join(dir, SavedModelTextFile)
+	}
+	variables := filepath.Join(dir, VariablesIndexFile)
+	h := sha256.New()
+	for _, p := range []string{graph, variables, vocabPath} {
+		err := hashFile(h, p)
+		if os.IsNotExist(err) && p == variables {
+			err = nil // models without variables, ex frozen graphs
+		}
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0}) // separates the files so their contents can't shift between them
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// SameFingerprint reports whether outputs with fingerprints a and b are interchangeable.
+// An empty fingerprint is unknown, it only matches another when allowUnknown is set.
+func SameFingerprint(a, b string, allowUnknown bool) bool {
+	if a == "" || b == "" {
+		return allowUnknown
+	}
+	return a == b
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"github.com/sunhailin-Leo/gobert/tokenize"
+	"github.com/sunhailin-Leo/gobert/tokenize/vocab"
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+)
+
+// loader holds the options needed to load a SavedModel, before the Bert itself can be built
+type loader struct {
+	session   *SessionConfig
+	tags      []string
+	vocabPath string
+	vocab     *vocab.Dict
+
+	calibrated bool // calibration set by WithCalibration rather than read from the export dir
+}
+
+// loaderFrom applies opts to an empty Bert to collect the load options ahead of NewBert
+func loaderFrom(opts []BertOption) loader {
+	b := Bert{factory: &tokenize.FeatureFactory{}}
+	for _, opt := range opts {
+		b = opt(b)
+	}
+	return b.loader
+}
+
+// load reads the SavedModel at path, or its newest version directory, and resolves the vocab path.
+// The given tags are used unless overridden with WithTags. The returned dir is the loaded export dir.
+func (l loader) load(path string, tags []string) (m *tf.SavedModel, dir, vocabPath string, err error) {
+	dir, err = ExportDir(path)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if l.tags != nil {
+		tags = l.tags
+	}
+	if err := checkTags(dir, tags); err != nil {
+		return nil, "", "", err
+	}
+	var so *tf.SessionOptions
+	if l.session != nil {
+		cfg, err := l.session.Marshal()
+		if err != nil {
+			return nil, "", "", err
+		}
+		so = &tf.SessionOptions{Config: cfg}
+	}
+	m, err = tf.LoadSavedModel(dir, tags, so)
+	if err != nil {
+		return nil, "", "", err
+	}
+	vocabPath = l.vocabPath
+	if vocabPath == "" {
+		vocabPath = findVocab(path, dir)
+	}
+	return m, dir, vocabPath, nil
+}
+
+// newLoaded builds a Bert from a freshly loaded model, closing the session if that fails
+func newLoaded(m *tf.SavedModel, vocabPath string, opts []BertOption) (Bert, error) {
+	b, err := NewBert(m, vocabPath, opts...)
+	if err != nil {
+		m.Session.Close()
+		return Bert{}, err
+	}
+	return b, nil
+}
@@ -0,0 +1,33 @@
+package modeltest
+
+import (
+	"math/rand"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Embeddings draws n embeddings of dim from seed, so tests are repeatable. With topics > 0 they are spread around
+// that many random topics by a normal noise of scale noise, the i-th around topic i % topics, like sentence embeddings
+// of a few subjects. Otherwise they are independent normal vectors.
+func Embeddings(seed int64, n, dim, topics int, noise float64) []model.Embedding {
+	rng := rand.New(rand.NewSource(seed))
+	centers := make([][]float64, topics)
+	for t := range centers {
+		centers[t] = make([]float64, dim)
+		for j := range centers[t] {
+			centers[t][j] = rng.NormFloat64()
+		}
+	}
+	embs := make([]model.Embedding, n)
+	for i := range embs {
+		embs[i] = make(model.Embedding, dim)
+		for j := range embs[i] {
+			if topics > 0 {
+				embs[i][j] = float32(centers[i%topics][j] + noise*rng.NormFloat64())
+			} else {
+				embs[i][j] = float32(rng.NormFloat64())
+			}
+		}
+	}
+	return embs
+}
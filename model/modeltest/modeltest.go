@@ -0,0 +1,102 @@
+// Package modeltest has a fake model.Predictor for testing code built on models without a TF session
+package modeltest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Value is a model.ValueProvider of a fixed value
+type Value struct {
+	V interface{}
+}
+
+// Value returns the value
+func (v Value) Value() interface{} {
+	return v.V
+}
+
+// Predictor is a model.Predictor whose output is computed from the texts by Predict, ex [][]float32 for
+// a classifier or [][][]float32 for token vectors. It records the texts it predicted and fails with Err when set.
+// It is safe for concurrent use.
+type Predictor struct {
+	Predict func(texts []string) interface{}
+	// FeatureFunc tokenizes texts, features only have their text and a mask of one token by default
+	FeatureFunc func(texts ...string) []tokenize.Feature
+	Err         error
+
+	mu        sync.Mutex
+	calls     int
+	predicted []string
+}
+
+var _ model.Predictor = (*Predictor)(nil)
+
+// New returns a Predictor computing its output with predict
+func New(predict func(texts []string) interface{}) *Predictor {
+	return &Predictor{Predict: predict}
+}
+
+// Features tokenizes texts with FeatureFunc when set
+func (p *Predictor) Features(texts ...string) []tokenize.Feature {
+	if p.FeatureFunc != nil {
+		return p.FeatureFunc(texts...)
+	}
+	fs := make([]tokenize.Feature, len(texts))
+	for i, text := range texts {
+		fs[i] = tokenize.Feature{Text: text, Mask: []int32{1}}
+	}
+	return fs
+}
+
+// PredictValues predicts texts without a context
+func (p *Predictor) PredictValues(texts ...string) ([]model.ValueProvider, error) {
+	return p.PredictValuesContext(context.Background(), texts...)
+}
+
+// PredictValuesContext records the call and returns the output of Predict, or Err or the error of ctx
+func (p *Predictor) PredictValuesContext(ctx context.Context, texts ...string) ([]model.ValueProvider, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.calls++
+	p.predicted = append(p.predicted, texts...)
+	err := p.Err
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return []model.ValueProvider{Value{p.Predict(texts)}}, nil
+}
+
+// Calls returns the number of predictions so far
+func (p *Predictor) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// Predicted returns the texts predicted so far, in order
+func (p *Predictor) Predicted() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.predicted...)
+}
+
+// Reset forgets the predictions so far
+func (p *Predictor) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls, p.predicted = 0, nil
+}
+
+// SetErr makes the next predictions fail with err, nil makes them succeed
+func (p *Predictor) SetErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Err = err
+}
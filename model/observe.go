@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// BatchStats describes a batch run through the pipeline, stages that didn't run have a zero duration
+type BatchStats struct {
+	// Model is the name set with WithName
+	Model    string
+	Size     int
+	Tokenize time.Duration
+	Tensors  time.Duration
+	Run      time.Duration
+	// SeqLens is the number of tokens of each text including [CLS] and [SEP], before truncation
+	SeqLens []int
+	// Truncated is the number of texts that didn't fit the seq len
+	Truncated int
+	// Tokens and Unknown count the wordpiece tokens of the batch kept after truncation,
+	// Unknown are the ones mapped to tokenize.DefaultUnknownToken
+	Tokens  int
+	Unknown int
+	Err     error
+}
+
+// Observer receives the stats of every batch predicted by a model, it must be safe for concurrent use
+type Observer interface {
+	ObserveBatch(s BatchStats)
+}
+
+// ObserverFunc adapts a function to an Observer
+type ObserverFunc func(s BatchStats)
+
+// ObserveBatch calls fn
+func (fn ObserverFunc) ObserveBatch(s BatchStats) {
+	fn(s)
+}
+
+// countTokens fills the token stats of s from the features of its batch
+func (s *BatchStats) countTokens(fs []tokenize.Feature) {
+	s.SeqLens = make([]int, len(fs))
+	for i, f := range fs {
+		n := f.Count()
+		s.SeqLens[i] = n + f.Truncated
+		if f.Truncated > 0 {
+			s.Truncated++
+		}
+		for _, tok := range f.Tokens[:n] {
+			switch tok {
+			case tokenize.ClassToken, tokenize.SeparatorToken:
+			case tokenize.DefaultUnknownToken:
+				s.Tokens++
+				s.Unknown++
+			default:
+				s.Tokens++
+			}
+		}
+	}
+}
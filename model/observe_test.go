@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestObserver(t *testing.T) {
+	var mu sync.Mutex
+	var got []BatchStats
+	obs := ObserverFunc(func(s BatchStats) {
+		mu.Lock()
+		got = append(got, s)
+		mu.Unlock()
+	})
+	b := newFakeBert(&fakePredictor{}, WithName("faq:1"), WithObserver(obs))
+	if _, err := b.PredictValues("a zz", "a b c d e f g a"); err != nil {
+		t.Fatal(err)
+	}
+	fail := errors.New("fail")
+	b = newFakeBert(&fakePredictor{err: fail}, WithName("faq:1"), WithObserver(obs))
+	b.PredictValues("b")
+	if len(got) != 2 {
+		t.Fatalf("Invalid Batch Count - Want: 2, Got: %d", len(got))
+	}
+	s := got[0]
+	if s.Model != "faq:1" || s.Size != 2 || !reflect.DeepEqual(s.SeqLens, []int{4, 10}) || s.Truncated != 1 ||
+		s.Tokens != 8 || s.Unknown != 1 || s.Err != nil {
+		t.Errorf("Invalid Stats - Got: %+v", s)
+	}
+	if s := got[1]; s.Err != fail || s.Run != 0 || s.Tokens != 1 {
+		t.Errorf("Invalid Failed Stats - Got: %+v", s)
+	}
+}
@@ -1,8 +1,12 @@
 package model
 
 import (
+	"log/slog"
+
 	"github.com/sunhailin-Leo/gobert/model/estimator"
 	"github.com/sunhailin-Leo/gobert/tokenize"
+	"github.com/sunhailin-Leo/gobert/tokenize/vocab"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BertOption configures a BERT model
@@ -47,3 +51,102 @@ func WithInputFunc(fn TensorInputFunc) BertOption {
 		return b
 	}
 }
+
+// WithBatchSize splits predictions into sub-batches of at most n texts, 0 runs all texts in one batch
+func WithBatchSize(n int) BertOption {
+	return func(b Bert) Bert {
+		b.batchSize = n
+		return b
+	}
+}
+
+// WithSessionConfig configures the tensorflow session when a model is loaded, such as with NewEmbeddings.
+// It has no effect on NewBert since the model is already loaded.
+func WithSessionConfig(cfg SessionConfig) BertOption {
+	return func(b Bert) Bert {
+		b.loader.session = &cfg
+		return b
+	}
+}
+
+// WithTags overrides the tags of the meta graph to load, ex "serve" for standard exports
+func WithTags(tags ...string) BertOption {
+	return func(b Bert) Bert {
+		b.loader.tags = tags
+		return b
+	}
+}
+
+// WithVocabPath sets the vocab file explicitly instead of looking for it in the export dir
+func WithVocabPath(path string) BertOption {
+	return func(b Bert) Bert {
+		b.loader.vocabPath = path
+		return b
+	}
+}
+
+// WithVocab uses an already loaded vocab instead of reading one from disk, allowing models to share it
+func WithVocab(voc vocab.Dict) BertOption {
+	return func(b Bert) Bert {
+		b.loader.vocab = &voc
+		return b
+	}
+}
+
+// WithConcurrency bounds the number of concurrent session runs of the model, 0 uses GOMAXPROCS
+func WithConcurrency(n int) BertOption {
+	return func(b Bert) Bert {
+		b.concurrency = n
+		return b
+	}
+}
+
+// WithFailFast rejects predictions with ErrSaturated when every session slot is in use, instead of queueing them
+func WithFailFast() BertOption {
+	return func(b Bert) Bert {
+		b.failFast = true
+		return b
+	}
+}
+
+// WithName names the model in its BatchStats and log records, the registry sets it to ModelConfig.ID
+func WithName(name string) BertOption {
+	return func(b Bert) Bert {
+		b.name = name
+		return b
+	}
+}
+
+// WithLogger logs each predicted batch at debug level
+func WithLogger(l *slog.Logger) BertOption {
+	return func(b Bert) Bert {
+		b.logger = l
+		return b
+	}
+}
+
+// WithObserver reports the stats of each predicted batch to o, ex for metrics
+func WithObserver(o Observer) BertOption {
+	return func(b Bert) Bert {
+		b.observer = o
+		return b
+	}
+}
+
+// WithTracerProvider records the spans of the pipeline with tp instead of the global provider
+func WithTracerProvider(tp trace.TracerProvider) BertOption {
+	return func(b Bert) Bert {
+		b.tracerProvider = tp
+		return b
+	}
+}
+
+// WithCalibration calibrates the class probabilities of a classifier with c, ex from LoadCalibration.
+// It replaces the CalibrationFile read by NewBertClassifier, nil leaves the probabilities uncalibrated.
+func WithCalibration(c *Calibration) BertOption {
+	return func(b Bert) Bert {
+		b.calibration = c
+		b.loader.calibrated = true
+		return b
+	}
+}
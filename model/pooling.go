@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Embedding is a fixed length sentence vector pooled from the token vectors of a text
+type Embedding []float32
+
+// Pooling is a strategy for reducing token vectors to a single Embedding
+type Pooling string
+
+// Pooling strategies
+const (
+	MeanPooling Pooling = "mean" // average of the tokens in the mask
+	CLSPooling  Pooling = "cls"  // vector of the [CLS] token
+	MaxPooling  Pooling = "max"  // max of each dimension over the tokens in the mask
+)
+
+// Pool reduces the token vectors of a text to an Embedding, only tokens set in mask are used
+func (p Pooling) Pool(toks [][]float32, mask []int32) (Embedding, error) {
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("no token vectors to pool")
+	}
+	emb := make(Embedding, len(toks[0]))
+	if p == CLSPooling {
+		copy(emb, toks[0])
+		return emb, nil
+	}
+	var n int
+	for i, tok := range toks {
+		if i < len(mask) && mask[i] == 0 {
+			continue
+		}
+		for j, v := range tok {
+			switch {
+			case p == MeanPooling:
+				emb[j] += v
+			case p != MaxPooling:
+				return nil, fmt.Errorf("unknown pooling %q", p)
+			case n == 0 || v > emb[j]:
+				emb[j] = v
+			}
+		}
+		n++
+	}
+	if p == MeanPooling && n > 0 {
+		for j := range emb {
+			emb[j] /= float32(n)
+		}
+	}
+	return emb, nil
+}
+
+// Embeddings pools the token vectors output by an embedding model into one Embedding per feature
+func Embeddings(val ValueProvider, fs []tokenize.Feature, p Pooling) ([]Embedding, error) {
+	vals, ok := val.Value().([][][]float32)
+	if !ok {
+		return nil, fmt.Errorf("expected token vectors [][][]float32, got %T", val.Value())
+	}
+	if len(vals) != len(fs) {
+		return nil, fmt.Errorf("mismatched embedding count %d for %d features", len(vals), len(fs))
+	}
+	embs := make([]Embedding, len(vals))
+	for i, toks := range vals {
+		emb, err := p.Pool(toks, fs[i].Mask)
+		if err != nil {
+			return nil, err
+		}
+		embs[i] = emb
+	}
+	return embs, nil
+}
+
+// Embed predicts the embeddings of texts with an embedding model and pools them.
+// Texts are tokenized once, the masks of their features are used for pooling, see PredictFeatures.
+func Embed(ctx context.Context, m Predictor, p Pooling, texts ...string) ([]Embedding, error) {
+	fs := m.Features(texts...)
+	vals, err := PredictFeatures(ctx, m, fs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("model returned no outputs")
+	}
+	return Embeddings(vals[0], fs, p)
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model/estimator"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+)
+
+func TestPool(t *testing.T) {
+	toks := [][]float32{{1, 4}, {3, 2}, {5, 9}}
+	mask := []int32{1, 1, 0}
+	for _, test := range []struct {
+		pooling Pooling
+		emb     Embedding
+	}{
+		{MeanPooling, Embedding{2, 3}},
+		{MaxPooling, Embedding{3, 4}},
+		{CLSPooling, Embedding{1, 4}},
+	} {
+		emb, err := test.pooling.Pool(toks, mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(emb, test.emb) {
+			t.Errorf("Invalid %s Pooling - Want: %v, Got: %v", test.pooling, test.emb, emb)
+		}
+	}
+	if _, err := Pooling("median").Pool(toks, mask); err == nil {
+		t.Errorf("Expected Error for unknown pooling")
+	}
+}
+
+// countingTokenizer counts the texts tokenized
+type countingTokenizer struct {
+	tokenize.VocabTokenizer
+	texts int32
+}
+
+func (c *countingTokenizer) Tokenize(text string) []string {
+	atomic.AddInt32(&c.texts, 1)
+	return c.VocabTokenizer.Tokenize(text)
+}
+
+// tokenPredictor stands in for the session of an embedding model, the vector of each token is its id
+type tokenPredictor struct{}
+
+func (p tokenPredictor) Predict(fn estimator.InputFunc) ([]*tf.Tensor, error) {
+	return p.PredictContext(context.Background(), fn)
+}
+
+func (p tokenPredictor) PredictContext(ctx context.Context, fn estimator.InputFunc) ([]*tf.Tensor, error) {
+	ids := fn(nil)[tf.Output{}].Value().([][]int32)
+	out := make([][][]float32, len(ids))
+	for i, row := range ids {
+		for _, id := range row {
+			out[i] = append(out[i], []float32{float32(id)})
+		}
+	}
+	t, err := tf.NewTensor(out)
+	return []*tf.Tensor{t}, err
+}
+
+func TestEmbedTokenizesOnce(t *testing.T) {
+	for name, wrap := range map[string]func(b Bert) Predictor{
+		"bert":    func(b Bert) Predictor { return b },
+		"batcher": func(b Bert) Predictor { return NewBatcher(b) },
+	} {
+		b := newFakeBert(tokenPredictor{})
+		tkz := &countingTokenizer{VocabTokenizer: b.factory.Tokenizer}
+		b = WithTokenizer(tkz)(b)
+		m := wrap(b)
+		embs, err := Embed(context.Background(), m, MeanPooling, "c", "a b")
+		if err != nil {
+			t.Fatalf("Invalid Error for %s - Want: %v, Got: %v", name, nil, err)
+		}
+		// means of the ids of [CLS] c [SEP] and [CLS] a b [SEP]
+		if want := []Embedding{{11.0 / 3}, {11.0 / 4}}; !reflect.DeepEqual(embs, want) {
+			t.Errorf("Invalid Embeddings for %s - Want: %v, Got: %v", name, want, embs)
+		}
+		if tkz.texts != 2 {
+			t.Errorf("Invalid Tokenized Texts for %s - Want: %v, Got: %v", name, 2, tkz.texts)
+		}
+	}
+}
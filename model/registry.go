@@ -0,0 +1,372 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/tokenize/vocab"
+)
+
+// ModelType is the kind of model served by a registry entry
+type ModelType string
+
+// Model types supported by the registry
+const (
+	EmbeddingModel  ModelType = "embedding"
+	ClassifierModel ModelType = "classifier"
+)
+
+// ModelConfig describes a model to load into a Registry.
+// Relative paths in a config file are resolved against the directory of the file.
+type ModelConfig struct {
+	Name    string    `json:"name"`
+	Version string    `json:"version,omitempty"`
+	Type    ModelType `json:"type"`
+	Path    string    `json:"path"`
+	Vocab   string    `json:"vocab,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	SeqLen  int32     `json:"seq_len,omitempty"`
+	Labels  []string  `json:"labels,omitempty"`
+	// Concurrency bounds concurrent session runs, requests over it queue unless FailFast is set
+	Concurrency int  `json:"concurrency,omitempty"`
+	FailFast    bool `json:"fail_fast,omitempty"`
+	// Batching gathers concurrent requests into batched runs when set
+	Batching *BatchConfig `json:"batching,omitempty"`
+	// Cache memoizes predictions of repeated texts when set, it is applied by a Wrapper such as cache.Wrap
+	Cache *CacheConfig `json:"cache,omitempty"`
+}
+
+// ID is the name of the model, with its version when it has one, ex "faq:2"
+func (mc ModelConfig) ID() string {
+	if mc.Version == "" {
+		return mc.Name
+	}
+	return mc.Name + ":" + mc.Version
+}
+
+// BatchConfig configures a Batcher in front of a registered model, unset values use the defaults
+type BatchConfig struct {
+	MaxBatch   int `json:"max_batch,omitempty"`
+	MaxWaitMS  int `json:"max_wait_ms,omitempty"`
+	QueueDepth int `json:"queue_depth,omitempty"`
+	TimeoutMS  int `json:"timeout_ms,omitempty"`
+}
+
+// options converts the config to batch options
+func (c BatchConfig) options() []BatchOption {
+	var opts []BatchOption
+	if c.MaxBatch > 0 {
+		opts = append(opts, WithMaxBatch(c.MaxBatch))
+	}
+	if c.MaxWaitMS > 0 {
+		opts = append(opts, WithMaxWait(time.Duration(c.MaxWaitMS)*time.Millisecond))
+	}
+	if c.QueueDepth > 0 {
+		opts = append(opts, WithQueueDepth(c.QueueDepth))
+	}
+	if c.TimeoutMS > 0 {
+		opts = append(opts, WithBatchTimeout(time.Duration(c.TimeoutMS)*time.Millisecond))
+	}
+	return opts
+}
+
+// CacheConfig configures an in-memory cache in front of a registered model, unset values use the defaults
+type CacheConfig struct {
+	MaxEntries int   `json:"max_entries,omitempty"`
+	MaxBytes   int64 `json:"max_bytes,omitempty"`
+	TTLSeconds int   `json:"ttl_s,omitempty"`
+}
+
+// RegistryConfig is the JSON file format read by LoadRegistry
+type RegistryConfig struct {
+	Models []ModelConfig `json:"models"`
+}
+
+// Entry is a named model in a Registry
+type Entry struct {
+	ModelConfig
+	Model Predictor
+}
+
+// Registry holds named, versioned models for serving several of them in one process.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string][]*Entry // by name, sorted oldest to newest version
+	vocabs  map[string]vocab.Dict
+	wrap    []Wrapper
+}
+
+// Wrapper wraps a model loaded by a Registry, ex to cache it, models it doesn't apply to are returned as is.
+// It lets packages that import model, such as model/cache, hook into the config.
+type Wrapper func(mc ModelConfig, m Predictor) (Predictor, error)
+
+// RegistryOption configures a Registry
+type RegistryOption func(r *Registry) *Registry
+
+// WithWrapper applies w to every model loaded from a config, after the batcher, in the order they are set
+func WithWrapper(w Wrapper) RegistryOption {
+	return func(r *Registry) *Registry {
+		r.wrap = append(r.wrap, w)
+		return r
+	}
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		entries: map[string][]*Entry{},
+		vocabs:  map[string]vocab.Dict{},
+	}
+	for _, opt := range opts {
+		r = opt(r)
+	}
+	return r
+}
+
+// LoadRegistry loads every model in the JSON config file at path, opts are applied to each model.
+// Models that were loaded are closed if a later one fails.
+func LoadRegistry(path string, opts ...BertOption) (*Registry, error) {
+	r := NewRegistry()
+	if err := r.LoadFile(path, opts...); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// LoadFile loads every model in the JSON config file at path into the registry, opts are applied to each model.
+// Models loaded before a failing one stay registered.
+func (r *Registry) LoadFile(path string, opts ...BertOption) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg RegistryConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return fmt.Errorf("invalid registry config %s: %w", path, err)
+	}
+	base := filepath.Dir(path)
+	for _, mc := range cfg.Models {
+		mc.Path = resolvePath(base, mc.Path)
+		mc.Vocab = resolvePath(base, mc.Vocab)
+		if err := r.Load(mc, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load loads the configured model and registers it.
+// Vocab files are read once and shared between models using the same file.
+func (r *Registry) Load(mc ModelConfig, opts ...BertOption) error {
+	if err := r.available(mc); err != nil {
+		return err
+	}
+	opts = append(opts, WithName(mc.ID()))
+	if mc.Tags != nil {
+		opts = append(opts, WithTags(mc.Tags...))
+	}
+	if mc.SeqLen > 0 {
+		opts = append(opts, WithSeqLen(mc.SeqLen))
+	}
+	if mc.Concurrency > 0 {
+		opts = append(opts, WithConcurrency(mc.Concurrency))
+	}
+	if mc.FailFast {
+		opts = append(opts, WithFailFast())
+	}
+	if mc.Vocab == "" {
+		dir, err := ExportDir(mc.Path)
+		if err != nil {
+			return fmt.Errorf("model %s: %w", mc.Name, err)
+		}
+		mc.Vocab = findVocab(mc.Path, dir)
+	}
+	voc, err := r.vocab(mc.Vocab)
+	if err != nil {
+		return fmt.Errorf("model %s: %w", mc.Name, err)
+	}
+	opts = append(opts, WithVocab(voc))
+	var b Bert
+	switch mc.Type {
+	case EmbeddingModel:
+		b, err = NewEmbeddings(mc.Path, opts...)
+	case ClassifierModel:
+		b, err = NewBertClassifier(mc.Path, mc.Vocab, opts...)
+	default:
+		err = fmt.Errorf("unknown model type %q", mc.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("model %s: %w", mc.Name, err)
+	}
+	var m Predictor = b
+	if mc.Batching != nil {
+		m = NewBatcher(b, mc.Batching.options()...)
+	}
+	for _, w := range r.wrap {
+		wrapped, err := w(mc, m)
+		if err != nil {
+			closeModel(m)
+			return fmt.Errorf("model %s: %w", mc.Name, err)
+		}
+		m = wrapped
+	}
+	if err := r.Register(Entry{ModelConfig: mc, Model: m}); err != nil {
+		closeModel(m)
+		return err
+	}
+	return nil
+}
+
+// closeModel closes m if it is an io.Closer
+func closeModel(m Predictor) {
+	if c, ok := m.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// Register adds a model that was built outside of the registry, such as a Reloadable.
+// The seq len is taken from the model when it reports one.
+func (r *Registry) Register(e Entry) error {
+	if e.Name == "" || e.Model == nil {
+		return fmt.Errorf("registry entries require a name and model")
+	}
+	if s, ok := e.Model.(interface{ SeqLen() int32 }); ok && s.SeqLen() > 0 {
+		e.SeqLen = s.SeqLen()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.entries[e.Name] {
+		if other.Version == e.Version {
+			return fmt.Errorf("model %s version %q is already registered", e.Name, e.Version)
+		}
+	}
+	vs := append(r.entries[e.Name], &e)
+	sort.SliceStable(vs, func(i, j int) bool { return versionLess(vs[i].Version, vs[j].Version) })
+	r.entries[e.Name] = vs
+	return nil
+}
+
+// Get returns the model with the given name and version, an empty version returns the newest one
+func (r *Registry) Get(name, version string) (*Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	vs := r.entries[name]
+	if len(vs) == 0 {
+		return nil, fmt.Errorf("model %s not found", name)
+	}
+	if version == "" {
+		return vs[len(vs)-1], nil
+	}
+	for _, e := range vs {
+		if e.Version == version {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("model %s version %q not found", name, version)
+}
+
+// Entries returns every registered model sorted by name and version
+func (r *Registry) Entries() []*Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var es []*Entry
+	for _, name := range names {
+		es = append(es, r.entries[name]...)
+	}
+	return es
+}
+
+// Close closes the session of every registered model and empties the registry.
+// The first error is returned after attempting to close all models.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	entries := r.entries
+	r.entries = map[string][]*Entry{}
+	r.vocabs = map[string]vocab.Dict{}
+	r.mu.Unlock()
+	var first error
+	for _, vs := range entries {
+		for _, e := range vs {
+			c, ok := e.Model.(io.Closer)
+			if !ok {
+				continue
+			}
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// available checks a config before a model is loaded for it
+func (r *Registry) available(mc ModelConfig) error {
+	if mc.Name == "" {
+		return fmt.Errorf("model config for %s requires a name", mc.Path)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, other := range r.entries[mc.Name] {
+		if other.Version == mc.Version { // Get of an empty version resolves the latest, so entries are compared
+			return fmt.Errorf("model %s version %q is already registered", mc.Name, mc.Version)
+		}
+	}
+	return nil
+}
+
+// vocab reads the vocab at path once, later calls return the same instance
+func (r *Registry) vocab(path string) (vocab.Dict, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return vocab.Dict{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if voc, ok := r.vocabs[key]; ok {
+		return voc, nil
+	}
+	voc, err := vocab.FromFile(key)
+	if err != nil {
+		return vocab.Dict{}, err
+	}
+	r.vocabs[key] = voc
+	return voc, nil
+}
+
+// versionLess orders unversioned first, then numeric versions numerically and others lexically
+func versionLess(x, y string) bool {
+	xi, xerr := strconv.ParseInt(x, 10, 64)
+	yi, yerr := strconv.ParseInt(y, 10, 64)
+	switch {
+	case x == "" || y == "":
+		return x == "" && y != ""
+	case xerr == nil && yerr == nil:
+		return xi < yi
+	case xerr == nil:
+		return true
+	case yerr == nil:
+		return false
+	}
+	return x < y
+}
+
+func resolvePath(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
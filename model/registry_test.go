@@ -0,0 +1,101 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// closeCounter is a Bert that records when it is closed
+type closeCounter struct {
+	Bert
+	closed *int
+}
+
+func (c closeCounter) Close() error {
+	*c.closed++
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	var closed int
+	for _, v := range []string{"10", "", "9", "beta"} {
+		e := Entry{ModelConfig: ModelConfig{Name: "faq", Version: v}, Model: closeCounter{newFakeBert(&fakePredictor{}), &closed}}
+		if err := r.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Register(Entry{ModelConfig: ModelConfig{Name: "faq", Version: "9"}, Model: newFakeBert(nil)}); err == nil {
+		t.Errorf("Expected Error for duplicate version")
+	}
+	// duplicates are rejected before loading, the path doesn't exist
+	if err := r.Load(ModelConfig{Name: "faq", Path: "missing"}); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("Invalid Duplicate Error - Want: already registered, Got: %v", err)
+	}
+	e, err := r.Get("faq", "")
+	if err != nil || e.Version != "beta" {
+		t.Errorf("Invalid Newest Version - Want: beta, Got: %+v %v", e, err)
+	}
+	if e, err = r.Get("faq", "9"); err != nil || e.Version != "9" {
+		t.Errorf("Invalid Version - Want: 9, Got: %+v %v", e, err)
+	}
+	if _, err = r.Get("faq", "11"); err == nil {
+		t.Errorf("Expected Error for missing version")
+	}
+	var versions []string
+	for _, e := range r.Entries() {
+		versions = append(versions, e.Version)
+	}
+	if got := strings.Join(versions, ","); got != ",9,10,beta" {
+		t.Errorf("Invalid Entry Order - Got: %s", got)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if closed != 4 || len(r.Entries()) != 0 {
+		t.Errorf("Expected all models closed, Got %d closed %d remaining", closed, len(r.Entries()))
+	}
+}
+
+func TestRegistryVocabShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultVocabFile)
+	if err := os.WriteFile(path, []byte(strings.Join(testVocab, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRegistry()
+	voc, err := r.vocab(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	again, err := r.vocab(path)
+	if err != nil {
+		t.Fatalf("Expected cached vocab, Got: %s", err)
+	}
+	if again.Size() != voc.Size() || again.GetID("c") != 3 {
+		t.Errorf("Invalid Shared Vocab - Got size %d", again.Size())
+	}
+}
+
+func TestLoadRegistryInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeSavedModel(t, filepath.Join(dir, "model", "1"), []string{"serve"})
+	os.WriteFile(filepath.Join(dir, "model", DefaultVocabFile), []byte("[CLS]\n[SEP]"), 0644)
+	for _, test := range []struct {
+		config string
+		err    string
+	}{
+		{`{"models": [`, "invalid registry config"},
+		{`{"models": [{"path": "model"}]}`, "requires a name"},
+		{`{"models": [{"name": "x", "type": "ner", "path": "model"}]}`, "unknown model type"},
+		{`{"models": [{"name": "x", "type": "embedding", "path": "missing"}]}`, "no such file"},
+	} {
+		path := filepath.Join(dir, "models.json")
+		os.WriteFile(path, []byte(test.config), 0644)
+		if _, err := LoadRegistry(path); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Invalid Error for %s - Want: %s, Got: %v", test.config, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// DefaultSmokeText is predicted to validate a freshly loaded model before it is swapped in
+const DefaultSmokeText = "the dog is hairy."
+
+// ErrClosed is returned when predicting with a closed model holder
+var ErrClosed = errors.New("model is closed")
+
+// LoadFunc loads a new instance of a model, ex a closure over NewEmbeddings
+type LoadFunc func() (Bert, error)
+
+// Reloadable holds a model that can be swapped for a freshly loaded one while requests are running.
+// A swapped out model is closed once its in-flight requests finish.
+type Reloadable struct {
+	load     LoadFunc
+	smoke    []string
+	onReload func(error)
+
+	mu     sync.RWMutex
+	cur    *generation
+	reload sync.Mutex // serializes reloads
+}
+
+// generation is a loaded model and the requests currently using it, retired is closed once the model is closed
+type generation struct {
+	b       Bert
+	flight  sync.WaitGroup
+	retired chan struct{}
+}
+
+func newGeneration(b Bert) *generation {
+	return &generation{b: b, retired: make(chan struct{})}
+}
+
+// ReloadOption configures a Reloadable
+type ReloadOption func(r *Reloadable) *Reloadable
+
+// WithSmokeTest sets the texts predicted to validate a new model before it is swapped in
+func WithSmokeTest(texts ...string) ReloadOption {
+	return func(r *Reloadable) *Reloadable {
+		r.smoke = texts
+		return r
+	}
+}
+
+// WithOnReload is called after each reload attempted by Watch, with a nil error on success
+func WithOnReload(fn func(error)) ReloadOption {
+	return func(r *Reloadable) *Reloadable {
+		r.onReload = fn
+		return r
+	}
+}
+
+// NewReloadable loads and validates the initial model with load, which is reused on each reload
+func NewReloadable(load LoadFunc, opts ...ReloadOption) (*Reloadable, error) {
+	r := &Reloadable{
+		load:     load,
+		smoke:    []string{DefaultSmokeText},
+		onReload: func(error) {},
+	}
+	for _, opt := range opts {
+		r = opt(r)
+	}
+	b, err := r.validated()
+	if err != nil {
+		return nil, err
+	}
+	r.cur = newGeneration(b)
+	return r, nil
+}
+
+// Features will tokenize texts with the current model
+func (r *Reloadable) Features(texts ...string) []tokenize.Feature {
+	g, err := r.acquire()
+	if err != nil {
+		return nil
+	}
+	defer g.flight.Done()
+	return g.b.Features(texts...)
+}
+
+// PredictValues will run the current model on the provided texts
+func (r *Reloadable) PredictValues(texts ...string) ([]ValueProvider, error) {
+	return r.PredictValuesContext(context.Background(), texts...)
+}
+
+// PredictValuesContext will run the current model on the provided texts.
+// A reload during the call doesn't affect it, the model it started with stays open until it returns.
+func (r *Reloadable) PredictValuesContext(ctx context.Context, texts ...string) ([]ValueProvider, error) {
+	g, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer g.flight.Done()
+	return g.b.PredictValuesContext(ctx, texts...)
+}
+
+// PredictFeaturesContext will run the current model on features from Features, like PredictValuesContext
+func (r *Reloadable) PredictFeaturesContext(ctx context.Context, fs ...tokenize.Feature) ([]ValueProvider, error) {
+	g, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer g.flight.Done()
+	return g.b.PredictFeaturesContext(ctx, fs...)
+}
+
+// Reload loads a new model in the calling goroutine while requests keep using the current one.
+// The new model is swapped in if it passes the smoke test, otherwise the current one is kept.
+func (r *Reloadable) Reload() error {
+	r.reload.Lock()
+	defer r.reload.Unlock()
+	b, err := r.validated()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	old := r.cur
+	if old != nil {
+		r.cur = newGeneration(b)
+	}
+	r.mu.Unlock()
+	if old == nil { // closed while loading
+		b.Close()
+		return ErrClosed
+	}
+	go retire(old)
+	return nil
+}
+
+// Watch polls the SavedModel at path every interval and reloads when it or its CalibrationFile changes,
+// including when a newer version directory appears. It blocks until ctx is done.
+func (r *Reloadable) Watch(ctx context.Context, path string, interval time.Duration) error {
+	last := exportStamp(path)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+		stamp := exportStamp(path)
+		if stamp == "" || stamp == last {
+			continue
+		}
+		err := r.Reload()
+		if err == nil {
+			last = stamp
+		}
+		r.onReload(err)
+	}
+}
+
+// Close waits for in-flight requests and closes the current model
+func (r *Reloadable) Close() error {
+	r.mu.Lock()
+	g := r.cur
+	r.cur = nil
+	r.mu.Unlock()
+	if g == nil {
+		return ErrClosed
+	}
+	g.flight.Wait()
+	defer close(g.retired)
+	return g.b.Close()
+}
+
+// acquire returns the current generation, callers must call flight.Done when finished with it
+func (r *Reloadable) acquire() (*generation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cur == nil {
+		return nil, ErrClosed
+	}
+	r.cur.flight.Add(1)
+	return r.cur, nil
+}
+
+// validated loads a model and runs the smoke test against it
+func (r *Reloadable) validated() (Bert, error) {
+	b, err := r.load()
+	if err != nil {
+		return Bert{}, err
+	}
+	if len(r.smoke) > 0 {
+		if _, err := b.PredictValues(r.smoke...); err != nil {
+			b.Close()
+			return Bert{}, err
+		}
+	}
+	return b, nil
+}
+
+// retire closes a swapped out generation after its in-flight requests finish
+func retire(g *generation) {
+	g.flight.Wait()
+	g.b.Close()
+	close(g.retired)
+}
+
+// exportStamp identifies the current SavedModel at path and its calibration, empty if it can't be resolved
+func exportStamp(path string) string {
+	dir, err := ExportDir(path)
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{SavedModelFile, SavedModelTextFile} {
+		if fi, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			stamp := dir + "@" + fi.ModTime().String()
+			if fi, err := os.Stat(filepath.Join(dir, CalibrationFile)); err == nil {
+				stamp += "+" + fi.ModTime().String()
+			}
+			return stamp
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloadable(t *testing.T) {
+	var loads int
+	failing := errors.New("smoke test failed")
+	preds := []*fakePredictor{{}, {delay: 50 * time.Millisecond}, {err: failing}}
+	r, err := NewReloadable(func() (Bert, error) {
+		p := preds[loads]
+		loads++
+		return newFakeBert(p), nil
+	}, WithSmokeTest("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	preds[0].started, preds[0].release = make(chan struct{}), make(chan struct{})
+	old := r.cur
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { // in flight across the swap
+		defer wg.Done()
+		if _, err := r.PredictValues("b"); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-preds[0].started
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-old.retired:
+		t.Error("Expected the old model to stay open while a request is in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(preds[0].release)
+	wg.Wait()
+	select {
+	case <-old.retired:
+	case <-time.After(time.Second):
+		t.Error("Expected the old model to be closed once its request returned")
+	}
+	vals, err := r.PredictValues("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := vals[0].Value(); !reflect.DeepEqual(got, [][]float32{{3}}) {
+		t.Errorf("Invalid Values - Got: %v", got)
+	}
+	if preds[1].calls != 2 { // smoke + c
+		t.Errorf("Expected new model to serve requests, Got %d calls", preds[1].calls)
+	}
+	if err := r.Reload(); err != failing {
+		t.Errorf("Invalid Reload Error - Want: %v, Got: %v", failing, err)
+	}
+	if _, err := r.PredictValues("d"); err != nil {
+		t.Errorf("Expected previous model to be kept after a failed reload, Got: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.PredictValues("a"); err != ErrClosed {
+		t.Errorf("Invalid Closed Error - Got: %v", err)
+	}
+}
+
+func TestExportStamp(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, SavedModelFile), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := exportStamp(dir)
+	if saved == "" {
+		t.Fatalf("Invalid Stamp - Want: the SavedModel stamp, Got: %q", saved)
+	}
+	path := filepath.Join(dir, CalibrationFile)
+	if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	calibrated := exportStamp(dir)
+	if calibrated == saved {
+		t.Errorf("Invalid Calibrated Stamp - Want: other than %q, Got: %q", saved, calibrated)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := exportStamp(dir); got == calibrated || got == saved {
+		t.Errorf("Invalid Recalibrated Stamp - Want: other than %q, Got: %q", calibrated, got)
+	}
+}
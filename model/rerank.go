@@ -0,0 +1,194 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Reranker Defaults
+const (
+	DefaultRerankBatch = 32
+	RelevantLabel      = 1
+)
+
+// Reranker scores the relevance of passages to a query with a cross-encoder, a classifier of sentence pairs
+// such as a model fine-tuned with run_classifier and loaded by NewBertClassifier. The query and a passage are
+// read together by the model, it is slower but more accurate than comparing embeddings, so it is used to
+// reorder the top results of a search.
+type Reranker struct {
+	m       Predictor
+	label   int
+	batch   int
+	workers int
+}
+
+// RerankOption configures a Reranker
+type RerankOption func(r *Reranker) *Reranker
+
+// WithRelevantLabel sets the class of the classifier probabilities used as the relevance score, RelevantLabel by default.
+// Models with a single output, ex a regression head, are scored by it.
+func WithRelevantLabel(label int) RerankOption {
+	return func(r *Reranker) *Reranker {
+		r.label = label
+		return r
+	}
+}
+
+// WithRerankBatch sets the number of pairs predicted together
+func WithRerankBatch(n int) RerankOption {
+	return func(r *Reranker) *Reranker {
+		r.batch = n
+		return r
+	}
+}
+
+// WithRerankWorkers sets the number of batches predicted concurrently, 1 by default.
+// The model bounds its session runs itself, ex with WithConcurrency.
+func WithRerankWorkers(n int) RerankOption {
+	return func(r *Reranker) *Reranker {
+		r.workers = n
+		return r
+	}
+}
+
+// NewReranker returns a Reranker scoring pairs with m, a pair classifier
+func NewReranker(m Predictor, opts ...RerankOption) *Reranker {
+	r := &Reranker{m: m, label: RelevantLabel, batch: DefaultRerankBatch, workers: 1}
+	for _, opt := range opts {
+		r = opt(r)
+	}
+	if r.batch <= 0 {
+		r.batch = DefaultRerankBatch
+	}
+	if r.workers <= 0 {
+		r.workers = 1
+	}
+	return r
+}
+
+// Ranked is a passage of a Rerank, Index is its position in the passages given to Rerank
+type Ranked struct {
+	Index   int
+	Passage string
+	Score   float32
+}
+
+// Rerank returns passages by decreasing relevance to query, ties keep the order of passages
+func (r *Reranker) Rerank(ctx context.Context, query string, passages ...string) ([]Ranked, error) {
+	scores, err := r.Scores(ctx, query, passages...)
+	if err != nil {
+		return nil, err
+	}
+	ranked := make([]Ranked, len(passages))
+	for i, p := range passages {
+		ranked[i] = Ranked{Index: i, Passage: p, Score: scores[i]}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked, nil
+}
+
+// Scores returns the relevance of each passage to query in the order of passages.
+// Duplicate passages are predicted once, the pairs are split into batches run by the workers.
+// The query and passages can't contain tokenize.SequenceSeparator as it would split their pair.
+func (r *Reranker) Scores(ctx context.Context, query string, passages ...string) ([]float32, error) {
+	var pairs []string
+	index := make([]int, len(passages)) // pair of each passage
+	seen := map[string]int{}
+	for i, p := range passages {
+		j, ok := seen[p]
+		if !ok {
+			pair, err := tokenize.JoinPair(query, p)
+			if err != nil {
+				return nil, err
+			}
+			j = len(pairs)
+			seen[p] = j
+			pairs = append(pairs, pair)
+		}
+		index[i] = j
+	}
+	rows, err := predictPairs(ctx, r.m, pairs, r.batch, r.workers)
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]float32, len(passages))
+	for i, j := range index {
+		row := rows[j]
+		switch {
+		case len(row) == 1:
+			scores[i] = row[0]
+		case r.label >= 0 && r.label < len(row):
+			scores[i] = row[r.label]
+		default:
+			return nil, fmt.Errorf("relevant label %d out of the %d classes of the model", r.label, len(row))
+		}
+	}
+	return scores, nil
+}
+
+// predictPairs returns the output rows of m, a pair classifier, for pairs.
+// The pairs are split into batches of size batch, up to workers of them are predicted concurrently.
+func predictPairs(ctx context.Context, m Predictor, pairs []string, batch, workers int) ([][]float32, error) {
+	rows := make([][]float32, len(pairs))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, workers)
+	for from := 0; from < len(pairs); from += batch {
+		to := min(from+batch, len(pairs))
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := predictRows(ctx, m, pairs[from:to], rows[from:to]); err != nil {
+				once.Do(func() { firstErr = err })
+				cancel()
+			}
+		}(from, to)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// predictRows sets the output row of each pair in rows
+func predictRows(ctx context.Context, m Predictor, pairs []string, rows [][]float32) error {
+	vals, err := m.PredictValuesContext(ctx, pairs...)
+	if err != nil {
+		return err
+	}
+	if len(vals) == 0 {
+		return fmt.Errorf("model returned no outputs")
+	}
+	probs, ok := vals[0].Value().([][]float32)
+	if !ok {
+		return fmt.Errorf("expected pair scores [][]float32, got %T", vals[0].Value())
+	}
+	if len(probs) != len(pairs) {
+		return fmt.Errorf("mismatched score count %d for %d pairs", len(probs), len(pairs))
+	}
+	copy(rows, probs)
+	return nil
+}
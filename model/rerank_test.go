@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+type pairValue [][]float32
+
+func (v pairValue) Value() interface{} {
+	return [][]float32(v)
+}
+
+// pairClassifier scores a pair by the number of words of the passage found in the query, out of 10
+type pairClassifier struct {
+	calls int32
+	pairs int32
+	err   error
+}
+
+func (c *pairClassifier) Features(texts ...string) []tokenize.Feature {
+	return nil
+}
+
+func (c *pairClassifier) PredictValues(texts ...string) ([]ValueProvider, error) {
+	return c.PredictValuesContext(context.Background(), texts...)
+}
+
+func (c *pairClassifier) PredictValuesContext(ctx context.Context, texts ...string) ([]ValueProvider, error) {
+	atomic.AddInt32(&c.calls, 1)
+	atomic.AddInt32(&c.pairs, int32(len(texts)))
+	if c.err != nil {
+		return nil, c.err
+	}
+	probs := make(pairValue, len(texts))
+	for i, text := range texts {
+		pair := strings.Split(text, tokenize.SequenceSeparator)
+		var same float32
+		for _, w := range strings.Fields(pair[1]) {
+			if strings.Contains(pair[0], w) {
+				same += 0.1
+			}
+		}
+		probs[i] = []float32{1 - same, same}
+	}
+	return []ValueProvider{probs}, nil
+}
+
+func TestRerank(t *testing.T) {
+	c := &pairClassifier{}
+	r := NewReranker(c, WithRerankBatch(2), WithRerankWorkers(2))
+	passages := []string{"cats", "dogs are hairy", "my dog", "dogs are hairy", "hairy dogs"}
+	ranked, err := r.Rerank(context.Background(), "are dogs hairy", passages...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []int
+	for _, rk := range ranked {
+		order = append(order, rk.Index)
+		if rk.Passage != passages[rk.Index] {
+			t.Errorf("Invalid Passage - Want: %q, Got: %q", passages[rk.Index], rk.Passage)
+		}
+	}
+	if want := []int{1, 3, 4, 2, 0}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Invalid Order - Want: %v, Got: %v", want, order)
+	}
+	if s := ranked[0].Score; s < 0.29 || s > 0.31 {
+		t.Errorf("Invalid Score - Want: 0.3, Got: %v", s)
+	}
+	// the duplicate passage is predicted once, 4 pairs in batches of 2
+	if c.pairs != 4 || c.calls != 2 {
+		t.Errorf("Invalid Predictions - Want: 4 pairs in 2 calls, Got: %d in %d", c.pairs, c.calls)
+	}
+	if ranked, err := r.Rerank(context.Background(), "q"); err != nil || len(ranked) != 0 {
+		t.Errorf("Invalid Empty Rerank - Want: [], Got: %v %v", ranked, err)
+	}
+}
+
+func TestRerankErrors(t *testing.T) {
+	fail := errors.New("fail")
+	r := NewReranker(&pairClassifier{err: fail}, WithRerankBatch(1), WithRerankWorkers(3))
+	if _, err := r.Scores(context.Background(), "q", "a", "b", "c", "d"); err != fail {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", fail, err)
+	}
+	r = NewReranker(&pairClassifier{}, WithRelevantLabel(2))
+	if _, err := r.Scores(context.Background(), "q", "a"); err == nil {
+		t.Errorf("Invalid Label - Want: error")
+	}
+	for _, pair := range [][2]string{{"q ||| a", "b"}, {"q", "a ||| b"}} {
+		if _, err := NewReranker(&pairClassifier{}).Scores(context.Background(), pair[0], pair[1]); err == nil {
+			t.Errorf("Invalid Error for %q - Want: an error, Got: %v", pair, err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewReranker(&pairClassifier{}).Scores(ctx, "q", "a"); err != context.Canceled {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", context.Canceled, err)
+	}
+}
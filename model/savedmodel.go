@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// SavedModel file names
+const (
+	SavedModelFile     = "saved_model.pb"
+	SavedModelTextFile = "saved_model.pbtxt"
+	AssetsDir          = "assets"
+)
+
+// ExportDir resolves path to a SavedModel directory.
+// If path has no saved_model.pb, the newest numeric version sub-directory is used, ex export/1696000000
+func ExportDir(path string) (string, error) {
+	if isSavedModel(path) {
+		return path, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var versions []int64
+	for _, e := range entries {
+		v, err := strconv.ParseInt(e.Name(), 10, 64)
+		if err != nil || !e.IsDir() || !isSavedModel(filepath.Join(path, e.Name())) {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no %s or version directories found in %s", SavedModelFile, path)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return filepath.Join(path, strconv.FormatInt(versions[0], 10)), nil
+}
+
+// SavedModelTags returns the tag set of each meta graph in the binary SavedModel at dir
+func SavedModelTags(dir string) ([][]string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, SavedModelFile))
+	if err != nil {
+		return nil, err
+	}
+	var tags [][]string
+	// SavedModel.meta_graphs = 2 -> MetaGraphDef.meta_info_def = 1 -> MetaInfoDef.tags = 4
+	err = walkFields(b, func(num protowire.Number, graph []byte) error {
+		if num != 2 {
+			return nil
+		}
+		var set []string
+		err := walkFields(graph, func(num protowire.Number, info []byte) error {
+			if num != 1 {
+				return nil
+			}
+			return walkFields(info, func(num protowire.Number, tag []byte) error {
+				if num == 4 {
+					set = append(set, string(tag))
+				}
+				return nil
+			})
+		})
+		tags = append(tags, set)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %w", SavedModelFile, dir, err)
+	}
+	return tags, nil
+}
+
+// checkTags returns an error listing the available tag sets if no meta graph in dir matches tags.
+// Text SavedModels aren't inspected and are left for tensorflow to validate.
+func checkTags(dir string, tags []string) error {
+	if _, err := os.Stat(filepath.Join(dir, SavedModelFile)); err != nil {
+		return nil
+	}
+	sets, err := SavedModelTags(dir)
+	if err != nil {
+		return err
+	}
+	for _, set := range sets {
+		if sameTags(set, tags) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no meta graph tagged %q in %s, available tag sets: %q", tags, dir, sets)
+}
+
+// findVocab looks for the vocab in the export dir, its assets and finally the path it was resolved from
+func findVocab(path, dir string) string {
+	candidates := []string{
+		filepath.Join(dir, DefaultVocabFile),
+		filepath.Join(dir, AssetsDir, DefaultVocabFile),
+		filepath.Join(path, DefaultVocabFile),
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return candidates[0] // let the vocab loader report the missing file
+}
+
+func isSavedModel(dir string) bool {
+	for _, name := range []string{SavedModelFile, SavedModelTextFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTags compares tags as sets, matching how tensorflow selects a meta graph
+func sameTags(x, y []string) bool {
+	set := make(map[string]bool, len(x))
+	for _, t := range x {
+		set[t] = true
+	}
+	for _, t := range y {
+		if !set[t] {
+			return false
+		}
+	}
+	other := make(map[string]bool, len(y))
+	for _, t := range y {
+		other[t] = true
+	}
+	return len(set) == len(other)
+}
+
+// walkFields calls fn with the payload of each length delimited field in a proto message, others are skipped
+func walkFields(b []byte, fn func(protowire.Number, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// savedModelBytes builds a minimal SavedModel proto with a meta graph per tag set
+func savedModelBytes(sets ...[]string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	for _, set := range sets {
+		var info []byte
+		info = protowire.AppendTag(info, 1, protowire.BytesType)
+		info = protowire.AppendString(info, "v1")
+		for _, tag := range set {
+			info = protowire.AppendTag(info, 4, protowire.BytesType)
+			info = protowire.AppendString(info, tag)
+		}
+		var graph []byte
+		graph = protowire.AppendTag(graph, 1, protowire.BytesType)
+		graph = protowire.AppendBytes(graph, info)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, graph)
+	}
+	return b
+}
+
+func writeSavedModel(t *testing.T, dir string, sets ...[]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SavedModelFile), savedModelBytes(sets...), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportDir(t *testing.T) {
+	root := t.TempDir()
+	if _, err := ExportDir(root); err == nil {
+		t.Errorf("Expected Error for empty export dir")
+	}
+	writeSavedModel(t, filepath.Join(root, "9"), []string{"serve"})
+	writeSavedModel(t, filepath.Join(root, "1696000000"), []string{"serve"})
+	os.MkdirAll(filepath.Join(root, "2000000000"), 0755) // no model, skipped
+	os.MkdirAll(filepath.Join(root, "latest"), 0755)
+	dir, err := ExportDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "1696000000"); dir != want {
+		t.Errorf("Invalid Export Dir - Want: %s, Got: %s", want, dir)
+	}
+	if dir, _ = ExportDir(filepath.Join(root, "9")); dir != filepath.Join(root, "9") {
+		t.Errorf("Invalid Export Dir - Want: explicit version, Got: %s", dir)
+	}
+}
+
+func TestSavedModelTags(t *testing.T) {
+	dir := t.TempDir()
+	writeSavedModel(t, dir, []string{"serve"}, []string{"serve", "gpu"})
+	tags, err := SavedModelTags(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"serve"}, {"serve", "gpu"}}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Invalid Tags - Want: %q, Got: %q", want, tags)
+	}
+	if err := checkTags(dir, []string{"gpu", "serve"}); err != nil {
+		t.Errorf("Unexpected Error: %s", err)
+	}
+	err = checkTags(dir, []string{EmbeddingModelTag})
+	if err == nil || !strings.Contains(err.Error(), `["serve" "gpu"]`) {
+		t.Errorf("Expected Error listing available tags, Got: %v", err)
+	}
+}
+
+func Test_findVocab(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "1")
+	os.MkdirAll(filepath.Join(dir, AssetsDir), 0755)
+	if got := findVocab(root, dir); got != filepath.Join(dir, DefaultVocabFile) {
+		t.Errorf("Invalid Missing Vocab Path - Got: %s", got)
+	}
+	os.WriteFile(filepath.Join(root, DefaultVocabFile), nil, 0644)
+	if got := findVocab(root, dir); got != filepath.Join(root, DefaultVocabFile) {
+		t.Errorf("Invalid Root Vocab Path - Got: %s", got)
+	}
+	os.WriteFile(filepath.Join(dir, AssetsDir, DefaultVocabFile), nil, 0644)
+	if got := findVocab(root, dir); got != filepath.Join(dir, AssetsDir, DefaultVocabFile) {
+		t.Errorf("Invalid Assets Vocab Path - Got: %s", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	root := t.TempDir()
+	writeSavedModel(t, filepath.Join(root, "1"), []string{"serve"})
+	if _, err := Fingerprint(root, ""); err == nil {
+		t.Errorf("Expected Error for missing vocab")
+	}
+	vocabPath := filepath.Join(root, DefaultVocabFile)
+	os.WriteFile(vocabPath, []byte("[CLS]\n[SEP]\n"), 0644)
+	fp, err := Fingerprint(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := Fingerprint(filepath.Join(root, "1"), vocabPath); again != fp || len(fp) != 32 {
+		t.Errorf("Invalid Fingerprint - Want: %s, Got: %s", fp, again)
+	}
+	os.WriteFile(vocabPath, []byte("[CLS]\n[SEP]\n[UNK]\n"), 0644)
+	if vocab, _ := Fingerprint(root, ""); vocab == fp {
+		t.Errorf("Invalid Fingerprint - Want: changed with the vocab, Got: %s", vocab)
+	}
+	os.MkdirAll(filepath.Join(root, "1", "variables"), 0755)
+	os.WriteFile(filepath.Join(root, "1", VariablesIndexFile), []byte("weights"), 0644)
+	if weights, _ := Fingerprint(root, ""); weights == fp {
+		t.Errorf("Invalid Fingerprint - Want: changed with the weights, Got: %s", weights)
+	}
+}
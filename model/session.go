@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// JITLevel maps to OptimizerOptions.GlobalJitLevel and toggles XLA JIT compilation
+type JITLevel int32
+
+// JIT levels, JITDefault leaves the choice to tensorflow
+const (
+	JITDefault JITLevel = 0
+	JITOff     JITLevel = -1
+	JITOn      JITLevel = 1
+	JITOn2     JITLevel = 2
+)
+
+// OptLevel maps to OptimizerOptions.Level for graph optimizations
+type OptLevel int32
+
+// Graph optimization levels, OptL1 is the tensorflow default
+const (
+	OptL1 OptLevel = 0
+	OptL0 OptLevel = -1
+)
+
+// SessionConfig is a typed subset of tensorflow's ConfigProto used when loading a SavedModel.
+// Zero values leave the tensorflow defaults in place.
+type SessionConfig struct {
+	// IntraOpThreads is the number of threads used to parallelize a single op
+	IntraOpThreads int32
+	// InterOpThreads is the number of threads used to run independent ops
+	InterOpThreads int32
+	// CPUOnly hides all GPUs from the session
+	CPUOnly bool
+	// AllowSoftPlacement falls back to CPU for ops without a kernel on the requested device
+	AllowSoftPlacement bool
+	// LogDevicePlacement logs the device each op is placed on
+	LogDevicePlacement bool
+	// OperationTimeout bounds blocking ops in the session, rounded to milliseconds
+	OperationTimeout time.Duration
+	// GPUMemoryFraction is the fraction of GPU memory the process may allocate, between 0 and 1
+	GPUMemoryFraction float64
+	// GPUAllowGrowth allocates GPU memory on demand instead of up front
+	GPUAllowGrowth bool
+	// JIT toggles XLA JIT compilation for the whole graph
+	JIT JITLevel
+	// OptLevel sets the graph optimizer level
+	OptLevel OptLevel
+}
+
+// ConfigProto field numbers, from tensorflow/core/protobuf/config.proto
+const (
+	configDeviceCount        = 1
+	configIntraOpThreads     = 2
+	configInterOpThreads     = 5
+	configGPUOptions         = 6
+	configAllowSoftPlacement = 7
+	configLogDevicePlacement = 8
+	configGraphOptions       = 10
+	configOperationTimeout   = 11
+
+	gpuMemoryFraction = 1
+	gpuAllowGrowth    = 4
+
+	graphOptimizerOptions = 3
+
+	optimizerOptLevel = 3
+	optimizerJITLevel = 5
+)
+
+// Marshal serializes the config into ConfigProto bytes, as expected by tf.SessionOptions
+func (c SessionConfig) Marshal() ([]byte, error) {
+	if c.IntraOpThreads < 0 || c.InterOpThreads < 0 {
+		return nil, fmt.Errorf("session threads must not be negative, 0 uses the TF default, intra %d inter %d", c.IntraOpThreads, c.InterOpThreads)
+	}
+	if c.GPUMemoryFraction < 0 || c.GPUMemoryFraction > 1 {
+		return nil, fmt.Errorf("GPU memory fraction %v not in [0, 1]", c.GPUMemoryFraction)
+	}
+	var b []byte
+	if c.CPUOnly {
+		var entry []byte // map<string, int32> entry {"GPU": 0}
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, "GPU")
+		entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+		entry = protowire.AppendVarint(entry, 0)
+		b = protowire.AppendTag(b, configDeviceCount, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	b = appendInt(b, configIntraOpThreads, int64(c.IntraOpThreads))
+	b = appendInt(b, configInterOpThreads, int64(c.InterOpThreads))
+	var gpu []byte
+	if c.GPUMemoryFraction > 0 {
+		gpu = protowire.AppendTag(gpu, gpuMemoryFraction, protowire.Fixed64Type)
+		gpu = protowire.AppendFixed64(gpu, math.Float64bits(c.GPUMemoryFraction))
+	}
+	gpu = appendBool(gpu, gpuAllowGrowth, c.GPUAllowGrowth)
+	b = appendMessage(b, configGPUOptions, gpu)
+	b = appendBool(b, configAllowSoftPlacement, c.AllowSoftPlacement)
+	b = appendBool(b, configLogDevicePlacement, c.LogDevicePlacement)
+	var opt []byte
+	opt = appendInt(opt, optimizerOptLevel, int64(c.OptLevel))
+	opt = appendInt(opt, optimizerJITLevel, int64(c.JIT))
+	b = appendMessage(b, configGraphOptions, appendMessage(nil, graphOptimizerOptions, opt))
+	b = appendInt(b, configOperationTimeout, c.OperationTimeout.Milliseconds())
+	return b, nil
+}
+
+// appendInt appends a varint field, skipping zero values as proto3 does.
+// Negative values are sign extended to 64 bits, matching int32 and enum encoding.
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(v))
+}
+
+// appendMessage appends an embedded message field, skipping empty messages
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	if len(msg) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
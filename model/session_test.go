@@ -0,0 +1,44 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSessionConfigMarshal(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		cfg  SessionConfig
+		want []byte
+	}{
+		{"empty", SessionConfig{}, nil},
+		{"threads", SessionConfig{IntraOpThreads: 2, InterOpThreads: 1}, []byte{0x10, 0x02, 0x28, 0x01}},
+		{"cpu only", SessionConfig{CPUOnly: true}, []byte{0x0a, 0x07, 0x0a, 0x03, 'G', 'P', 'U', 0x10, 0x00}},
+		{"soft placement", SessionConfig{AllowSoftPlacement: true}, []byte{0x38, 0x01}},
+		{"allow growth", SessionConfig{GPUAllowGrowth: true}, []byte{0x32, 0x02, 0x20, 0x01}},
+		{"timeout", SessionConfig{OperationTimeout: time.Second}, []byte{0x58, 0xe8, 0x07}},
+		{"jit on", SessionConfig{JIT: JITOn}, []byte{0x52, 0x04, 0x1a, 0x02, 0x28, 0x01}},
+		{"jit off", SessionConfig{JIT: JITOff}, append([]byte{0x52, 0x0d, 0x1a, 0x0b, 0x28},
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01)},
+	} {
+		b, err := test.cfg.Marshal()
+		if err != nil {
+			t.Errorf("Test %s - Unexpected Error: %s", test.name, err)
+		}
+		if !bytes.Equal(b, test.want) {
+			t.Errorf("Test %s - Invalid ConfigProto - Want: % x, Got: % x", test.name, test.want, b)
+		}
+	}
+}
+
+func TestSessionConfigMarshalInvalid(t *testing.T) {
+	for _, cfg := range []SessionConfig{
+		{IntraOpThreads: -1},
+		{GPUMemoryFraction: 1.5},
+	} {
+		if _, err := cfg.Marshal(); err == nil {
+			t.Errorf("Expected Error for %+v", cfg)
+		}
+	}
+}
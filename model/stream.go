@@ -0,0 +1,194 @@
+package model
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// DefaultStreamBatch is the batch size of streams when the model has none set
+const DefaultStreamBatch = 32
+
+// StreamResult is the prediction for the text received at Index of a stream
+type StreamResult struct {
+	Index  int
+	Values []ValueProvider
+	Err    error
+}
+
+// stream gathers texts into batches that are predicted by a fixed number of workers
+type stream struct {
+	batch     int
+	workers   int
+	unordered bool
+}
+
+// StreamOption configures PredictStream
+type StreamOption func(s *stream) *stream
+
+// WithStreamBatch sets the max number of texts predicted together
+func WithStreamBatch(n int) StreamOption {
+	return func(s *stream) *stream {
+		s.batch = n
+		return s
+	}
+}
+
+// WithUnordered sends results as soon as their batch is done instead of in input order
+func WithUnordered() StreamOption {
+	return func(s *stream) *stream {
+		s.unordered = true
+		return s
+	}
+}
+
+// streamBatch is a run of texts starting at index from
+type streamBatch struct {
+	from  int
+	texts []string
+	res   chan []StreamResult
+}
+
+// PredictStream predicts texts as they are received, batching whatever is available up to the batch size.
+// Batches run concurrently on as many workers as the model has session slots.
+// Results are sent in input order unless WithUnordered is set, they are tagged with their input index either way.
+// A failed batch reports its error in the result of each of its texts and the stream continues.
+// The returned channel is closed once texts is closed and all results are sent, or when ctx is done.
+func (b Bert) PredictStream(ctx context.Context, texts <-chan string, opts ...StreamOption) <-chan StreamResult {
+	s := &stream{batch: b.batchSize, workers: b.concurrency}
+	if b.pool != nil {
+		s.workers = b.pool.Size()
+	}
+	for _, opt := range opts {
+		s = opt(s)
+	}
+	if s.batch <= 0 {
+		s.batch = DefaultStreamBatch
+	}
+	if s.workers <= 0 {
+		s.workers = runtime.GOMAXPROCS(0)
+	}
+	out := make(chan StreamResult)
+	work := make(chan streamBatch)
+	order := make(chan streamBatch, s.workers)
+	go s.gather(ctx, texts, work, order)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sb := range work {
+				res := b.predictStreamBatch(ctx, sb)
+				if !s.unordered {
+					sb.res <- res
+					continue
+				}
+				if !send(ctx, out, res) {
+					return
+				}
+			}
+		}()
+	}
+	if s.unordered {
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+		return out
+	}
+	go func() {
+		defer close(out)
+		for sb := range order {
+			select {
+			case res := <-sb.res:
+				if !send(ctx, out, res) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// gather cuts batches from texts, a partial batch is cut when no more texts are ready
+func (s *stream) gather(ctx context.Context, texts <-chan string, work, order chan<- streamBatch) {
+	defer close(work)
+	defer close(order)
+	next := 0
+	var batch []string
+	flush := func() bool {
+		sb := streamBatch{from: next, texts: batch, res: make(chan []StreamResult, 1)}
+		next += len(batch)
+		batch = nil
+		if !s.unordered {
+			select {
+			case order <- sb:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		select {
+		case work <- sb:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		var text string
+		var ok bool
+		if len(batch) == 0 {
+			select {
+			case text, ok = <-texts:
+			case <-ctx.Done():
+				return
+			}
+		} else {
+			select {
+			case text, ok = <-texts:
+			case <-ctx.Done():
+				return
+			default:
+				if !flush() {
+					return
+				}
+				continue
+			}
+		}
+		if !ok {
+			if len(batch) > 0 {
+				flush()
+			}
+			return
+		}
+		if batch = append(batch, text); len(batch) == s.batch && !flush() {
+			return
+		}
+	}
+}
+
+// predictStreamBatch predicts a batch and splits it into a result per text
+func (b Bert) predictStreamBatch(ctx context.Context, sb streamBatch) []StreamResult {
+	res := make([]StreamResult, len(sb.texts))
+	vals, err := b.PredictValuesContext(ctx, sb.texts...)
+	for i := range res {
+		res[i] = StreamResult{Index: sb.from + i, Err: err}
+		if err == nil {
+			res[i].Values, res[i].Err = sliceValues(vals, i, i+1)
+		}
+	}
+	return res
+}
+
+func send(ctx context.Context, out chan<- StreamResult, res []StreamResult) bool {
+	for _, r := range res {
+		select {
+		case out <- r:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func streamTexts(texts ...string) <-chan string {
+	c := make(chan string)
+	go func() {
+		defer close(c)
+		for _, text := range texts {
+			c <- text
+		}
+	}()
+	return c
+}
+
+func TestPredictStream(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e", "f", "g", "a", "b", "c"}
+	for _, test := range []struct {
+		name string
+		opts []StreamOption
+	}{
+		{"ordered", []StreamOption{WithStreamBatch(3)}},
+		{"unordered", []StreamOption{WithStreamBatch(3), WithUnordered()}},
+	} {
+		b := newFakeBert(&fakePredictor{}, WithConcurrency(3))
+		got := make([]interface{}, len(texts))
+		i := 0
+		for res := range b.PredictStream(context.Background(), streamTexts(texts...), test.opts...) {
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+			if test.name == "ordered" && res.Index != i {
+				t.Errorf("Invalid Order - Want: %d, Got: %d", i, res.Index)
+			}
+			got[res.Index] = res.Values[0].Value()
+			i++
+		}
+		if i != len(texts) {
+			t.Errorf("Invalid Result Count for %s - Want: %d, Got: %d", test.name, len(texts), i)
+		}
+		for j, text := range texts {
+			want := [][]float32{{float32(text[0] - 'a' + 1)}}
+			if !reflect.DeepEqual(got[j], want) {
+				t.Errorf("Invalid Values for %s %d - Want: %v, Got: %v", test.name, j, want, got[j])
+			}
+		}
+	}
+}
+
+func TestPredictStreamErrors(t *testing.T) {
+	fail := errors.New("fail")
+	b := newFakeBert(&fakePredictor{err: fail})
+	n := 0
+	for res := range b.PredictStream(context.Background(), streamTexts("a", "b", "c")) {
+		if res.Err != fail {
+			t.Errorf("Invalid Error - Want: %v, Got: %v", fail, res.Err)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("Invalid Result Count - Want: 3, Got: %d", n)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for res := range newFakeBert(&fakePredictor{}).PredictStream(ctx, make(chan string)) {
+		t.Errorf("Invalid Result after cancel - Got: %+v", res)
+	}
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the spans of the pipeline
+const TracerName = "github.com/sunhailin-Leo/gobert/model"
+
+// Span names of the pipeline stages, each sub-batch has a span per stage below the SpanPredict span.
+// SpanInput is below SpanRun since feeds are built once the session is running.
+const (
+	SpanPredict  = "gobert.Predict"
+	SpanFeatures = "gobert.Features"
+	SpanTensors  = "gobert.Tensors"
+	SpanInput    = "gobert.Input"
+	SpanRun      = "gobert.SessionRun"
+	SpanValues   = "gobert.Values"
+)
+
+// Span attribute keys
+const (
+	AttrModel     = attribute.Key("gobert.model")
+	AttrBatchSize = attribute.Key("gobert.batch_size")
+	AttrSeqLen    = attribute.Key("gobert.seq_len")
+	AttrTruncated = attribute.Key("gobert.truncated")
+	AttrUnknown   = attribute.Key("gobert.unknown_tokens")
+)
+
+// tracer returns the tracer of the configured provider, or of the global one
+func (b Bert) tracer() trace.Tracer {
+	tp := b.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(TracerName)
+}
+
+// startSpan starts a span of a pipeline stage with the model attributes
+func (b Bert) startSpan(ctx context.Context, name string, size int) (context.Context, trace.Span) {
+	return b.tracer().Start(ctx, name, trace.WithAttributes(
+		AttrModel.String(b.name),
+		AttrBatchSize.Int(size),
+		AttrSeqLen.Int(int(b.factory.SeqLen)),
+	))
+}
+
+// endSpan records err on span before ending it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	b := newFakeBert(&fakePredictor{}, WithName("faq:1"), WithTracerProvider(tp))
+	if _, err := b.PredictValuesContext(context.Background(), "a zz", "a b"); err != nil {
+		t.Fatal(err)
+	}
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exp.GetSpans() {
+		spans[s.Name] = s
+	}
+	parents := map[string]string{
+		SpanFeatures: SpanPredict,
+		SpanTensors:  SpanPredict,
+		SpanRun:      SpanPredict,
+		SpanInput:    SpanRun,
+		SpanValues:   SpanPredict,
+	}
+	root, ok := spans[SpanPredict]
+	if !ok {
+		t.Fatalf("Invalid Spans - Want: %s, Got: %v", SpanPredict, spans)
+	}
+	for name, parent := range parents {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("Missing Span - Want: %s", name)
+			continue
+		}
+		if s.Parent.SpanID() != spans[parent].SpanContext.SpanID() || s.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("Invalid Parent of %s - Want: %s", name, parent)
+		}
+	}
+	want := map[attribute.Key]attribute.Value{
+		AttrModel:     attribute.StringValue("faq:1"),
+		AttrBatchSize: attribute.IntValue(2),
+		AttrSeqLen:    attribute.IntValue(8),
+	}
+	for _, s := range spans {
+		got := map[attribute.Key]attribute.Value{}
+		for _, kv := range s.Attributes {
+			got[kv.Key] = kv.Value
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("Invalid %s of %s - Want: %v, Got: %v", k, s.Name, v.Emit(), got[k].Emit())
+			}
+		}
+	}
+
+	exp.Reset()
+	fail := errors.New("fail")
+	b = newFakeBert(&fakePredictor{err: fail}, WithTracerProvider(tp))
+	b.PredictValues("a")
+	for _, s := range exp.GetSpans() {
+		if (s.Name == SpanRun || s.Name == SpanPredict) && s.Status.Code != codes.Error {
+			t.Errorf("Invalid Status of %s - Want: %v, Got: %v", s.Name, codes.Error, s.Status.Code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// value is a ValueProvider for values assembled outside of a tensor, such as merged sub-batches
+type value struct {
+	v interface{}
+}
+
+// Value returns the underlying value
+func (v value) Value() interface{} {
+	return v.v
+}
+
+// concatValues appends the rows of each value in src to the matching value in dst.
+// Values are expected to be slices with the batch as the first dimension.
+func concatValues(dst, src []ValueProvider) ([]ValueProvider, error) {
+	if dst == nil {
+		return src, nil
+	}
+	if len(dst) != len(src) {
+		return nil, fmt.Errorf("mismatched output count %d != %d", len(dst), len(src))
+	}
+	vals := make([]ValueProvider, len(dst))
+	for i := range dst {
+		x, y := reflect.ValueOf(dst[i].Value()), reflect.ValueOf(src[i].Value())
+		if x.Kind() != reflect.Slice || x.Type() != y.Type() {
+			return nil, fmt.Errorf("output %d can't be batched, %T and %T", i, dst[i].Value(), src[i].Value())
+		}
+		vals[i] = value{v: reflect.AppendSlice(x, y).Interface()}
+	}
+	return vals, nil
+}
+
+// sliceValues returns rows [from, to) of each value, the inverse of concatValues
+func sliceValues(vals []ValueProvider, from, to int) ([]ValueProvider, error) {
+	res := make([]ValueProvider, len(vals))
+	for i, v := range vals {
+		x := reflect.ValueOf(v.Value())
+		if x.Kind() != reflect.Slice || x.Len() < to {
+			return nil, fmt.Errorf("output %d can't be split, %T has no rows [%d, %d)", i, v.Value(), from, to)
+		}
+		res[i] = value{v: x.Slice3(from, to, to).Interface()}
+	}
+	return res, nil
+}
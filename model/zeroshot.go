@@ -0,0 +1,168 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// ZeroShot Defaults, the labels are the classes of run_classifier's MnliProcessor: contradiction, entailment, neutral
+const (
+	LabelPlaceholder          = "{label}"
+	DefaultHypothesisTemplate = "This example is about {label}."
+	ContradictionLabel        = 0
+	EntailmentLabel           = 1
+	DefaultZeroShotBatch      = 32
+)
+
+// ZeroShot classifies texts into arbitrary labels without fine-tuning, with a classifier tuned on natural
+// language inference such as MNLI and loaded by NewBertClassifier. Each text is the premise of a hypothesis
+// per label built from a template, the entailment of the hypothesis is the score of the label.
+type ZeroShot struct {
+	m             Predictor
+	template      string
+	entailment    int
+	contradiction int
+	multi         bool
+	batch         int
+	workers       int
+}
+
+// ZeroShotOption configures a ZeroShot
+type ZeroShotOption func(z *ZeroShot) *ZeroShot
+
+// WithHypothesisTemplate sets the hypothesis of a label, LabelPlaceholder is replaced by the label and must be in it.
+// DefaultHypothesisTemplate by default.
+func WithHypothesisTemplate(template string) ZeroShotOption {
+	return func(z *ZeroShot) *ZeroShot {
+		z.template = template
+		return z
+	}
+}
+
+// WithNLILabels sets the classes of the model for entailment and contradiction,
+// EntailmentLabel and ContradictionLabel by default
+func WithNLILabels(entailment, contradiction int) ZeroShotOption {
+	return func(z *ZeroShot) *ZeroShot {
+		z.entailment = entailment
+		z.contradiction = contradiction
+		return z
+	}
+}
+
+// WithMultiLabel scores each label independently of the others instead of as one of exclusive labels
+func WithMultiLabel() ZeroShotOption {
+	return func(z *ZeroShot) *ZeroShot {
+		z.multi = true
+		return z
+	}
+}
+
+// WithZeroShotBatch sets the number of premise/hypothesis pairs predicted together
+func WithZeroShotBatch(n int) ZeroShotOption {
+	return func(z *ZeroShot) *ZeroShot {
+		z.batch = n
+		return z
+	}
+}
+
+// WithZeroShotWorkers sets the number of batches predicted concurrently, 1 by default.
+// The model bounds its session runs itself, ex with WithConcurrency.
+func WithZeroShotWorkers(n int) ZeroShotOption {
+	return func(z *ZeroShot) *ZeroShot {
+		z.workers = n
+		return z
+	}
+}
+
+// NewZeroShot returns a ZeroShot classifying with m, an NLI classifier.
+// It fails if the hypothesis template has no LabelPlaceholder or a tokenize.SequenceSeparator.
+func NewZeroShot(m Predictor, opts ...ZeroShotOption) (*ZeroShot, error) {
+	z := &ZeroShot{
+		m:             m,
+		template:      DefaultHypothesisTemplate,
+		entailment:    EntailmentLabel,
+		contradiction: ContradictionLabel,
+		batch:         DefaultZeroShotBatch,
+		workers:       1,
+	}
+	for _, opt := range opts {
+		z = opt(z)
+	}
+	if z.batch <= 0 {
+		z.batch = DefaultZeroShotBatch
+	}
+	if z.workers <= 0 {
+		z.workers = 1
+	}
+	if !strings.Contains(z.template, LabelPlaceholder) {
+		return nil, fmt.Errorf("hypothesis template %q has no %s", z.template, LabelPlaceholder)
+	}
+	if strings.Contains(z.template, tokenize.SequenceSeparator) {
+		return nil, fmt.Errorf("hypothesis template %q contains the sequence separator %q", z.template, tokenize.SequenceSeparator)
+	}
+	return z, nil
+}
+
+// LabelScore is the score of a label for a text
+type LabelScore struct {
+	Label string
+	Score float32
+}
+
+// Classify returns the scores of labels for each text by decreasing score, ties keep the order of labels.
+// Scores of a text sum to 1 in single label mode, the entailment probabilities are normalized over the labels.
+// In multi-label mode, each score is the probability of entailment against contradiction of its hypothesis.
+// Labels can't contain tokenize.SequenceSeparator as it would split their hypothesis.
+func (z *ZeroShot) Classify(ctx context.Context, labels []string, texts ...string) ([][]LabelScore, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no labels to classify into")
+	}
+	for _, label := range labels {
+		if strings.Contains(label, tokenize.SequenceSeparator) {
+			return nil, fmt.Errorf("label %q contains the sequence separator %q", label, tokenize.SequenceSeparator)
+		}
+	}
+	pairs := make([]string, 0, len(texts)*len(labels))
+	for _, text := range texts {
+		for _, label := range labels {
+			pairs = append(pairs, text+tokenize.SequenceSeparator+strings.ReplaceAll(z.template, LabelPlaceholder, label))
+		}
+	}
+	rows, err := predictPairs(ctx, z.m, pairs, z.batch, z.workers)
+	if err != nil {
+		return nil, err
+	}
+	res := make([][]LabelScore, len(texts))
+	for t := range texts {
+		scores := make([]LabelScore, len(labels))
+		var total float32
+		for l, label := range labels {
+			row := rows[t*len(labels)+l]
+			if z.entailment < 0 || z.entailment >= len(row) || z.contradiction < 0 || z.contradiction >= len(row) {
+				return nil, fmt.Errorf("NLI labels %d and %d out of the %d classes of the model", z.entailment, z.contradiction, len(row))
+			}
+			score := row[z.entailment]
+			if z.multi {
+				if sum := row[z.entailment] + row[z.contradiction]; sum > 0 {
+					score /= sum
+				}
+			}
+			scores[l] = LabelScore{Label: label, Score: score}
+			total += score
+		}
+		if !z.multi && total > 0 {
+			for l := range scores {
+				scores[l].Score /= total
+			}
+		}
+		sort.SliceStable(scores, func(a, b int) bool {
+			return scores[a].Score > scores[b].Score
+		})
+		res[t] = scores
+	}
+	return res, nil
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+	"testing"
+)
+
+func TestZeroShot(t *testing.T) {
+	// pairClassifier outputs contradiction then entailment, a label is entailed by its words found in the text
+	c := &pairClassifier{}
+	labels := []string{"sports news", "politics", "cooking"}
+	texts := []string{"the sports news", "politics and sports"}
+	z, err := NewZeroShot(c, WithHypothesisTemplate("{label}"), WithZeroShotBatch(4), WithZeroShotWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := z.Classify(context.Background(), labels, texts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]LabelScore{
+		{{"sports news", 1}, {"politics", 0}, {"cooking", 0}},
+		{{"sports news", 0.5}, {"politics", 0.5}, {"cooking", 0}},
+	}
+	checkScores(t, "Single Label", res, want)
+	if c.pairs != 6 || c.calls != 2 {
+		t.Errorf("Invalid Predictions - Want: 6 pairs in 2 calls, Got: %d in %d", c.pairs, c.calls)
+	}
+	z, err = NewZeroShot(c, WithHypothesisTemplate("{label}"), WithMultiLabel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = z.Classify(context.Background(), labels, texts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]LabelScore{
+		{{"sports news", 0.2}, {"politics", 0}, {"cooking", 0}},
+		{{"sports news", 0.1}, {"politics", 0.1}, {"cooking", 0}},
+	}
+	checkScores(t, "Multi Label", res, want)
+	nli, _ := NewZeroShot(c, WithNLILabels(2, 0))
+	if _, err := nli.Classify(context.Background(), labels, texts...); err == nil {
+		t.Errorf("Invalid NLI Labels - Want: error")
+	}
+	for _, template := range []string{"This example is about", "{label} ||| {label}"} {
+		if _, err := NewZeroShot(c, WithHypothesisTemplate(template)); err == nil {
+			t.Errorf("Invalid Template %q - Want: error", template)
+		}
+	}
+	if _, err := z.Classify(context.Background(), []string{"a ||| b"}, texts...); err == nil {
+		t.Errorf("Invalid Separator Label - Want: error")
+	}
+	if _, err := z.Classify(context.Background(), nil, texts...); err == nil {
+		t.Errorf("Invalid Empty Labels - Want: error")
+	}
+}
+
+func checkScores(t *testing.T, name string, got, want [][]LabelScore) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Invalid %s Result Count - Want: %d, Got: %d", name, len(want), len(got))
+	}
+	for i := range want {
+		for j, ls := range want[i] {
+			g := got[i][j]
+			if d := g.Score - ls.Score; g.Label != ls.Label || d < -1e-6 || d > 1e-6 {
+				t.Errorf("Invalid %s Scores of %d - Want: %v, Got: %v", name, i, want[i], got[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Defaults of a BM25 index
+const (
+	DefaultK1 = 1.2
+	DefaultB  = 0.75
+)
+
+// posting is the frequency of a term in a text
+type posting struct {
+	id int
+	tf int32
+}
+
+// BM25 is an inverted index scoring texts by the BM25 ranking function, it finds exact keyword hits
+// such as error codes and function names that embeddings miss. Texts are split by tokenize.Basic,
+// as BERT models do before word pieces, and punctuation tokens are dropped.
+// Texts are identified by the order they were added in, like vectors of a VectorIndex.
+type BM25 struct {
+	k1, b float64
+	tkz   tokenize.Basic
+
+	postings map[string][]posting
+	lens     []int32
+	total    int64 // length of the texts that aren't deleted
+	deleted  map[int]bool
+}
+
+// BM25Option configures a BM25 index
+type BM25Option func(x *BM25) *BM25
+
+// WithK1 sets how fast the score of a term saturates with its frequency
+func WithK1(k1 float64) BM25Option {
+	return func(x *BM25) *BM25 {
+		x.k1 = k1
+		return x
+	}
+}
+
+// WithB sets how much scores are normalized by the length of texts, from 0 to 1
+func WithB(b float64) BM25Option {
+	return func(x *BM25) *BM25 {
+		x.b = b
+		return x
+	}
+}
+
+// NewBM25 returns an empty BM25 index
+func NewBM25(opts ...BM25Option) *BM25 {
+	x := &BM25{k1: DefaultK1, b: DefaultB, tkz: tokenize.NewBasic(), postings: map[string][]posting{}}
+	for _, opt := range opts {
+		x = opt(x)
+	}
+	return x
+}
+
+// Add indexes texts
+func (x *BM25) Add(texts ...string) {
+	for _, text := range texts {
+		id := len(x.lens)
+		tfs := map[string]int32{}
+		terms := x.terms(text)
+		for _, t := range terms {
+			tfs[t]++
+		}
+		for t, tf := range tfs {
+			x.postings[t] = append(x.postings[t], posting{id: id, tf: tf})
+		}
+		x.lens = append(x.lens, int32(len(terms)))
+		x.total += int64(len(terms))
+	}
+}
+
+// Search returns up to k texts containing terms of query sorted by decreasing score,
+// only IDs accepted by accept are returned if it isn't nil
+func (x *BM25) Search(query string, k int, accept func(id int) bool) []Hit {
+	n := x.Len() - len(x.deleted)
+	if n == 0 || k <= 0 {
+		return nil
+	}
+	avg := float64(x.total) / float64(n)
+	scores := map[int]float64{}
+	seen := map[string]bool{}
+	for _, t := range x.terms(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		var df int
+		for _, p := range x.postings[t] {
+			if !x.deleted[p.id] {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(n-df)+0.5)/(float64(df)+0.5))
+		for _, p := range x.postings[t] {
+			if x.deleted[p.id] || (accept != nil && !accept(p.id)) {
+				continue
+			}
+			tf := float64(p.tf)
+			norm := x.k1 * (1 - x.b + x.b*float64(x.lens[p.id])/avg)
+			scores[p.id] += idf * tf * (x.k1 + 1) / (tf + norm)
+		}
+	}
+	top := NewTopK(k)
+	for id, s := range scores {
+		top.Push(Hit{ID: id, Score: float32(s)})
+	}
+	return top.Sorted()
+}
+
+// Delete removes the text id from search results and from the statistics of the index
+func (x *BM25) Delete(id int) error {
+	if id < 0 || id >= x.Len() {
+		return fmt.Errorf("unknown text %d", id)
+	}
+	if x.deleted[id] {
+		return nil
+	}
+	if x.deleted == nil {
+		x.deleted = map[int]bool{}
+	}
+	x.deleted[id] = true
+	x.total -= int64(x.lens[id])
+	return nil
+}
+
+// Len returns the number of texts added to the index, including deleted ones
+func (x *BM25) Len() int {
+	return len(x.lens)
+}
+
+// terms returns the tokens of text that aren't only punctuation or symbols
+func (x *BM25) terms(text string) []string {
+	toks := x.tkz.Tokenize(text)
+	terms := toks[:0]
+	for _, t := range toks {
+		for _, r := range t {
+			if !unicode.IsPunct(r) && !unicode.IsSymbol(r) {
+				terms = append(terms, t)
+				break
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBM25(t *testing.T) {
+	x := NewBM25()
+	if hits := x.Search("err", 3, nil); len(hits) != 0 {
+		t.Errorf("Invalid Empty Search - Want: [], Got: %v", hits)
+	}
+	x.Add(
+		"How do I reset my password?",
+		"Saving fails with error E1234.",
+		"The error E1234 is returned by model.NewBertClassifier when the model is missing, see the error log.",
+		"Passwords must have 8 characters.",
+		"",
+	)
+	tests := []struct {
+		query string
+		k     int
+		ids   []int
+	}{
+		{"E1234", 3, []int{1, 2}},
+		{"e1234 error", 1, []int{1}},
+		{"NewBertClassifier()", 3, []int{2}},
+		{"PASSWORD", 3, []int{0}},
+		{"?", 3, []int{}},
+		{"unknown words", 3, []int{}},
+	}
+	for _, test := range tests {
+		ids := []int{}
+		for _, h := range x.Search(test.query, test.k, nil) {
+			ids = append(ids, h.ID)
+		}
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("Invalid Hits for %q - Want: %v, Got: %v", test.query, test.ids, ids)
+		}
+	}
+	if hits := x.Search("error", 3, func(id int) bool { return id != 1 }); len(hits) != 1 || hits[0].ID != 2 {
+		t.Errorf("Invalid Accepted Hits - Got: %v", hits)
+	}
+	if err := x.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	hits := x.Search("error", 3, nil)
+	if len(hits) != 1 || hits[0].ID != 2 {
+		t.Errorf("Invalid Hits After Delete - Got: %v", hits)
+	}
+	if err := x.Delete(5); err == nil || x.Len() != 5 {
+		t.Errorf("Invalid Unknown Delete - Want: error, Got: %v %d", err, x.Len())
+	}
+}
@@ -0,0 +1,458 @@
+// Package embfile stores precomputed embeddings and their documents in a versioned file,
+// so a search engine can be loaded without embedding its documents again.
+//
+// A file starts with the magic bytes, the format version and a JSON Header, followed by one record per document:
+// the length of its metadata, its vector and its metadata as JSON, each aligned to 4 bytes.
+// Records are only ever appended, the file is memory mapped when opened.
+package embfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+	"github.com/sunhailin-Leo/gobert/search/quant"
+)
+
+// Magic starts every embedding file
+const Magic = "GBEMBIDX"
+
+// Version is the format version written by this package
+const Version = uint32(1)
+
+// Errors of embedding files
+var (
+	// ErrFormat is returned when opening a file that isn't an embedding file
+	ErrFormat = errors.New("not an embedding file")
+	// ErrMismatch is returned when the file was built with a different model, vocab or pooling
+	ErrMismatch = errors.New("embedding file mismatch")
+)
+
+// DType is the encoding of the vectors of a file
+type DType string
+
+// Vector encodings
+const (
+	Float32 DType = "float32"
+	Float16 DType = "float16" // half the size, with about 3 significant digits
+	// Quantized vectors are stored as the codes of Header.Quantizer, see the quant package
+	Quantized DType = "quantized"
+)
+
+// Header describes how the vectors of a file were built
+type Header struct {
+	// Fingerprint identifies the model and vocab, ex from model.Fingerprint
+	Fingerprint string        `json:"fingerprint"`
+	Dim         int           `json:"dim"`
+	Pooling     model.Pooling `json:"pooling"`
+	// Normalized vectors are scaled to a unit length before they are written
+	Normalized bool  `json:"normalized"`
+	DType      DType `json:"dtype"`
+	// Quantizer is the quantizer of Quantized vectors as encoded by quant.Marshal
+	Quantizer []byte `json:"quantizer,omitempty"`
+	// AllowUnknownFingerprint lets Create write and Check accept an empty fingerprint, it isn't saved
+	AllowUnknownFingerprint bool `json:"-"`
+}
+
+// Check returns ErrMismatch if vectors built as described by want can't be mixed with the ones of h.
+// Fingerprints are compared by model.SameFingerprint, an empty one is a mismatch unless want.AllowUnknownFingerprint is set.
+// Normalized is always compared, other zero values of want aren't checked.
+func (h Header) Check(want Header) error {
+	switch {
+	case !model.SameFingerprint(h.Fingerprint, want.Fingerprint, want.AllowUnknownFingerprint):
+		return fmt.Errorf("%w: fingerprint %q, file has %q", ErrMismatch, want.Fingerprint, h.Fingerprint)
+	case want.Dim != 0 && h.Dim != want.Dim:
+		return fmt.Errorf("%w: dimension %d, file has %d", ErrMismatch, want.Dim, h.Dim)
+	case want.Pooling != "" && h.Pooling != want.Pooling:
+		return fmt.Errorf("%w: pooling %q, file has %q", ErrMismatch, want.Pooling, h.Pooling)
+	case h.Normalized != want.Normalized:
+		return fmt.Errorf("%w: normalized %v, file has %v", ErrMismatch, want.Normalized, h.Normalized)
+	case want.DType != "" && h.DType != want.DType:
+		return fmt.Errorf("%w: dtype %q, file has %q", ErrMismatch, want.DType, h.DType)
+	case want.Quantizer != nil && !bytes.Equal(h.Quantizer, want.Quantizer):
+		return fmt.Errorf("%w: quantizer", ErrMismatch)
+	}
+	return nil
+}
+
+// record is the metadata of a document as stored in a file
+type record struct {
+	ID       string            `json:"id,omitempty"`
+	Text     string            `json:"text"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// File is an embedding file opened for reading and appending.
+// Records present when it was opened are read from the memory mapping, appended ones are also kept in memory.
+// It is safe for concurrent use.
+type File struct {
+	mu       sync.RWMutex
+	f        *os.File
+	hdr      Header
+	q        quant.Quantizer // of Quantized files
+	vecBytes int
+	data     []byte // mapping of the file when it was opened
+	offsets  []int  // start of each record in data
+	vecs     []model.Embedding
+	metas    [][]byte // appended records
+	codes    [][]byte // codes of appended records of Quantized files
+	end      int64    // where the next record is written, past the last complete record
+}
+
+// Create creates the file at path for vectors described by h, it fails if the file exists.
+// h needs a fingerprint unless AllowUnknownFingerprint is set.
+func Create(path string, h Header) (*File, error) {
+	if h.Fingerprint == "" && !h.AllowUnknownFingerprint {
+		return nil, errors.New("no fingerprint, set AllowUnknownFingerprint to create a file without one")
+	}
+	if h.Dim <= 0 {
+		return nil, fmt.Errorf("invalid dimension %d", h.Dim)
+	}
+	if h.DType == "" {
+		h.DType = Float32
+	}
+	ef := &File{hdr: h}
+	if err := ef.init(); err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	buf := append([]byte(Magic), make([]byte, 8)...)
+	binary.LittleEndian.PutUint32(buf[len(Magic):], Version)
+	binary.LittleEndian.PutUint32(buf[len(Magic)+4:], uint32(len(js)))
+	buf = pad(append(buf, js...))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	ef.f, ef.end = f, int64(len(buf))
+	return ef, nil
+}
+
+// Open opens the file at path and maps its records. A truncated last record, ex from a crash during an append,
+// is ignored and overwritten by the next append.
+func Open(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	ef, err := open(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return ef, nil
+}
+
+// OpenOrCreate opens the file at path and checks its header against h, or creates it if it doesn't exist
+func OpenOrCreate(path string, h Header) (*File, error) {
+	f, err := Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Create(path, h)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := f.hdr.Check(h); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func open(f *os.File) (*File, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := mmap(f, st.Size())
+	if err != nil {
+		return nil, err
+	}
+	ef := &File{f: f, data: data}
+	if err := ef.readHeader(); err != nil {
+		munmap(data)
+		return nil, err
+	}
+	ef.scan()
+	return ef, nil
+}
+
+// readHeader decodes the header and sets end to the first record
+func (f *File) readHeader() error {
+	n := len(Magic) + 8
+	if len(f.data) < n || string(f.data[:len(Magic)]) != Magic {
+		return ErrFormat
+	}
+	if v := binary.LittleEndian.Uint32(f.data[len(Magic):]); v != Version {
+		return fmt.Errorf("unsupported embedding file version %d", v)
+	}
+	size := int(binary.LittleEndian.Uint32(f.data[len(Magic)+4:]))
+	if size > len(f.data)-n {
+		return fmt.Errorf("%w: truncated header", ErrFormat)
+	}
+	if err := json.Unmarshal(f.data[n:n+size], &f.hdr); err != nil {
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if err := f.init(); err != nil {
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	f.end = int64(align(n + size))
+	return nil
+}
+
+// scan indexes the complete records of the mapping
+func (f *File) scan() {
+	off := int(f.end)
+	for off+4 <= len(f.data) {
+		size := int(binary.LittleEndian.Uint32(f.data[off:]))
+		next := align(off + 4 + f.vecBytes + size)
+		if size > len(f.data) || next > len(f.data) {
+			break
+		}
+		f.offsets = append(f.offsets, off)
+		off = next
+	}
+	f.end = int64(off)
+}
+
+// Header returns the header of the file
+func (f *File) Header() Header {
+	return f.hdr
+}
+
+// Len returns the number of records in the file
+func (f *File) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.offsets) + len(f.vecs)
+}
+
+// Vector returns the vector of record i, it must not be modified and is only valid until the file is closed
+func (f *File) Vector(i int) model.Embedding {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if i >= len(f.offsets) {
+		return f.vecs[i-len(f.offsets)]
+	}
+	return f.decode(f.data[f.offsets[i]+4:])
+}
+
+// Quantizer returns the quantizer of a Quantized file, or nil
+func (f *File) Quantizer() quant.Quantizer {
+	return f.q
+}
+
+// Document returns the document of record i
+func (f *File) Document(i int) (search.Document, error) {
+	f.mu.RLock()
+	var meta []byte
+	if i >= len(f.offsets) {
+		meta = f.metas[i-len(f.offsets)]
+	} else {
+		off := f.offsets[i]
+		size := int(binary.LittleEndian.Uint32(f.data[off:]))
+		meta = f.data[off+4+f.vecBytes : off+4+f.vecBytes+size]
+	}
+	f.mu.RUnlock()
+	var r record
+	if err := json.Unmarshal(meta, &r); err != nil {
+		return search.Document{}, fmt.Errorf("record %d: %w", i, err)
+	}
+	return search.Document{ID: r.ID, Text: r.Text, Metadata: r.Metadata}, nil
+}
+
+// Append writes docs and their vectors at the end of the file and syncs it.
+// Vectors are normalized if the header is, and must have its dimension.
+func (f *File) Append(docs []search.Document, vecs []model.Embedding) error {
+	if len(docs) != len(vecs) {
+		return fmt.Errorf("mismatched vector count %d for %d documents", len(vecs), len(docs))
+	}
+	var buf []byte
+	stored := make([]model.Embedding, len(vecs))
+	metas := make([][]byte, len(docs))
+	var codes [][]byte
+	if f.q != nil {
+		codes = make([][]byte, len(docs))
+	}
+	for i, d := range docs {
+		if len(vecs[i]) != f.hdr.Dim {
+			return fmt.Errorf("%w: dimension %d, file has %d", ErrMismatch, len(vecs[i]), f.hdr.Dim)
+		}
+		meta, err := json.Marshal(record{ID: d.ID, Text: d.Text, Metadata: d.Metadata})
+		if err != nil {
+			return err
+		}
+		v := vecs[i]
+		if f.hdr.Normalized {
+			v = search.Normalize(v)
+		}
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(meta)))
+		buf = f.encode(buf, v)
+		buf = pad(append(buf, meta...))
+		if f.hdr.DType == Float32 {
+			stored[i] = append(model.Embedding(nil), v...)
+		} else {
+			enc := f.encode(nil, v)
+			stored[i] = f.decode(enc) // as read back once rounded
+			if f.q != nil {
+				codes[i] = enc[:f.q.CodeSize()]
+			}
+		}
+		metas[i] = meta
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.f.WriteAt(buf, f.end); err != nil {
+		return err
+	}
+	if err := f.f.Truncate(f.end + int64(len(buf))); err != nil { // drops a partial record past the new end
+		return err
+	}
+	if err := f.f.Sync(); err != nil {
+		return err
+	}
+	f.end += int64(len(buf))
+	f.vecs = append(f.vecs, stored...)
+	f.metas = append(f.metas, metas...)
+	f.codes = append(f.codes, codes...)
+	return nil
+}
+
+// Documents returns the documents of every record
+func (f *File) Documents() ([]search.Document, error) {
+	docs := make([]search.Document, f.Len())
+	for i := range docs {
+		d, err := f.Document(i)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = d
+	}
+	return docs, nil
+}
+
+// AddTo adds the documents and vectors of the file to e, it must embed with the model and pooling of the header.
+// The vectors of Quantized files are decoded and encoded again by the index of e, see Index to keep their codes.
+func (f *File) AddTo(e *search.Engine) error {
+	docs, err := f.Documents()
+	if err != nil {
+		return err
+	}
+	vecs := make([]model.Embedding, len(docs))
+	for i := range vecs {
+		vecs[i] = f.Vector(i)
+	}
+	return e.AddEmbedded(docs, vecs)
+}
+
+// Index returns a quant.Index of the codes of a normalized Quantized file as stored, without decoding them.
+// Its documents are added to an engine searching it with search.Engine.AddIndexed.
+func (f *File) Index() (*quant.Index, error) {
+	if f.q == nil || !f.hdr.Normalized {
+		return nil, fmt.Errorf("index of a %s file, only normalized %s files have one", f.hdr.DType, Quantized)
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	size := f.q.CodeSize()
+	codes := make([]byte, 0, (len(f.offsets)+len(f.codes))*size)
+	for _, off := range f.offsets {
+		codes = append(codes, f.data[off+4:off+4+size]...)
+	}
+	for _, c := range f.codes {
+		codes = append(codes, c...)
+	}
+	return quant.NewIndexFromCodes(f.q, codes)
+}
+
+// Close unmaps and closes the file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := munmap(f.data)
+	f.data = nil
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// init checks the dtype of the header and sets the size of the vectors
+func (f *File) init() error {
+	if f.hdr.Dim <= 0 {
+		return fmt.Errorf("invalid dimension %d", f.hdr.Dim)
+	}
+	switch f.hdr.DType {
+	case Float32:
+		f.vecBytes = 4 * f.hdr.Dim
+	case Float16:
+		f.vecBytes = align(2 * f.hdr.Dim)
+	case Quantized:
+		q, err := quant.Unmarshal(f.hdr.Quantizer)
+		if err != nil {
+			return err
+		}
+		if q.Dim() != f.hdr.Dim {
+			return fmt.Errorf("quantizer dimension %d, header has %d", q.Dim(), f.hdr.Dim)
+		}
+		f.q, f.vecBytes = q, align(q.CodeSize())
+	default:
+		return fmt.Errorf("unknown dtype %q", f.hdr.DType)
+	}
+	return nil
+}
+
+func align(n int) int {
+	return (n + 3) &^ 3
+}
+
+func pad(b []byte) []byte {
+	return append(b, make([]byte, align(len(b))-len(b))...)
+}
+
+func (f *File) encode(b []byte, v model.Embedding) []byte {
+	if f.q != nil {
+		return pad(f.q.Encode(b, v))
+	}
+	for _, x := range v {
+		if f.hdr.DType == Float16 {
+			b = binary.LittleEndian.AppendUint16(b, toHalf(x))
+		} else {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(x))
+		}
+	}
+	return pad(b)
+}
+
+// decode reads a vector at the start of b, float32 vectors are a view of b on little endian hosts
+func (f *File) decode(b []byte) model.Embedding {
+	switch {
+	case f.q != nil:
+		return f.q.Decode(b[:f.q.CodeSize()])
+	case f.hdr.DType == Float32 && littleEndian:
+		return view(b, f.hdr.Dim)
+	}
+	v := make(model.Embedding, f.hdr.Dim)
+	for i := range v {
+		if f.hdr.DType == Float16 {
+			v[i] = fromHalf(binary.LittleEndian.Uint16(b[2*i:]))
+		} else {
+			v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+		}
+	}
+	return v
+}
@@ -0,0 +1,243 @@
+package embfile
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+	"github.com/sunhailin-Leo/gobert/search/quant"
+)
+
+func TestAppendOpen(t *testing.T) {
+	for _, dtype := range []DType{Float32, Float16} {
+		path := filepath.Join(t.TempDir(), "faq.emb")
+		h := Header{Fingerprint: "abc", Dim: 3, Pooling: model.MeanPooling, DType: dtype}
+		f, err := OpenOrCreate(path, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs := []search.Document{
+			{ID: "1", Text: "the dog", Metadata: map[string]string{"lang": "en"}},
+			{Text: "is hairy"},
+		}
+		vecs := []model.Embedding{{1, 0.5, -2}, {0.25, 3, 1}}
+		if err := f.Append(docs, vecs); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Append(docs[:1], []model.Embedding{{1, 2}}); !errors.Is(err, ErrMismatch) {
+			t.Errorf("Invalid Dimension Error - Want: %v, Got: %v", ErrMismatch, err)
+		}
+		if f.Len() != 2 || !reflect.DeepEqual(f.Vector(1), vecs[1]) {
+			t.Errorf("Invalid Appended Vector - Want: %v, Got: %v", vecs[1], f.Vector(1))
+		}
+		f.Close()
+
+		f, err = OpenOrCreate(path, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		more := []search.Document{{ID: "3", Text: "a cat"}}
+		if err := f.Append(more, []model.Embedding{{0, 0, 1}}); err != nil {
+			t.Fatal(err)
+		}
+		docs, vecs = append(docs, more...), append(vecs, model.Embedding{0, 0, 1})
+		if f.Len() != 3 {
+			t.Fatalf("Invalid Len - Want: 3, Got: %d", f.Len())
+		}
+		for i := range docs {
+			d, err := f.Document(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(d, docs[i]) || !reflect.DeepEqual(f.Vector(i), vecs[i]) {
+				t.Errorf("Invalid %s Record %d - Want: %v %v, Got: %v %v", dtype, i, docs[i], vecs[i], d, f.Vector(i))
+			}
+		}
+		e := search.New(nil)
+		if err := f.AddTo(e); err != nil {
+			t.Fatal(err)
+		}
+		if d, ok := e.Get("3"); !ok || d.Text != "a cat" || e.Len() != 3 {
+			t.Errorf("Invalid Engine - Want: 3 documents, Got: %d", e.Len())
+		}
+		f.Close()
+	}
+}
+
+func TestQuantized(t *testing.T) {
+	vecs := []model.Embedding{{0.6, 0.8, 0}, {0, 0.6, 0.8}, {0.8, 0, 0.6}}
+	q, _ := quant.TrainScalar(vecs)
+	b, _ := quant.Marshal(q)
+	path := filepath.Join(t.TempDir(), "faq.emb")
+	h := Header{Fingerprint: "abc", Dim: 3, Normalized: true, DType: Quantized, Quantizer: b}
+	f, err := Create(path, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []search.Document{{Text: "a"}, {Text: "b"}, {Text: "c"}}
+	if err := f.Append(docs, vecs); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	f, err = OpenOrCreate(path, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.vecBytes != 4 {
+		t.Errorf("Invalid Code Size - Want: 4, Got: %d", f.vecBytes)
+	}
+	for i, v := range vecs {
+		if got := f.Vector(i); search.Dot(got, v) < 0.99 {
+			t.Errorf("Invalid Decoded Vector %d - Want: %v, Got: %v", i, v, got)
+		}
+	}
+	e := search.New(nil, search.WithIndex(quant.NewIndex(f.Quantizer())))
+	if err := f.AddTo(e); err != nil || e.Len() != 3 {
+		t.Errorf("Invalid Quantized Engine - Got: %d %v", e.Len(), err)
+	}
+	if err := f.Append([]search.Document{{Text: "d"}}, vecs[:1]); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := f.Index()
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	want := quant.NewIndex(f.Quantizer())
+	want.Add(append(vecs, vecs[0])...)
+	for _, v := range vecs {
+		w, _ := want.Search(v, 4, nil)
+		got, _ := idx.Search(v, 4, nil)
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("Invalid Index Hits - Want: %v, Got: %v", w, got)
+		}
+	}
+	docs, err = f.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = search.New(nil, search.WithIndex(idx))
+	if err := e.AddIndexed(docs...); err != nil || e.Len() != 4 {
+		t.Errorf("Invalid Indexed Engine - Got: %d %v", e.Len(), err)
+	}
+	if err := e.AddIndexed(search.Document{Text: "e"}); err == nil {
+		t.Errorf("Invalid Error - Want: more documents than embeddings, Got: %v", err)
+	}
+	if _, err := Create(filepath.Join(t.TempDir(), "bad.emb"), Header{Fingerprint: "abc", Dim: 3, DType: Quantized}); err == nil {
+		t.Errorf("Invalid Create - Want: missing quantizer error")
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faq.emb")
+	h := Header{Fingerprint: "abc", Dim: 3, Pooling: model.MeanPooling}
+	f, err := Create(path, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := Create(path, h); !errors.Is(err, os.ErrExist) {
+		t.Errorf("Invalid Create Error - Want: %v, Got: %v", os.ErrExist, err)
+	}
+	tests := []Header{
+		{Fingerprint: "other", Dim: 3, Pooling: model.MeanPooling},
+		{Fingerprint: "abc", Dim: 4, Pooling: model.MeanPooling},
+		{Fingerprint: "abc", Dim: 3, Pooling: model.CLSPooling},
+		{Fingerprint: "abc", Dim: 3, Pooling: model.MeanPooling, Normalized: true},
+		{Fingerprint: "abc", Dim: 3, Pooling: model.MeanPooling, DType: Float16},
+		{Dim: 3, Pooling: model.MeanPooling},
+	}
+	for _, want := range tests {
+		if _, err := OpenOrCreate(path, want); !errors.Is(err, ErrMismatch) {
+			t.Errorf("Invalid Error for %+v - Want: %v, Got: %v", want, ErrMismatch, err)
+		}
+	}
+	for _, want := range []Header{{Dim: 3, AllowUnknownFingerprint: true}, {Fingerprint: "abc"}} {
+		if f, err := OpenOrCreate(path, want); err != nil {
+			t.Errorf("Invalid Error for %+v - Want: %v, Got: %v", want, nil, err)
+		} else {
+			f.Close()
+		}
+	}
+	os.WriteFile(path, []byte("not an embedding file"), 0644)
+	if _, err := Open(path); !errors.Is(err, ErrFormat) {
+		t.Errorf("Invalid Format Error - Want: %v, Got: %v", ErrFormat, err)
+	}
+}
+
+func TestUnknownFingerprint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faq.emb")
+	if _, err := Create(path, Header{Dim: 3}); err == nil {
+		t.Errorf("Invalid Create Error - Want: an error, Got: %v", err)
+	}
+	f, err := Create(path, Header{Dim: 3, AllowUnknownFingerprint: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := OpenOrCreate(path, Header{Fingerprint: "abc", Dim: 3}); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", ErrMismatch, err)
+	}
+	if f, err := OpenOrCreate(path, Header{Fingerprint: "abc", Dim: 3, AllowUnknownFingerprint: true}); err != nil {
+		t.Errorf("Invalid Allowed Error - Want: %v, Got: %v", nil, err)
+	} else {
+		f.Close()
+	}
+}
+
+func TestTruncatedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faq.emb")
+	f, _ := Create(path, Header{Fingerprint: "abc", Dim: 2, Normalized: true})
+	f.Append([]search.Document{{Text: "a"}, {Text: "b"}}, []model.Embedding{{3, 4}, {0, 2}})
+	f.Close()
+	st, _ := os.Stat(path)
+	os.Truncate(path, st.Size()-2) // crash while writing the last record
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Len() != 1 || !reflect.DeepEqual(f.Vector(0), model.Embedding{0.6, 0.8}) {
+		t.Fatalf("Invalid Records - Want: 1 [0.6 0.8], Got: %d %v", f.Len(), f.Vector(0))
+	}
+	if err := f.Append([]search.Document{{Text: "c"}}, []model.Embedding{{0, -1}}); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := f.Document(1); d.Text != "c" {
+		t.Errorf("Invalid Overwritten Record - Want: c, Got: %v", d)
+	}
+}
+
+func TestHalf(t *testing.T) {
+	tests := []struct {
+		f     float32
+		half  uint16
+		lossy bool
+	}{
+		{0, 0x0000, false},
+		{1, 0x3c00, false},
+		{-2, 0xc000, false},
+		{65504, 0x7bff, false},
+		{1e6, 0x7c00, true},
+		{float32(math.Inf(-1)), 0xfc00, false},
+		{5.960464477539063e-08, 0x0001, false}, // smallest subnormal
+		{1.0009765625, 0x3c01, false},
+		{1.00048828125, 0x3c00, true}, // tie rounds to even
+	}
+	for _, test := range tests {
+		if got := toHalf(test.f); got != test.half {
+			t.Errorf("Invalid Half of %v - Want: %#04x, Got: %#04x", test.f, test.half, got)
+		}
+		if got := fromHalf(test.half); !test.lossy && got != test.f {
+			t.Errorf("Invalid Float of %#04x - Want: %v, Got: %v", test.half, test.f, got)
+		}
+	}
+	if h := toHalf(float32(math.NaN())); fromHalf(h) == fromHalf(h) {
+		t.Errorf("Invalid NaN - Got: %#04x", h)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package embfile
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the first size bytes of f on platforms without memory mapping
+func mmap(f *os.File, size int64) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := f.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b, nil
+}
+
+func munmap(b []byte) error {
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package embfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of f read only
+func mmap(f *os.File, size int64) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	return syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func munmap(b []byte) error {
+	if b == nil {
+		return nil
+	}
+	return syscall.Munmap(b)
+}
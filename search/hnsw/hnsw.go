@@ -0,0 +1,436 @@
+// Package hnsw is an approximate nearest neighbor index of embeddings for the search package.
+// It builds a Hierarchical Navigable Small World graph, see https://arxiv.org/abs/1603.09320
+package hnsw
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// Defaults of an Index
+const (
+	DefaultM              = 16
+	DefaultEfConstruction = 200
+	DefaultEfSearch       = 64
+	DefaultSeed           = 1
+)
+
+// Index is a search.VectorIndex searching a graph of the vectors by cosine similarity.
+// Each layer links a vector to its M nearest neighbors, 2*M on the bottom layer,
+// searches walk down the layers from a single entry point to the neighborhood of the query.
+// Deleted vectors are kept in the graph to route searches but aren't returned.
+// It is safe for concurrent use.
+type Index struct {
+	m              int
+	efConstruction int
+	efSearch       int
+	seed           int64
+	rng            *rand.Rand
+	ml             float64
+
+	mu       sync.RWMutex
+	dim      int
+	nodes    []node
+	entry    int32
+	maxLevel int
+	deleted  int
+	visited  sync.Pool
+}
+
+var (
+	_ search.VectorIndex = (*Index)(nil)
+	_ search.Deleter     = (*Index)(nil)
+)
+
+// node is a normalized vector and its neighbors on each of its layers
+type node struct {
+	vec     model.Embedding
+	links   [][]int32
+	deleted bool
+}
+
+// Option configures an Index
+type Option func(h *Index) *Index
+
+// WithM sets the number of neighbors of a vector on each layer, higher is more accurate but slower and larger
+func WithM(m int) Option {
+	return func(h *Index) *Index {
+		h.m = m
+		return h
+	}
+}
+
+// WithEfConstruction sets the number of candidate neighbors considered when adding a vector
+func WithEfConstruction(ef int) Option {
+	return func(h *Index) *Index {
+		h.efConstruction = ef
+		return h
+	}
+}
+
+// WithEfSearch sets the number of candidates considered by a search, raising it trades latency for recall.
+// Searches consider at least k candidates.
+func WithEfSearch(ef int) Option {
+	return func(h *Index) *Index {
+		h.efSearch = ef
+		return h
+	}
+}
+
+// WithSeed seeds the random layers of vectors, an index built with the same seed and vectors is the same
+func WithSeed(seed int64) Option {
+	return func(h *Index) *Index {
+		h.seed = seed
+		return h
+	}
+}
+
+// New returns an empty index
+func New(opts ...Option) *Index {
+	h := &Index{
+		m:              DefaultM,
+		efConstruction: DefaultEfConstruction,
+		efSearch:       DefaultEfSearch,
+		seed:           DefaultSeed,
+		entry:          -1,
+	}
+	return h.configure(opts...)
+}
+
+func (h *Index) configure(opts ...Option) *Index {
+	for _, opt := range opts {
+		h = opt(h)
+	}
+	if h.m < 2 {
+		h.m = 2
+	}
+	if h.efConstruction < h.m {
+		h.efConstruction = h.m
+	}
+	if h.efSearch < 1 {
+		h.efSearch = 1
+	}
+	h.rng = rand.New(rand.NewSource(h.seed))
+	h.ml = 1 / math.Log(float64(h.m))
+	return h
+}
+
+// Add normalizes vecs and inserts them in the graph, they must all have the same dimension
+func (h *Index) Add(vecs ...model.Embedding) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dim := h.dim
+	for _, v := range vecs {
+		if err := search.CheckDim(v, &dim); err != nil {
+			return err
+		}
+	}
+	h.dim = dim
+	for _, v := range vecs {
+		h.insert(search.Normalize(v))
+	}
+	return nil
+}
+
+// Search returns the k nearest vectors to q accepted by accept, among the max of efSearch and k candidates.
+// Selective filters explore more of the graph to find k vectors.
+func (h *Index) Search(q model.Embedding, k int, accept func(id int) bool) ([]search.Hit, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.entry < 0 || k <= 0 {
+		return nil, nil
+	}
+	if err := search.CheckDim(q, &h.dim); err != nil { // h.dim is set, it isn't written
+		return nil, err
+	}
+	q = search.Normalize(q)
+	ep := []candidate{h.candidate(q, h.entry)}
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(q, ep, 1, l, nil)
+	}
+	ef := h.efSearch
+	if k > ef {
+		ef = k
+	}
+	res := h.searchLayer(q, ep, ef, 0, func(id int32) bool {
+		return !h.nodes[id].deleted && (accept == nil || accept(int(id)))
+	})
+	if len(res) > k {
+		res = res[:k]
+	}
+	hits := make([]search.Hit, len(res))
+	for i, c := range res {
+		hits[i] = search.Hit{ID: int(c.id), Score: c.score}
+	}
+	return hits, nil
+}
+
+// Delete removes the vector id from search results, it is still used to route searches
+func (h *Index) Delete(id int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id < 0 || id >= len(h.nodes) {
+		return fmt.Errorf("unknown vector %d", id)
+	}
+	if !h.nodes[id].deleted {
+		h.nodes[id].deleted = true
+		h.deleted++
+	}
+	return nil
+}
+
+// Len returns the number of vectors added to the index, including deleted ones
+func (h *Index) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.nodes)
+}
+
+// Deleted returns the number of deleted vectors
+func (h *Index) Deleted() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.deleted
+}
+
+// insert links a normalized vector into every layer up to a random one
+func (h *Index) insert(v model.Embedding) {
+	id := int32(len(h.nodes))
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.ml))
+	h.nodes = append(h.nodes, node{vec: v, links: make([][]int32, level+1)})
+	if h.entry < 0 {
+		h.entry, h.maxLevel = id, level
+		return
+	}
+	ep := []candidate{h.candidate(v, h.entry)}
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(v, ep, 1, l, nil)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		cands := h.searchLayer(v, ep, h.efConstruction, l, nil)
+		neighbors := h.selectNeighbors(cands, h.m)
+		links := make([]int32, len(neighbors))
+		for i, c := range neighbors {
+			links[i] = c.id
+		}
+		h.nodes[id].links[l] = links
+		for _, c := range neighbors {
+			h.link(c.id, id, l)
+		}
+		ep = cands
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = id, level
+	}
+}
+
+// link adds to to the neighbors of from on level l, pruning them when there are too many
+func (h *Index) link(from, to int32, l int) {
+	links := append(h.nodes[from].links[l], to)
+	maxLinks := h.m
+	if l == 0 {
+		maxLinks = 2 * h.m
+	}
+	if len(links) > maxLinks {
+		v := h.nodes[from].vec
+		cands := make([]candidate, len(links))
+		for i, id := range links {
+			cands[i] = h.candidate(v, id)
+		}
+		sortCandidates(cands)
+		pruned := h.selectNeighbors(cands, maxLinks)
+		links = links[:len(pruned)]
+		for i, c := range pruned {
+			links[i] = c.id
+		}
+	}
+	h.nodes[from].links[l] = links
+}
+
+// selectNeighbors keeps up to m of cands sorted by decreasing score, preferring candidates closer to the base vector
+// than to the ones already selected so links span several directions, the rest are filled with the nearest pruned ones.
+func (h *Index) selectNeighbors(cands []candidate, m int) []candidate {
+	if len(cands) <= m {
+		return cands
+	}
+	selected := make([]candidate, 0, m)
+	var pruned []candidate
+	for _, c := range cands {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if search.Dot(h.nodes[c.id].vec, h.nodes[s.id].vec) > c.score {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+	for _, c := range pruned {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	sortCandidates(selected)
+	return selected
+}
+
+// searchLayer walks layer l from ep and returns up to ef candidates nearest to q sorted by decreasing score.
+// Only candidates passing accept are returned but every vector is walked through.
+func (h *Index) searchLayer(q model.Embedding, ep []candidate, ef, l int, accept func(id int32) bool) []candidate {
+	visited := h.visitor()
+	defer h.visited.Put(visited)
+	cands := &candidates{nearest: true}
+	res := &candidates{}
+	for _, c := range ep {
+		visited.visit(c.id)
+		cands.push(c)
+		if accept == nil || accept(c.id) {
+			res.push(c)
+		}
+	}
+	for len(res.cs) > ef {
+		res.pop()
+	}
+	for len(cands.cs) > 0 {
+		c := cands.pop()
+		if len(res.cs) >= ef && c.score < res.cs[0].score {
+			break
+		}
+		for _, id := range h.nodes[c.id].links[l] {
+			if !visited.visit(id) {
+				continue
+			}
+			n := h.candidate(q, id)
+			if len(res.cs) >= ef && n.score <= res.cs[0].score {
+				continue
+			}
+			cands.push(n)
+			if accept == nil || accept(id) {
+				res.push(n)
+				if len(res.cs) > ef {
+					res.pop()
+				}
+			}
+		}
+	}
+	sortCandidates(res.cs)
+	return res.cs
+}
+
+func (h *Index) candidate(q model.Embedding, id int32) candidate {
+	return candidate{id: id, score: search.Dot(q, h.nodes[id].vec)}
+}
+
+// visitor returns a visited set sized for the nodes of the index
+func (h *Index) visitor() *visitSet {
+	v, _ := h.visited.Get().(*visitSet)
+	if v == nil {
+		v = &visitSet{}
+	}
+	v.reset(len(h.nodes))
+	return v
+}
+
+// visitSet marks visited nodes with the generation of the search so it is only cleared on wrap around
+type visitSet struct {
+	marks []uint32
+	gen   uint32
+}
+
+func (v *visitSet) reset(n int) {
+	if len(v.marks) < n {
+		v.marks = make([]uint32, n+n/4)
+		v.gen = 0
+	}
+	v.gen++
+	if v.gen == 0 {
+		for i := range v.marks {
+			v.marks[i] = 0
+		}
+		v.gen = 1
+	}
+}
+
+// visit marks id as visited, returning false if it already was
+func (v *visitSet) visit(id int32) bool {
+	if v.marks[id] == v.gen {
+		return false
+	}
+	v.marks[id] = v.gen
+	return true
+}
+
+// candidate is a node and its score to the vector being searched
+type candidate struct {
+	id    int32
+	score float32
+}
+
+// sortCandidates sorts by decreasing score then increasing ID
+func sortCandidates(cs []candidate) {
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].score > cs[j].score || (cs[i].score == cs[j].score && cs[i].id < cs[j].id)
+	})
+}
+
+// candidates is a binary heap with the highest score first if nearest is set, else the lowest.
+// It avoids the interface conversions of container/heap on the hot path of searches.
+type candidates struct {
+	cs      []candidate
+	nearest bool
+}
+
+func (h *candidates) less(i, j int) bool {
+	if h.nearest {
+		return h.cs[i].score > h.cs[j].score
+	}
+	return h.cs[i].score < h.cs[j].score
+}
+
+func (h *candidates) push(c candidate) {
+	h.cs = append(h.cs, c)
+	for i := len(h.cs) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+		h.cs[i], h.cs[parent] = h.cs[parent], h.cs[i]
+		i = parent
+	}
+}
+
+func (h *candidates) pop() candidate {
+	top := h.cs[0]
+	last := len(h.cs) - 1
+	h.cs[0] = h.cs[last]
+	h.cs = h.cs[:last]
+	for i := 0; ; {
+		first := i
+		if l := 2*i + 1; l < last && h.less(l, first) {
+			first = l
+		}
+		if r := 2*i + 2; r < last && h.less(r, first) {
+			first = r
+		}
+		if first == i {
+			break
+		}
+		h.cs[i], h.cs[first] = h.cs[first], h.cs[i]
+		i = first
+	}
+	return top
+}
@@ -0,0 +1,184 @@
+package hnsw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// recall is the fraction of the exact hits found by the approximate ones
+func recall(exact, approx []search.Hit) float64 {
+	found := map[int]bool{}
+	for _, h := range approx {
+		found[h.ID] = true
+	}
+	var n int
+	for _, h := range exact {
+		if found[h.ID] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(exact))
+}
+
+// build indexes n vectors around topics, or random ones without topics, and returns 100 more as queries
+func build(t testing.TB, topics, n, dim int, opts ...Option) (*Index, *search.Flat, []model.Embedding) {
+	vecs := modeltest.Embeddings(7, n+100, dim, topics, 0.5)
+	queries := vecs[n:]
+	vecs = vecs[:n]
+	h := New(opts...)
+	flat := search.NewFlat()
+	if err := h.Add(vecs...); err != nil {
+		t.Fatal(err)
+	}
+	flat.Add(vecs...)
+	return h, flat, queries
+}
+
+func TestRecall(t *testing.T) {
+	h, flat, queries := build(t, 0, 2000, 32)
+	var total float64
+	for _, q := range queries {
+		exact, _ := flat.Search(q, 10, nil)
+		approx, err := h.Search(q, 10, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(approx) != 10 {
+			t.Fatalf("Invalid Hit Count - Want: 10, Got: %d", len(approx))
+		}
+		total += recall(exact, approx)
+	}
+	if r := total / float64(len(queries)); r < 0.95 {
+		t.Errorf("Invalid Recall@10 - Want: >= 0.95, Got: %.3f", r)
+	}
+}
+
+func TestDeleteAndFilter(t *testing.T) {
+	h, _, queries := build(t, 0, 500, 16)
+	q := queries[0]
+	hits, _ := h.Search(q, 5, nil)
+	if err := h.Delete(hits[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := h.Search(q, 5, nil)
+	for _, hit := range after {
+		if hit.ID == hits[0].ID {
+			t.Errorf("Invalid Deleted Hit - Got: %d", hit.ID)
+		}
+	}
+	if h.Deleted() != 1 || h.Len() != 500 {
+		t.Errorf("Invalid Counts - Want: 1 500, Got: %d %d", h.Deleted(), h.Len())
+	}
+	even, _ := h.Search(q, 20, func(id int) bool { return id%2 == 0 })
+	if len(even) != 20 {
+		t.Fatalf("Invalid Filtered Hit Count - Want: 20, Got: %d", len(even))
+	}
+	for _, hit := range even {
+		if hit.ID%2 != 0 {
+			t.Errorf("Invalid Filtered Hit - Got: %d", hit.ID)
+		}
+	}
+	rare, _ := h.Search(q, 10, func(id int) bool { return id == 123 })
+	if len(rare) != 1 || rare[0].ID != 123 {
+		t.Errorf("Invalid Selective Filter Hits - Want: [123], Got: %v", rare)
+	}
+	if err := h.Delete(500); err == nil {
+		t.Errorf("Invalid Delete - Want: error")
+	}
+	if err := h.Add(model.Embedding{1}); err == nil {
+		t.Errorf("Invalid Add - Want: dimension error")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	h, _, queries := build(t, 0, 300, 8, WithM(8), WithEfSearch(32))
+	h.Delete(3)
+	path := filepath.Join(t.TempDir(), "index.hnsw")
+	if err := h.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.m != 8 || loaded.efSearch != 32 || loaded.Deleted() != 1 || loaded.Len() != 300 {
+		t.Errorf("Invalid Loaded Params - Got: %d %d %d %d", loaded.m, loaded.efSearch, loaded.Deleted(), loaded.Len())
+	}
+	for _, q := range queries[:10] {
+		want, _ := h.Search(q, 5, nil)
+		got, _ := loaded.Search(q, 5, nil)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("Invalid Loaded Hits - Want: %v, Got: %v", want, got)
+		}
+	}
+	var buf bytes.Buffer
+	h.Save(&buf)
+	data := buf.Bytes()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"magic", append([]byte("NOTANINDEX"), data[10:]...)},
+		{"truncated", data[:len(data)/2]},
+	}
+	for _, test := range tests {
+		if _, err := Load(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("Invalid %s Load - Want: error", test.name)
+		}
+	}
+	if _, err := Load(bytes.NewReader(tests[0].data)); !errors.Is(err, ErrFormat) {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", ErrFormat, err)
+	}
+	empty := New()
+	buf.Reset()
+	empty.Save(&buf)
+	if e, err := Load(&buf); err != nil || e.Len() != 0 {
+		t.Errorf("Invalid Empty Load - Got: %v", err)
+	}
+}
+
+// BenchmarkSearch compares the latency and recall@10 of the index to a brute force scan.
+// Uniform random vectors are the worst case of the graph, raise efSearch for them.
+func BenchmarkSearch(b *testing.B) {
+	for _, n := range []int{10000, 50000} {
+		h, flat, queries := build(b, 64, n, 64)
+		exact := make([][]search.Hit, len(queries))
+		for i, q := range queries {
+			exact[i], _ = flat.Search(q, 10, nil)
+		}
+		for _, idx := range []struct {
+			name  string
+			index search.VectorIndex
+		}{
+			{"flat", flat},
+			{"hnsw", h},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", idx.name, n), func(b *testing.B) {
+				var total float64
+				for i := 0; i < b.N; i++ {
+					q := i % len(queries)
+					hits, _ := idx.index.Search(queries[q], 10, nil)
+					total += recall(exact[q], hits)
+				}
+				b.ReportMetric(total/float64(b.N), "recall@10")
+			})
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	vecs := modeltest.Embeddings(7, b.N, 64, 0, 0)
+	h := New()
+	b.ResetTimer()
+	for _, v := range vecs {
+		h.Add(v)
+	}
+}
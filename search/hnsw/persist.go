@@ -0,0 +1,193 @@
+package hnsw
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sunhailin-Leo/gobert/internal/fileutil"
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// magic and version start a saved index
+const (
+	magic   = "GOBERTHNSW"
+	version = uint32(1)
+)
+
+// maxDim bounds the dimension read from a saved index before allocating its vectors
+const maxDim = 1 << 16
+
+// ErrFormat is returned when loading data that isn't a saved index
+var ErrFormat = errors.New("not a saved hnsw index")
+
+// header is the fixed size start of a saved index
+type header struct {
+	Version        uint32
+	M              uint32
+	EfConstruction uint32
+	EfSearch       uint32
+	Seed           int64
+	Dim            uint32
+	Count          uint32
+	Entry          int32
+	MaxLevel       uint32
+}
+
+// Save writes the parameters and graph of the index to w
+func (h *Index) Save(w io.Writer) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(magic); err != nil {
+		return err
+	}
+	hd := header{
+		Version:        version,
+		M:              uint32(h.m),
+		EfConstruction: uint32(h.efConstruction),
+		EfSearch:       uint32(h.efSearch),
+		Seed:           h.seed,
+		Dim:            uint32(h.dim),
+		Count:          uint32(len(h.nodes)),
+		Entry:          h.entry,
+		MaxLevel:       uint32(h.maxLevel),
+	}
+	if err := binary.Write(bw, binary.LittleEndian, hd); err != nil {
+		return err
+	}
+	for _, n := range h.nodes {
+		var deleted uint8
+		if n.deleted {
+			deleted = 1
+		}
+		if err := binary.Write(bw, binary.LittleEndian, deleted); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, uint32(len(n.links))); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, []float32(n.vec)); err != nil {
+			return err
+		}
+		for _, links := range n.links {
+			if err := binary.Write(bw, binary.LittleEndian, uint32(len(links))); err != nil {
+				return err
+			}
+			if err := binary.Write(bw, binary.LittleEndian, links); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// Load reads an index written by Save, opts override its saved parameters, ex WithEfSearch.
+// Vectors added after loading get different layers than if the index was never saved.
+func Load(r io.Reader, opts ...Option) (*Index, error) {
+	br := bufio.NewReader(r)
+	m := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, m); err != nil || string(m) != magic {
+		return nil, ErrFormat
+	}
+	var hd header
+	if err := binary.Read(br, binary.LittleEndian, &hd); err != nil {
+		return nil, err
+	}
+	if hd.Version != version {
+		return nil, fmt.Errorf("unsupported hnsw index version %d", hd.Version)
+	}
+	if hd.Dim > maxDim {
+		return nil, fmt.Errorf("%w: dimension %d", ErrFormat, hd.Dim)
+	}
+	h := &Index{
+		m:              int(hd.M),
+		efConstruction: int(hd.EfConstruction),
+		efSearch:       int(hd.EfSearch),
+		seed:           hd.Seed,
+		dim:            int(hd.Dim),
+		entry:          hd.Entry,
+		maxLevel:       int(hd.MaxLevel),
+	}
+	for i := uint32(0); i < hd.Count; i++ {
+		var deleted uint8
+		var levels uint32
+		if err := binary.Read(br, binary.LittleEndian, &deleted); err != nil {
+			return nil, unexpected(err)
+		}
+		if err := binary.Read(br, binary.LittleEndian, &levels); err != nil {
+			return nil, unexpected(err)
+		}
+		if levels == 0 || levels > hd.MaxLevel+1 {
+			return nil, fmt.Errorf("%w: vector %d has %d layers", ErrFormat, i, levels)
+		}
+		n := node{vec: make(model.Embedding, hd.Dim), links: make([][]int32, levels), deleted: deleted == 1}
+		if err := binary.Read(br, binary.LittleEndian, []float32(n.vec)); err != nil {
+			return nil, unexpected(err)
+		}
+		for l := range n.links {
+			var size uint32
+			if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+				return nil, unexpected(err)
+			}
+			if size > hd.Count {
+				return nil, fmt.Errorf("%w: vector %d has %d links", ErrFormat, i, size)
+			}
+			n.links[l] = make([]int32, size)
+			if err := binary.Read(br, binary.LittleEndian, n.links[l]); err != nil {
+				return nil, unexpected(err)
+			}
+		}
+		if n.deleted {
+			h.deleted++
+		}
+		h.nodes = append(h.nodes, n)
+	}
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	return h.configure(opts...), nil
+}
+
+// validate checks links point to vectors that have the linked layer
+func (h *Index) validate() error {
+	if (h.entry < 0) != (len(h.nodes) == 0) || int(h.entry) >= len(h.nodes) ||
+		(h.entry >= 0 && len(h.nodes[h.entry].links) != h.maxLevel+1) {
+		return fmt.Errorf("%w: invalid entry point %d", ErrFormat, h.entry)
+	}
+	for i, n := range h.nodes {
+		for l, links := range n.links {
+			for _, id := range links {
+				if id < 0 || int(id) >= len(h.nodes) || len(h.nodes[id].links) <= l {
+					return fmt.Errorf("%w: vector %d links to %d on layer %d", ErrFormat, i, id, l)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SaveFile saves the index to path, replacing it only once the index is fully written
+func (h *Index) SaveFile(path string) error {
+	return fileutil.WriteFile(path, h.Save)
+}
+
+// LoadFile loads an index saved to path
+func LoadFile(path string, opts ...Option) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, opts...)
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
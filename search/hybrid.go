@@ -0,0 +1,124 @@
+package search
+
+import (
+	"context"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Defaults of a hybrid search
+const (
+	DefaultCandidates = 50
+	DefaultRRFK       = 60
+)
+
+// Fusion combines the hits of the semantic and lexical searches, sorted by decreasing score, into a score per ID
+type Fusion func(semantic, lexical []Hit) map[int]float32
+
+// RRF is the reciprocal rank fusion, a hit at rank r (from 1) of a search scores 1/(k+r).
+// It ignores the scores of the searches so they don't need to be comparable, k <= 0 uses DefaultRRFK.
+func RRF(k int) Fusion {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	return func(semantic, lexical []Hit) map[int]float32 {
+		scores := map[int]float32{}
+		for _, hits := range [][]Hit{semantic, lexical} {
+			for r, h := range hits {
+				scores[h.ID] += 1 / float32(k+r+1)
+			}
+		}
+		return scores
+	}
+}
+
+// Weighted blends the scores of the searches, alpha * semantic + (1 - alpha) * lexical.
+// Scores of each search are min-max normalized to [0, 1] over its hits, a missing hit scores 0.
+func Weighted(alpha float32) Fusion {
+	return func(semantic, lexical []Hit) map[int]float32 {
+		scores := map[int]float32{}
+		for i, hits := range [][]Hit{semantic, lexical} {
+			w := alpha
+			if i == 1 {
+				w = 1 - alpha
+			}
+			if len(hits) == 0 {
+				continue
+			}
+			hi, lo := hits[0].Score, hits[len(hits)-1].Score
+			for _, h := range hits {
+				s := float32(1)
+				if hi > lo {
+					s = (h.Score - lo) / (hi - lo)
+				}
+				scores[h.ID] += w * s
+			}
+		}
+		return scores
+	}
+}
+
+// Signal is the rank (from 1) and score of a document in one of the fused searches, Rank is 0 when it wasn't a hit
+type Signal struct {
+	Rank  int
+	Score float32
+}
+
+// HybridResult is a document matching a query, Score is its fused score.
+// Semantic and Lexical are its cosine similarity and BM25 score for debugging.
+type HybridResult struct {
+	Result
+	Semantic Signal
+	Lexical  Signal
+}
+
+// HybridSearch returns the k documents best matching query by fusing the results of the semantic search and
+// of the BM25 search of the engine, by decreasing fused score. Each search returns the best candidates
+// set by WithCandidates, at least k. fuse nil uses RRF(DefaultRRFK), k <= 0 uses DefaultK.
+// It returns ErrNoLexicalIndex unless the engine was built WithLexicalIndex.
+func (e *Engine) HybridSearch(ctx context.Context, query string, k int, fuse Fusion, filters ...Filter) ([]HybridResult, error) {
+	if e.lexical == nil {
+		return nil, ErrNoLexicalIndex
+	}
+	if k <= 0 {
+		k = DefaultK
+	}
+	if fuse == nil {
+		fuse = RRF(DefaultRRFK)
+	}
+	embs, err := model.Embed(ctx, e.m, e.pooling, query)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	accept := e.accept(filters)
+	n := max(k, e.candidates)
+	semantic, err := e.index.Search(embs[0], n, accept)
+	if err != nil {
+		return nil, err
+	}
+	lexical := e.lexical.Search(query, n, accept)
+	top := NewTopK(k)
+	for id, s := range fuse(semantic, lexical) {
+		top.Push(Hit{ID: id, Score: s})
+	}
+	signals := func(hits []Hit) map[int]Signal {
+		m := make(map[int]Signal, len(hits))
+		for r, h := range hits {
+			m[h.ID] = Signal{Rank: r + 1, Score: h.Score}
+		}
+		return m
+	}
+	sem, lex := signals(semantic), signals(lexical)
+	hits := top.Sorted()
+	res := make([]HybridResult, len(hits))
+	for i, h := range hits {
+		res[i] = HybridResult{
+			Result:   Result{Document: e.docs[h.ID], Score: h.Score},
+			Semantic: sem[h.ID],
+			Lexical:  lex[h.ID],
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHybridSearch(t *testing.T) {
+	if _, err := New(fakeModel()).HybridSearch(context.Background(), "E1234", 1, nil); !errors.Is(err, ErrNoLexicalIndex) {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", ErrNoLexicalIndex, err)
+	}
+	e := New(fakeModel(), WithCandidates(2), WithLexicalIndex(NewBM25()))
+	docs := []Document{
+		{ID: "1", Text: "account password reset"},
+		{ID: "2", Text: "autosave fails when the disk is full"},
+		{ID: "3", Text: "build error E1234 when saving", Metadata: map[string]string{"lang": "en"}},
+		{ID: "4", Text: "cache error log"},
+	}
+	if err := e.Add(context.Background(), docs...); err != nil {
+		t.Fatal(err)
+	}
+	res, err := e.HybridSearch(context.Background(), "apply fix for E1234", 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, r := range res {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"1", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Invalid RRF Results - Want: %v, Got: %v", want, ids)
+	}
+	if s := res[0]; s.Score != 1.0/61 || s.Semantic.Rank != 1 || s.Semantic.Score < 0.999 || s.Lexical.Rank != 0 {
+		t.Errorf("Invalid RRF Signals - Got: %+v", s)
+	}
+	if s := res[1]; s.Score != 1.0/61 || s.Semantic.Rank != 0 || s.Lexical.Rank != 1 || s.Lexical.Score <= 0 {
+		t.Errorf("Invalid RRF Signals - Got: %+v", s)
+	}
+	res, _ = e.HybridSearch(context.Background(), "apply fix for E1234", 1, Weighted(0))
+	if len(res) != 1 || res[0].ID != "3" || res[0].Score != 1 {
+		t.Errorf("Invalid Lexical Results - Got: %+v", res)
+	}
+	res, _ = e.HybridSearch(context.Background(), "apply fix for E1234", 0, Weighted(0.5), Match("lang", "en"))
+	if len(res) != 1 || res[0].ID != "3" || res[0].Score != 1 {
+		t.Errorf("Invalid Filtered Results - Got: %+v", res)
+	}
+	if err := e.Delete("3"); err != nil {
+		t.Fatal(err)
+	}
+	res, _ = e.HybridSearch(context.Background(), "apply fix for E1234", 3, Weighted(0))
+	if len(res) != 3 || res[2].ID != "4" || res[0].Lexical.Rank != 0 {
+		t.Errorf("Invalid Results After Delete - Got: %+v", res)
+	}
+}
+
+func TestDeleteMissingFromIndex(t *testing.T) {
+	idx := NewFlat()
+	e := New(fakeModel(), WithIndex(idx), WithLexicalIndex(NewBM25()))
+	if err := e.Add(context.Background(), Document{ID: "1", Text: "a"}, Document{ID: "2", Text: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	e.lexical = NewBM25() // lost the texts
+	e.lexical.Add("a")
+	if err := e.Delete("2"); err == nil {
+		t.Errorf("Invalid Delete Error - Want: an error, Got: %v", err)
+	}
+	if idx.deleted[1] || e.Len() != 2 {
+		t.Errorf("Invalid Vector Index - Want: 2 documents, Got: %d deleted %v", e.Len(), idx.deleted)
+	}
+}
+
+func TestFusion(t *testing.T) {
+	semantic := []Hit{{ID: 1, Score: 0.9}, {ID: 2, Score: 0.5}, {ID: 3, Score: 0.1}}
+	lexical := []Hit{{ID: 3, Score: 12}, {ID: 4, Score: 6}}
+	tests := []struct {
+		fuse Fusion
+		want map[int]float32
+	}{
+		{RRF(1), map[int]float32{1: 1.0 / 2, 2: 1.0 / 3, 3: 1.0/4 + 1.0/2, 4: 1.0 / 3}},
+		{Weighted(0.75), map[int]float32{1: 0.75, 2: 0.75 * 0.5, 3: 0.25, 4: 0}},
+		{Weighted(1), map[int]float32{1: 1, 2: 0.5, 3: 0, 4: 0}},
+	}
+	for i, test := range tests {
+		got := test.fuse(semantic, lexical)
+		if len(got) != len(test.want) {
+			t.Errorf("Invalid Fusion %d - Want: %v, Got: %v", i, test.want, got)
+		}
+		for id, s := range test.want {
+			if d := got[id] - s; d < -1e-6 || d > 1e-6 {
+				t.Errorf("Invalid Fusion %d Score of %d - Want: %v, Got: %v", i, id, s, got[id])
+			}
+		}
+	}
+}
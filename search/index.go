@@ -0,0 +1,186 @@
+package search
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Hit is a vector of an index matching a query, Score is the cosine similarity
+type Hit struct {
+	ID    int
+	Score float32
+}
+
+// VectorIndex finds the nearest neighbors of a query by cosine similarity.
+// Vectors are identified by the order they were added in, starting at 0.
+// The Engine serializes calls to Add, Search may be called concurrently with other calls to Search.
+type VectorIndex interface {
+	// Add appends vecs to the index
+	Add(vecs ...model.Embedding) error
+	// Search returns up to k hits sorted by decreasing score, only IDs accepted by accept are returned if it isn't nil
+	Search(q model.Embedding, k int, accept func(id int) bool) ([]Hit, error)
+	// Len returns the number of vectors added to the index, including deleted ones
+	Len() int
+}
+
+// Deleter is a VectorIndex that can delete vectors, their IDs aren't reused
+type Deleter interface {
+	Delete(id int) error
+}
+
+// Flat is a VectorIndex comparing the query to every vector, results are exact
+type Flat struct {
+	dim     int
+	vecs    []model.Embedding
+	deleted map[int]bool
+}
+
+// NewFlat returns an empty Flat index
+func NewFlat() *Flat {
+	return &Flat{}
+}
+
+// Add normalizes vecs and appends them, they must all have the same dimension
+func (f *Flat) Add(vecs ...model.Embedding) error {
+	dim := f.dim
+	for _, v := range vecs {
+		if err := CheckDim(v, &dim); err != nil {
+			return err
+		}
+	}
+	f.dim = dim
+	for _, v := range vecs {
+		f.vecs = append(f.vecs, Normalize(v))
+	}
+	return nil
+}
+
+// Search scans every vector accepted by accept
+func (f *Flat) Search(q model.Embedding, k int, accept func(id int) bool) ([]Hit, error) {
+	if len(f.vecs) == 0 || k <= 0 {
+		return nil, nil
+	}
+	if err := CheckDim(q, &f.dim); err != nil {
+		return nil, err
+	}
+	q = Normalize(q)
+	top := NewTopK(k)
+	for id, v := range f.vecs {
+		if f.deleted[id] || (accept != nil && !accept(id)) {
+			continue
+		}
+		top.Push(Hit{ID: id, Score: Dot(q, v)})
+	}
+	return top.Sorted(), nil
+}
+
+// Delete removes the vector id from search results
+func (f *Flat) Delete(id int) error {
+	if id < 0 || id >= len(f.vecs) {
+		return fmt.Errorf("unknown vector %d", id)
+	}
+	if f.deleted == nil {
+		f.deleted = map[int]bool{}
+	}
+	f.deleted[id] = true
+	return nil
+}
+
+// Len returns the number of vectors added to the index, including deleted ones
+func (f *Flat) Len() int {
+	return len(f.vecs)
+}
+
+// CheckDim checks v has the dimension dim, which is set to the one of v if it is 0
+func CheckDim(v model.Embedding, dim *int) error {
+	switch {
+	case len(v) == 0:
+		return fmt.Errorf("empty vector")
+	case *dim == 0:
+		*dim = len(v)
+	case len(v) != *dim:
+		return fmt.Errorf("mismatched vector dimension %d, index has %d", len(v), *dim)
+	}
+	return nil
+}
+
+// Dot returns the dot product of x and y, the cosine similarity of normalized vectors
+func Dot(x, y model.Embedding) float32 {
+	y = y[:len(x)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		s0 += x[i] * y[i]
+		s1 += x[i+1] * y[i+1]
+		s2 += x[i+2] * y[i+2]
+		s3 += x[i+3] * y[i+3]
+	}
+	for ; i < len(x); i++ {
+		s0 += x[i] * y[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// Normalize returns a copy of v scaled to a unit length, a zero vector is copied as is
+func Normalize(v model.Embedding) model.Embedding {
+	n := float32(math.Sqrt(float64(Dot(v, v))))
+	u := make(model.Embedding, len(v))
+	for i, x := range v {
+		if n > 0 {
+			x /= n
+		}
+		u[i] = x
+	}
+	return u
+}
+
+// TopK keeps the k best hits pushed to it, ties are broken by the lowest ID
+type TopK struct {
+	k    int
+	hits hits
+}
+
+// NewTopK returns an empty TopK keeping k hits
+func NewTopK(k int) *TopK {
+	return &TopK{k: k}
+}
+
+// Push adds h if it is better than the worst of the k hits
+func (t *TopK) Push(h Hit) {
+	if len(t.hits) < t.k {
+		heap.Push(&t.hits, h)
+	} else if t.hits.better(h, t.hits[0]) {
+		t.hits[0] = h
+		heap.Fix(&t.hits, 0)
+	}
+}
+
+// Sorted empties t into hits of decreasing score
+func (t *TopK) Sorted() []Hit {
+	res := make([]Hit, len(t.hits))
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = heap.Pop(&t.hits).(Hit)
+	}
+	return res
+}
+
+// hits is a heap.Interface with the worst hit first
+type hits []Hit
+
+func (h hits) better(a, b Hit) bool {
+	return a.Score > b.Score || (a.Score == b.Score && a.ID < b.ID)
+}
+
+func (h hits) Len() int            { return len(h) }
+func (h hits) Less(i, j int) bool  { return h.better(h[j], h[i]) }
+func (h hits) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hits) Push(x interface{}) { *h = append(*h, x.(Hit)) }
+func (h *hits) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,221 @@
+package quant
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Defaults of product quantization
+const (
+	DefaultCentroids  = 256
+	DefaultIterations = 20
+)
+
+// PQ splits vectors into M groups of dimensions and encodes each group by the nearest of K centroids learned
+// with k-means, a code is M bytes. 768 dimension vectors encoded in 96 bytes are 32 times smaller.
+type PQ struct {
+	dim       int
+	m         int
+	k         int
+	sub       int       // dimensions per group
+	centroids []float32 // k centroids of each group, group major
+}
+
+// pqConfig holds the training parameters of a PQ
+type pqConfig struct {
+	k          int
+	iterations int
+	seed       int64
+}
+
+// PQOption configures the training of a PQ
+type PQOption func(c *pqConfig) *pqConfig
+
+// WithCentroids sets the number of centroids of each group, at most 256 so codes fit a byte
+func WithCentroids(k int) PQOption {
+	return func(c *pqConfig) *pqConfig {
+		c.k = k
+		return c
+	}
+}
+
+// WithIterations sets the number of k-means iterations
+func WithIterations(n int) PQOption {
+	return func(c *pqConfig) *pqConfig {
+		c.iterations = n
+		return c
+	}
+}
+
+// WithSeed seeds the choice of the initial centroids
+func WithSeed(seed int64) PQOption {
+	return func(c *pqConfig) *pqConfig {
+		c.seed = seed
+		return c
+	}
+}
+
+// TrainPQ learns the centroids of m groups of the dimensions of vecs, m must divide the dimension.
+// There are fewer centroids than set when there are fewer training vectors.
+func TrainPQ(vecs []model.Embedding, m int, opts ...PQOption) (*PQ, error) {
+	c := &pqConfig{k: DefaultCentroids, iterations: DefaultIterations, seed: 1}
+	for _, opt := range opts {
+		c = opt(c)
+	}
+	if len(vecs) == 0 || len(vecs[0]) == 0 {
+		return nil, fmt.Errorf("no vectors to train on")
+	}
+	dim := len(vecs[0])
+	if m <= 0 || dim%m != 0 {
+		return nil, fmt.Errorf("%d groups don't divide the dimension %d", m, dim)
+	}
+	if c.k <= 0 || c.k > 256 {
+		return nil, fmt.Errorf("invalid centroid count %d, must be in [1, 256]", c.k)
+	}
+	for _, v := range vecs {
+		if len(v) != dim {
+			return nil, fmt.Errorf("mismatched vector dimension %d, expected %d", len(v), dim)
+		}
+	}
+	k := min(c.k, len(vecs))
+	p := &PQ{dim: dim, m: m, k: k, sub: dim / m, centroids: make([]float32, k*dim)}
+	rng := rand.New(rand.NewSource(c.seed))
+	for g := 0; g < m; g++ {
+		sub := make([][]float32, len(vecs))
+		for i, v := range vecs {
+			sub[i] = v[g*p.sub : (g+1)*p.sub]
+		}
+		copy(p.group(g), kmeans(sub, k, c.iterations, rng))
+	}
+	return p, nil
+}
+
+// group returns the centroids of group g
+func (p *PQ) group(g int) []float32 {
+	return p.centroids[g*p.k*p.sub : (g+1)*p.k*p.sub]
+}
+
+// Dim is the dimension of the encoded vectors
+func (p *PQ) Dim() int {
+	return p.dim
+}
+
+// CodeSize is a byte per group
+func (p *PQ) CodeSize() int {
+	return p.m
+}
+
+// Encode appends the nearest centroid of each group of v
+func (p *PQ) Encode(dst []byte, v model.Embedding) []byte {
+	for g := 0; g < p.m; g++ {
+		dst = append(dst, byte(nearest(v[g*p.sub:(g+1)*p.sub], p.group(g), p.sub)))
+	}
+	return dst
+}
+
+// Decode concatenates the centroids of code
+func (p *PQ) Decode(code []byte) model.Embedding {
+	v := make(model.Embedding, 0, p.dim)
+	for g, c := range code {
+		v = append(v, p.group(g)[int(c)*p.sub:(int(c)+1)*p.sub]...)
+	}
+	return v
+}
+
+// Scorer precomputes the dot product of each group of q with its centroids, a code is scored with a lookup per group
+func (p *PQ) Scorer(q model.Embedding) func(code []byte) float32 {
+	table := make([]float32, p.m*p.k)
+	for g := 0; g < p.m; g++ {
+		qg := q[g*p.sub : (g+1)*p.sub]
+		cs := p.group(g)
+		for c := 0; c < p.k; c++ {
+			var dot float32
+			for j, x := range cs[c*p.sub : (c+1)*p.sub] {
+				dot += qg[j] * x
+			}
+			table[g*p.k+c] = dot
+		}
+	}
+	return func(code []byte) float32 {
+		var score float32
+		for g, c := range code {
+			score += table[g*p.k+int(c)]
+		}
+		return score
+	}
+}
+
+// kmeans returns k centroids of vecs of dimension len(vecs[0]), flattened.
+// Centroids start at distinct random vectors, ones left without vectors are moved to the farthest vector.
+func kmeans(vecs [][]float32, k, iterations int, rng *rand.Rand) []float32 {
+	dim := len(vecs[0])
+	centroids := make([]float32, k*dim)
+	for c, i := range rng.Perm(len(vecs))[:k] {
+		copy(centroids[c*dim:], vecs[i])
+	}
+	assign := make([]int, len(vecs))
+	counts := make([]int, k)
+	for it := 0; it < iterations; it++ {
+		for i, v := range vecs {
+			assign[i] = nearest(v, centroids, dim)
+		}
+		for i := range centroids {
+			centroids[i] = 0
+		}
+		for c := range counts {
+			counts[c] = 0
+		}
+		for i, v := range vecs {
+			c := assign[i]
+			counts[c]++
+			for j, x := range v {
+				centroids[c*dim+j] += x
+			}
+		}
+		for c, n := range counts {
+			if n == 0 {
+				copy(centroids[c*dim:(c+1)*dim], farthest(vecs, assign, centroids, dim))
+				continue
+			}
+			for j := range centroids[c*dim : (c+1)*dim] {
+				centroids[c*dim+j] /= float32(n)
+			}
+		}
+	}
+	return centroids
+}
+
+// farthest returns the vector farthest from the centroid it is assigned to
+func farthest(vecs [][]float32, assign []int, centroids []float32, dim int) []float32 {
+	best, far := 0, float32(-1)
+	for i, v := range vecs {
+		if d := l2(v, centroids[assign[i]*dim:(assign[i]+1)*dim]); d > far {
+			best, far = i, d
+		}
+	}
+	return vecs[best]
+}
+
+// nearest returns the index of the centroid nearest to v by euclidean distance
+func nearest(v, centroids []float32, dim int) int {
+	best, dist := 0, float32(math.Inf(1))
+	for c := 0; c*dim < len(centroids); c++ {
+		if d := l2(v, centroids[c*dim:(c+1)*dim]); d < dist {
+			best, dist = c, d
+		}
+	}
+	return best
+}
+
+// l2 returns the squared euclidean distance of x and y
+func l2(x, y []float32) float32 {
+	var d float32
+	for i := range x {
+		diff := x[i] - y[i]
+		d += diff * diff
+	}
+	return d
+}
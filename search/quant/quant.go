@@ -0,0 +1,192 @@
+// Package quant compresses embeddings into compact codes that are searched without decoding them.
+// Scalar quantization stores a byte per dimension, product quantization a byte per group of dimensions.
+// Searches use asymmetric distance computation: the query is kept in float32 and scored against the codes.
+package quant
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// Quantizer encodes vectors into fixed size codes, it is trained on a sample of the vectors to encode
+type Quantizer interface {
+	// Dim is the dimension of the encoded vectors
+	Dim() int
+	// CodeSize is the number of bytes of a code
+	CodeSize() int
+	// Encode appends the code of v to dst
+	Encode(dst []byte, v model.Embedding) []byte
+	// Decode returns the approximation of the vector of code
+	Decode(code []byte) model.Embedding
+	// Scorer returns a function approximating the dot product of q and the vector of a code
+	Scorer(q model.Embedding) func(code []byte) float32
+}
+
+// Kinds of quantizers in their marshaled form
+const (
+	scalarKind byte = 1
+	pqKind     byte = 2
+)
+
+// ErrFormat is returned when unmarshaling data that isn't a quantizer
+var ErrFormat = errors.New("not a marshaled quantizer")
+
+// Marshal encodes q so it can be stored with its codes, ex in an embfile.Header
+func Marshal(q Quantizer) ([]byte, error) {
+	switch q := q.(type) {
+	case *Scalar:
+		b := []byte{scalarKind}
+		b = appendFloats(b, q.min)
+		return appendFloats(b, q.scale), nil
+	case *PQ:
+		b := []byte{pqKind}
+		b = binary.LittleEndian.AppendUint32(b, uint32(q.dim))
+		b = binary.LittleEndian.AppendUint32(b, uint32(q.m))
+		b = binary.LittleEndian.AppendUint32(b, uint32(q.k))
+		return appendFloats(b, q.centroids), nil
+	}
+	return nil, fmt.Errorf("can't marshal quantizer %T", q)
+}
+
+// Unmarshal decodes a quantizer encoded by Marshal
+func Unmarshal(b []byte) (Quantizer, error) {
+	if len(b) == 0 {
+		return nil, ErrFormat
+	}
+	switch b[0] {
+	case scalarKind:
+		lo, rest, err := readFloats(b[1:])
+		if err != nil {
+			return nil, err
+		}
+		scale, rest, err := readFloats(rest)
+		if err != nil || len(rest) != 0 || len(lo) == 0 || len(lo) != len(scale) {
+			return nil, ErrFormat
+		}
+		return &Scalar{min: lo, scale: scale}, nil
+	case pqKind:
+		if len(b) < 13 {
+			return nil, ErrFormat
+		}
+		dim := int(binary.LittleEndian.Uint32(b[1:]))
+		m := int(binary.LittleEndian.Uint32(b[5:]))
+		k := int(binary.LittleEndian.Uint32(b[9:]))
+		fs, rest, err := readFloats(b[13:])
+		if err != nil || len(rest) != 0 || m <= 0 || k <= 0 || k > 256 || dim%m != 0 || len(fs) != k*dim {
+			return nil, ErrFormat
+		}
+		return &PQ{dim: dim, m: m, k: k, sub: dim / m, centroids: fs}, nil
+	}
+	return nil, ErrFormat
+}
+
+func appendFloats(b []byte, fs []float32) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(fs)))
+	for _, f := range fs {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	return b
+}
+
+// readFloats reads floats appended by appendFloats at the start of b and returns the bytes after them
+func readFloats(b []byte) ([]float32, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, ErrFormat
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	b = b[4:]
+	if n < 0 || len(b) < 4*n {
+		return nil, nil, ErrFormat
+	}
+	fs := make([]float32, n)
+	for i := range fs {
+		fs[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return fs, b[4*n:], nil
+}
+
+// Index is a search.VectorIndex storing the codes of normalized vectors, scores approximate the cosine similarity
+type Index struct {
+	q       Quantizer
+	codes   []byte
+	deleted map[int]bool
+}
+
+var (
+	_ search.VectorIndex = (*Index)(nil)
+	_ search.Deleter     = (*Index)(nil)
+)
+
+// NewIndex returns an empty index encoding vectors with q, it should be trained on normalized vectors
+func NewIndex(q Quantizer) *Index {
+	return &Index{q: q}
+}
+
+// NewIndexFromCodes returns an index of codes encoded by q, ex read from a file, they are copied.
+// The codes must be of normalized vectors, concatenated without padding.
+func NewIndexFromCodes(q Quantizer, codes []byte) (*Index, error) {
+	if len(codes)%q.CodeSize() != 0 {
+		return nil, fmt.Errorf("codes of %d bytes aren't a multiple of the code size %d", len(codes), q.CodeSize())
+	}
+	return &Index{q: q, codes: append([]byte(nil), codes...)}, nil
+}
+
+// Add normalizes and encodes vecs
+func (x *Index) Add(vecs ...model.Embedding) error {
+	for _, v := range vecs {
+		if len(v) != x.q.Dim() {
+			return fmt.Errorf("mismatched vector dimension %d, index has %d", len(v), x.q.Dim())
+		}
+	}
+	for _, v := range vecs {
+		x.codes = x.q.Encode(x.codes, search.Normalize(v))
+	}
+	return nil
+}
+
+// Search scores the code of every vector accepted by accept
+func (x *Index) Search(q model.Embedding, k int, accept func(id int) bool) ([]search.Hit, error) {
+	if len(q) != x.q.Dim() {
+		return nil, fmt.Errorf("mismatched vector dimension %d, index has %d", len(q), x.q.Dim())
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	score := x.q.Scorer(search.Normalize(q))
+	size := x.q.CodeSize()
+	top := search.NewTopK(k)
+	for id := 0; id < x.Len(); id++ {
+		if x.deleted[id] || (accept != nil && !accept(id)) {
+			continue
+		}
+		top.Push(search.Hit{ID: id, Score: score(x.codes[id*size : (id+1)*size])})
+	}
+	return top.Sorted(), nil
+}
+
+// Delete removes the vector id from search results
+func (x *Index) Delete(id int) error {
+	if id < 0 || id >= x.Len() {
+		return fmt.Errorf("unknown vector %d", id)
+	}
+	if x.deleted == nil {
+		x.deleted = map[int]bool{}
+	}
+	x.deleted[id] = true
+	return nil
+}
+
+// Len returns the number of vectors added to the index, including deleted ones
+func (x *Index) Len() int {
+	return len(x.codes) / x.q.CodeSize()
+}
+
+// Bytes returns the size of the codes
+func (x *Index) Bytes() int {
+	return len(x.codes)
+}
@@ -0,0 +1,118 @@
+package quant
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// clustered draws normalized vectors around topics, like sentence embeddings
+func clustered(seed int64, n, dim int) []model.Embedding {
+	vecs := modeltest.Embeddings(seed, n, dim, 32, 0.5)
+	for i, v := range vecs {
+		vecs[i] = search.Normalize(v)
+	}
+	return vecs
+}
+
+func TestQuantizers(t *testing.T) {
+	vecs := clustered(3, 2050, 64)
+	vecs, queries := vecs[:2000], vecs[2000:]
+	scalar, err := TrainScalar(vecs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pq, err := TrainPQ(vecs, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		q      Quantizer
+		recall float64
+		ratio  float64
+	}{
+		{"scalar", scalar, 0.95, 4},
+		{"pq", pq, 0.5, 16},
+	}
+	for _, test := range tests {
+		r, err := Evaluate(test.q, vecs, queries, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%s %s", test.name, r)
+		if r.Recall < test.recall || r.Ratio() != test.ratio {
+			t.Errorf("Invalid %s Report - Want: recall >= %v and ratio %v, Got: %s", test.name, test.recall, test.ratio, r)
+		}
+		// ADC scores the code like the decoded vector
+		code := test.q.Encode(nil, vecs[0])
+		if got, want := test.q.Scorer(queries[0])(code), search.Dot(queries[0], test.q.Decode(code)); math.Abs(float64(got-want)) > 1e-4 {
+			t.Errorf("Invalid %s Score - Want: %v, Got: %v", test.name, want, got)
+		}
+		b, err := Marshal(test.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := Unmarshal(b)
+		if err != nil || !reflect.DeepEqual(q, test.q) {
+			t.Errorf("Invalid %s Unmarshal - Got: %v", test.name, err)
+		}
+		if _, err := Unmarshal(b[:len(b)-1]); err == nil {
+			t.Errorf("Invalid Truncated %s Unmarshal - Want: error", test.name)
+		}
+	}
+}
+
+func TestScalarClamp(t *testing.T) {
+	s, _ := TrainScalar([]model.Embedding{{0, 1}, {1, 1}})
+	code := s.Encode(nil, model.Embedding{2, -1})
+	if want := []byte{255, 0}; !reflect.DeepEqual(code, want) {
+		t.Errorf("Invalid Code - Want: %v, Got: %v", want, code)
+	}
+	if v := s.Decode([]byte{51, 0}); math.Abs(float64(v[0])-0.2) > 1e-6 || v[1] != 1 {
+		t.Errorf("Invalid Decoded Vector - Want: [0.2 1], Got: %v", v)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	vecs := clustered(5, 300, 8)
+	pq, err := TrainPQ(vecs, 4, WithCentroids(16), WithIterations(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TrainPQ(vecs, 3); err == nil {
+		t.Errorf("Invalid Groups - Want: error")
+	}
+	x := NewIndex(pq)
+	x.Add(vecs...)
+	if x.Len() != 300 || x.Bytes() != 1200 {
+		t.Errorf("Invalid Size - Want: 300 1200, Got: %d %d", x.Len(), x.Bytes())
+	}
+	hits, _ := x.Search(vecs[7], 5, nil)
+	found := false
+	for _, h := range hits {
+		found = found || h.ID == 7
+	}
+	if !found {
+		t.Errorf("Invalid Hits - Want: 7 in the top 5, Got: %v", hits)
+	}
+	y, err := NewIndexFromCodes(pq, x.codes)
+	if err != nil {
+		t.Fatalf("Invalid Error - Want: %v, Got: %v", nil, err)
+	}
+	if !reflect.DeepEqual(y.codes, x.codes) {
+		t.Errorf("Invalid Index From Codes - Want: %d bytes, Got: %d", x.Bytes(), y.Bytes())
+	}
+	if _, err := NewIndexFromCodes(pq, x.codes[:3]); err == nil {
+		t.Errorf("Invalid Error - Want: partial code, Got: %v", err)
+	}
+	x.Delete(7)
+	hits, _ = x.Search(vecs[7], 300, func(id int) bool { return id < 10 })
+	if len(hits) != 9 {
+		t.Errorf("Invalid Filtered Hits - Want: 9, Got: %d", len(hits))
+	}
+}
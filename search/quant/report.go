@@ -0,0 +1,81 @@
+package quant
+
+import (
+	"fmt"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// Report compares searches of quantized vectors to exact searches of the float32 vectors
+type Report struct {
+	K       int
+	Queries int
+	// Recall is the mean fraction of the exact top K found in the quantized top K, 1 - Recall is the loss
+	Recall float64
+	// Bytes is the size of the float32 vectors, CodeBytes of their codes
+	Bytes     int
+	CodeBytes int
+}
+
+// Ratio is the compression ratio of the codes
+func (r Report) Ratio() float64 {
+	if r.CodeBytes == 0 {
+		return 0
+	}
+	return float64(r.Bytes) / float64(r.CodeBytes)
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("recall@%d %.3f (loss %.3f) over %d queries, %d bytes -> %d bytes (%.1fx)",
+		r.K, r.Recall, 1-r.Recall, r.Queries, r.Bytes, r.CodeBytes, r.Ratio())
+}
+
+// Evaluate searches the k nearest of vecs to each query with codes of q and with the exact cosine similarity
+// of a search.Flat index, and reports the recall of the quantized searches
+func Evaluate(q Quantizer, vecs, queries []model.Embedding, k int) (Report, error) {
+	exact := search.NewFlat()
+	approx := NewIndex(q)
+	if err := exact.Add(vecs...); err != nil {
+		return Report{}, err
+	}
+	if err := approx.Add(vecs...); err != nil {
+		return Report{}, err
+	}
+	r := Report{K: k, Queries: len(queries), Bytes: 4 * q.Dim() * len(vecs), CodeBytes: approx.Bytes()}
+	if len(queries) == 0 {
+		return r, nil
+	}
+	var total float64
+	for _, query := range queries {
+		want, err := exact.Search(query, k, nil)
+		if err != nil {
+			return Report{}, err
+		}
+		got, err := approx.Search(query, k, nil)
+		if err != nil {
+			return Report{}, err
+		}
+		total += recall(want, got)
+	}
+	r.Recall = total / float64(len(queries))
+	return r, nil
+}
+
+// recall is the fraction of want found in got
+func recall(want, got []search.Hit) float64 {
+	if len(want) == 0 {
+		return 1
+	}
+	found := map[int]bool{}
+	for _, h := range got {
+		found[h.ID] = true
+	}
+	var n int
+	for _, h := range want {
+		if found[h.ID] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(want))
+}
@@ -0,0 +1,89 @@
+package quant
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Scalar maps each dimension to 256 levels between its min and max over the training vectors,
+// shrinking float32 vectors by 4 with a small loss of precision. Values out of the range are clamped.
+type Scalar struct {
+	min   []float32
+	scale []float32 // size of a level
+}
+
+// TrainScalar learns the range of each dimension of vecs
+func TrainScalar(vecs []model.Embedding) (*Scalar, error) {
+	if len(vecs) == 0 || len(vecs[0]) == 0 {
+		return nil, fmt.Errorf("no vectors to train on")
+	}
+	dim := len(vecs[0])
+	lo := make([]float32, dim)
+	hi := make([]float32, dim)
+	copy(lo, vecs[0])
+	copy(hi, vecs[0])
+	for _, v := range vecs {
+		if len(v) != dim {
+			return nil, fmt.Errorf("mismatched vector dimension %d, expected %d", len(v), dim)
+		}
+		for i, x := range v {
+			lo[i] = min(lo[i], x)
+			hi[i] = max(hi[i], x)
+		}
+	}
+	s := &Scalar{min: lo, scale: make([]float32, dim)}
+	for i := range s.scale {
+		s.scale[i] = (hi[i] - lo[i]) / 255
+	}
+	return s, nil
+}
+
+// Dim is the dimension of the encoded vectors
+func (s *Scalar) Dim() int {
+	return len(s.min)
+}
+
+// CodeSize is a byte per dimension
+func (s *Scalar) CodeSize() int {
+	return len(s.min)
+}
+
+// Encode appends the nearest level of each dimension of v
+func (s *Scalar) Encode(dst []byte, v model.Embedding) []byte {
+	for i, x := range v {
+		var level float64
+		if s.scale[i] > 0 {
+			level = math.Round(float64((x - s.min[i]) / s.scale[i]))
+		}
+		dst = append(dst, byte(max(0, min(255, level))))
+	}
+	return dst
+}
+
+// Decode returns the levels of code
+func (s *Scalar) Decode(code []byte) model.Embedding {
+	v := make(model.Embedding, len(code))
+	for i, c := range code {
+		v[i] = s.min[i] + float32(c)*s.scale[i]
+	}
+	return v
+}
+
+// Scorer folds the ranges into q so a code is scored with a multiply-add per dimension
+func (s *Scalar) Scorer(q model.Embedding) func(code []byte) float32 {
+	qs := make([]float32, len(q))
+	var bias float32
+	for i, x := range q {
+		qs[i] = x * s.scale[i]
+		bias += x * s.min[i]
+	}
+	return func(code []byte) float32 {
+		score := bias
+		for i, c := range code {
+			score += qs[i] * float32(c)
+		}
+		return score
+	}
+}
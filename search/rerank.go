@@ -0,0 +1,25 @@
+package search
+
+import (
+	"context"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Rerank reorders the results of a search of query by the relevance scores of r, a cross-encoder.
+// Score is set to the relevance, retrieve more results than needed and keep the top ones.
+func Rerank(ctx context.Context, r *model.Reranker, query string, res []Result) ([]Result, error) {
+	passages := make([]string, len(res))
+	for i, doc := range res {
+		passages[i] = doc.Text
+	}
+	ranked, err := r.Rerank(ctx, query, passages...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Result, len(ranked))
+	for i, rk := range ranked {
+		out[i] = Result{Document: res[rk.Index].Document, Score: rk.Score}
+	}
+	return out, nil
+}
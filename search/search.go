@@ -0,0 +1,367 @@
+// Package search is a semantic search engine over documents embedded with a BERT model
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// Defaults of an Engine
+const (
+	DefaultBatchSize = 32
+	DefaultK         = 10
+)
+
+// Errors of an Engine
+var (
+	// ErrDuplicateID is returned when adding a document with the ID of one already in the engine
+	ErrDuplicateID = errors.New("duplicate document id")
+	// ErrNotFound is returned when deleting a document that isn't in the engine
+	ErrNotFound = errors.New("document not found")
+	// ErrNoLexicalIndex is returned by HybridSearch when the engine wasn't built WithLexicalIndex
+	ErrNoLexicalIndex = errors.New("no lexical index")
+)
+
+// Document is a text to search and its metadata, ID is optional but must be unique when set
+type Document struct {
+	ID       string
+	Text     string
+	Metadata map[string]string
+}
+
+// Result is a document matching a query, Score is the cosine similarity of their embeddings
+type Result struct {
+	Document
+	Score float32
+}
+
+// Filter selects the documents that can be returned by a search
+type Filter func(d Document) bool
+
+// Match is a Filter of documents with the metadata value set for key
+func Match(key, value string) Filter {
+	return func(d Document) bool {
+		v, ok := d.Metadata[key]
+		return ok && v == value
+	}
+}
+
+// Engine embeds documents with a model and searches them by the embedding of a query, texts can also be indexed by BM25
+// for hybrid searches. It is safe for concurrent use, documents are embedded outside of the lock so searches aren't
+// blocked by adds.
+type Engine struct {
+	m          model.Predictor
+	pooling    model.Pooling
+	batch      int
+	workers    int
+	candidates int
+
+	mu      sync.RWMutex
+	docs    []Document
+	ids     map[string]int
+	deleted int
+	index   VectorIndex
+	lexical *BM25
+}
+
+// Option configures an Engine
+type Option func(e *Engine) *Engine
+
+// WithPooling sets how token vectors are pooled into embeddings, MeanPooling by default
+func WithPooling(p model.Pooling) Option {
+	return func(e *Engine) *Engine {
+		e.pooling = p
+		return e
+	}
+}
+
+// WithBatchSize sets the number of documents embedded together
+func WithBatchSize(n int) Option {
+	return func(e *Engine) *Engine {
+		e.batch = n
+		return e
+	}
+}
+
+// WithWorkers sets the number of batches embedded concurrently, 1 by default.
+// The model bounds its session runs itself, ex with model.WithConcurrency.
+func WithWorkers(n int) Option {
+	return func(e *Engine) *Engine {
+		e.workers = n
+		return e
+	}
+}
+
+// WithIndex stores embeddings in idx instead of a Flat index.
+// It must be empty, or hold the embeddings of the documents then added with AddIndexed.
+func WithIndex(idx VectorIndex) Option {
+	return func(e *Engine) *Engine {
+		e.index = idx
+		return e
+	}
+}
+
+// WithLexicalIndex also indexes texts in x for HybridSearch, ex NewBM25(), it must be empty.
+// Texts aren't indexed by default as the index grows with the vocabulary of the documents.
+func WithLexicalIndex(x *BM25) Option {
+	return func(e *Engine) *Engine {
+		e.lexical = x
+		return e
+	}
+}
+
+// WithCandidates sets the number of hits of each search fused by HybridSearch, DefaultCandidates by default
+func WithCandidates(n int) Option {
+	return func(e *Engine) *Engine {
+		e.candidates = n
+		return e
+	}
+}
+
+// New returns an empty engine embedding with m, an embedding model
+func New(m model.Predictor, opts ...Option) *Engine {
+	e := &Engine{
+		m:          m,
+		pooling:    model.MeanPooling,
+		batch:      DefaultBatchSize,
+		workers:    1,
+		candidates: DefaultCandidates,
+		ids:        map[string]int{},
+		index:      NewFlat(),
+	}
+	for _, opt := range opts {
+		e = opt(e)
+	}
+	if e.batch <= 0 {
+		e.batch = DefaultBatchSize
+	}
+	if e.workers <= 0 {
+		e.workers = 1
+	}
+	return e
+}
+
+// Add embeds docs and adds them to the engine, none are added if any fails
+func (e *Engine) Add(ctx context.Context, docs ...Document) error {
+	seen := map[string]bool{}
+	for _, d := range docs {
+		if d.ID == "" {
+			continue
+		}
+		if seen[d.ID] || e.Has(d.ID) {
+			return fmt.Errorf("%w %q", ErrDuplicateID, d.ID)
+		}
+		seen[d.ID] = true
+	}
+	vecs, err := e.Embed(ctx, docs...)
+	if err != nil {
+		return err
+	}
+	return e.AddEmbedded(docs, vecs)
+}
+
+// AddEmbedded adds docs with their precomputed embeddings, ex read from a file.
+// They must have been embedded by the same model and pooling as the engine.
+func (e *Engine) AddEmbedded(docs []Document, vecs []model.Embedding) error {
+	if len(docs) != len(vecs) {
+		return fmt.Errorf("mismatched embedding count %d for %d documents", len(vecs), len(docs))
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.checkIDs(docs); err != nil {
+		return err
+	}
+	if err := e.index.Add(vecs...); err != nil {
+		return err
+	}
+	e.append(docs)
+	return nil
+}
+
+// AddIndexed adds docs whose embeddings are already in the index of the engine, in the same order after the ones
+// of the documents of the engine, ex an index read from a file.
+func (e *Engine) AddIndexed(docs ...Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n := len(e.docs) + len(docs); n > e.index.Len() {
+		return fmt.Errorf("%d documents for %d indexed embeddings", n, e.index.Len())
+	}
+	if err := e.checkIDs(docs); err != nil {
+		return err
+	}
+	e.append(docs)
+	return nil
+}
+
+// checkIDs returns ErrDuplicateID if an ID of docs is repeated or already in the engine, e.mu must be held
+func (e *Engine) checkIDs(docs []Document) error {
+	seen := map[string]bool{}
+	for _, d := range docs {
+		if d.ID == "" {
+			continue
+		}
+		if _, ok := e.ids[d.ID]; ok || seen[d.ID] {
+			return fmt.Errorf("%w %q", ErrDuplicateID, d.ID)
+		}
+		seen[d.ID] = true
+	}
+	return nil
+}
+
+// append adds docs after their embeddings were indexed, e.mu must be held
+func (e *Engine) append(docs []Document) {
+	for _, d := range docs {
+		if e.lexical != nil {
+			e.lexical.Add(d.Text)
+		}
+		if d.ID != "" {
+			e.ids[d.ID] = len(e.docs)
+		}
+		e.docs = append(e.docs, d)
+	}
+}
+
+// Search returns the k documents most similar to query that pass every filter, by decreasing score.
+// k <= 0 uses DefaultK, there are fewer results when fewer documents match.
+func (e *Engine) Search(ctx context.Context, query string, k int, filters ...Filter) ([]Result, error) {
+	if k <= 0 {
+		k = DefaultK
+	}
+	embs, err := model.Embed(ctx, e.m, e.pooling, query)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	hits, err := e.index.Search(embs[0], k, e.accept(filters))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Result, len(hits))
+	for i, h := range hits {
+		res[i] = Result{Document: e.docs[h.ID], Score: h.Score}
+	}
+	return res, nil
+}
+
+// accept returns whether a document passes every filter, it is nil when there are no filters
+func (e *Engine) accept(filters []Filter) func(id int) bool {
+	if len(filters) == 0 {
+		return nil
+	}
+	return func(id int) bool {
+		for _, f := range filters {
+			if !f(e.docs[id]) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Delete removes the document with id from the vector and lexical indexes, the vector index must be a Deleter
+func (e *Engine) Delete(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	i, ok := e.ids[id]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrNotFound, id)
+	}
+	d, ok := e.index.(Deleter)
+	if !ok {
+		return fmt.Errorf("%T can't delete documents", e.index)
+	}
+	if i >= e.index.Len() || (e.lexical != nil && i >= e.lexical.Len()) {
+		return fmt.Errorf("document %q is missing from an index", id)
+	}
+	if err := d.Delete(i); err != nil {
+		return err
+	}
+	if e.lexical != nil {
+		e.lexical.Delete(i) // can't fail once i is checked, so both indexes delete the document or neither does
+	}
+	delete(e.ids, id)
+	e.docs[i] = Document{}
+	e.deleted++
+	return nil
+}
+
+// Get returns the document with id
+func (e *Engine) Get(id string) (Document, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	i, ok := e.ids[id]
+	if !ok {
+		return Document{}, false
+	}
+	return e.docs[i], true
+}
+
+// Has reports whether a document with id was added
+func (e *Engine) Has(id string) bool {
+	_, ok := e.Get(id)
+	return ok
+}
+
+// Len returns the number of documents in the engine
+func (e *Engine) Len() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.docs) - e.deleted
+}
+
+// Embed returns the embeddings of the texts of docs without adding them, batches are run by the workers
+func (e *Engine) Embed(ctx context.Context, docs ...Document) ([]model.Embedding, error) {
+	vecs := make([]model.Embedding, len(docs))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	starts := make(chan int)
+	errs := make(chan error, e.workers)
+	var wg sync.WaitGroup
+	for w := 0; w < e.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for from := range starts {
+				to := from + e.batch
+				if to > len(docs) {
+					to = len(docs)
+				}
+				texts := make([]string, to-from)
+				for i, d := range docs[from:to] {
+					texts[i] = d.Text
+				}
+				embs, err := model.Embed(ctx, e.m, e.pooling, texts...)
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+				copy(vecs[from:to], embs)
+			}
+		}()
+	}
+feed:
+	for from := 0; from < len(docs); from += e.batch {
+		select {
+		case starts <- from:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(starts)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return vecs, nil
+}
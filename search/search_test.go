@@ -0,0 +1,181 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+)
+
+// fakeModel embeds texts to the vector of their first letter, ex "b..." -> [0, 1, 0]
+func fakeModel() *modeltest.Predictor {
+	return modeltest.New(func(texts []string) interface{} {
+		vals := make([][][]float32, len(texts))
+		for i, text := range texts {
+			vals[i] = [][]float32{letters[text[0]]}
+		}
+		return vals
+	})
+}
+
+var letters = map[byte][]float32{
+	'a': {1, 0, 0},
+	'b': {0, 1, 0},
+	'c': {0, 0, 1},
+	'd': {1, 1, 0},
+}
+
+func TestSearch(t *testing.T) {
+	m := fakeModel()
+	e := New(m, WithBatchSize(2), WithWorkers(2))
+	if res, err := e.Search(context.Background(), "a", 3); err != nil || len(res) != 0 {
+		t.Fatalf("Invalid Empty Search - Want: [], Got: %v %v", res, err)
+	}
+	docs := []Document{
+		{ID: "1", Text: "a one", Metadata: map[string]string{"lang": "en"}},
+		{ID: "2", Text: "b two", Metadata: map[string]string{"lang": "fr"}},
+		{ID: "3", Text: "d three", Metadata: map[string]string{"lang": "en"}},
+		{Text: "c four"},
+		{ID: "5", Text: "a five", Metadata: map[string]string{"lang": "fr"}},
+	}
+	m.Reset()
+	if err := e.Add(context.Background(), docs...); err != nil {
+		t.Fatal(err)
+	}
+	if c := m.Calls(); c != 3 {
+		t.Errorf("Invalid Batch Count - Want: 3, Got: %d", c)
+	}
+	tests := []struct {
+		query   string
+		k       int
+		filters []Filter
+		ids     []string
+	}{
+		{"a", 3, nil, []string{"1", "5", "3"}},
+		{"b", 1, nil, []string{"2"}},
+		{"a", 10, []Filter{Match("lang", "en")}, []string{"1", "3"}},
+		{"c", 10, []Filter{Match("lang", "fr"), Match("lang", "fr")}, []string{"2", "5"}},
+		{"a", 0, []Filter{Match("lang", "de")}, []string{}},
+	}
+	for _, test := range tests {
+		res, err := e.Search(context.Background(), test.query, test.k, test.filters...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, r := range res {
+			ids = append(ids, r.ID)
+		}
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("Invalid Results for %q - Want: %v, Got: %v", test.query, test.ids, ids)
+		}
+	}
+	res, _ := e.Search(context.Background(), "d", 1)
+	if len(res) != 1 || res[0].Score < 0.999 || res[0].Metadata["lang"] != "en" {
+		t.Errorf("Invalid Result - Got: %+v", res)
+	}
+	if err := e.Add(context.Background(), Document{ID: "2", Text: "b"}); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("Invalid Duplicate Error - Want: %v, Got: %v", ErrDuplicateID, err)
+	}
+	if d, ok := e.Get("3"); !ok || d.Text != "d three" || e.Len() != 5 {
+		t.Errorf("Invalid Get - Got: %+v %d", d, e.Len())
+	}
+	if err := e.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	res, _ = e.Search(context.Background(), "a", 2)
+	if len(res) != 2 || res[0].ID != "5" || res[1].ID != "3" || e.Len() != 4 || e.Has("1") {
+		t.Errorf("Invalid Results After Delete - Got: %+v %d", res, e.Len())
+	}
+	if err := e.Delete("1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Invalid Delete Error - Want: %v, Got: %v", ErrNotFound, err)
+	}
+}
+
+func TestAddError(t *testing.T) {
+	fail := errors.New("fail")
+	m := fakeModel()
+	m.Err = fail
+	e := New(m, WithBatchSize(1), WithWorkers(3))
+	docs := []Document{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}}
+	if err := e.Add(context.Background(), docs...); err != fail {
+		t.Errorf("Invalid Error - Want: %v, Got: %v", fail, err)
+	}
+	if e.Len() != 0 {
+		t.Errorf("Invalid Len - Want: 0, Got: %d", e.Len())
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	e := New(fakeModel())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := e.Add(context.Background(), Document{ID: fmt.Sprint(i), Text: "abcd"[i%4:]}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := e.Search(context.Background(), "a", 2, Match("x", "")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if e.Len() != 8 {
+		t.Errorf("Invalid Len - Want: 8, Got: %d", e.Len())
+	}
+}
+
+func TestFlat(t *testing.T) {
+	f := NewFlat()
+	if err := f.Add(model.Embedding{1, 0}, model.Embedding{1}); err == nil || f.Len() != 0 {
+		t.Errorf("Invalid Mismatched Add - Want: error, Got: %v %d", err, f.Len())
+	}
+	f.Add(model.Embedding{2, 0}, model.Embedding{0, 3}, model.Embedding{1, 1})
+	hits, _ := f.Search(model.Embedding{1, 0}, 2, nil)
+	if len(hits) != 2 || hits[0].ID != 0 || hits[1].ID != 2 {
+		t.Errorf("Invalid Hits - Got: %v", hits)
+	}
+	if _, err := f.Search(model.Embedding{1, 0, 0}, 1, nil); err == nil {
+		t.Errorf("Invalid Query Dimension - Want: error")
+	}
+}
+
+// pairModel is a cross-encoder scoring a pair by the length of the passage
+func pairModel() *modeltest.Predictor {
+	return modeltest.New(func(texts []string) interface{} {
+		probs := make([][]float32, len(texts))
+		for i, text := range texts {
+			probs[i] = []float32{float32(len(text))}
+		}
+		return probs
+	})
+}
+
+func TestRerank(t *testing.T) {
+	res := []Result{
+		{Document: Document{ID: "1", Text: "a"}, Score: 0.9},
+		{Document: Document{ID: "2", Text: "abc"}, Score: 0.8},
+		{Document: Document{ID: "3", Text: "ab"}, Score: 0.7},
+	}
+	res, err := Rerank(context.Background(), model.NewReranker(pairModel()), "q", res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, r := range res {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"2", "3", "1"}; !reflect.DeepEqual(ids, want) || res[0].Score != float32(len("q ||| abc")) {
+		t.Errorf("Invalid Reranked Results - Want: %v, Got: %+v", want, res)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import "github.com/sunhailin-Leo/gobert/model"
+
+// ModelRef selects a registered model, an empty version uses the newest one
+type ModelRef struct {
+	Model   string `json:"model"`
+	Version string `json:"version,omitempty"`
+}
+
+// Limits are per-request constraints on the texts being encoded
+type Limits struct {
+	// MaxSeqLen rejects texts with more tokens than this, including [CLS] and [SEP].
+	// It can't exceed the seq len of the model. When unset, long texts are truncated to fit the model.
+	MaxSeqLen int32 `json:"max_seq_len,omitempty"`
+}
+
+// EmbedRequest is the body of /v1/embed
+type EmbedRequest struct {
+	ModelRef
+	Limits
+	Texts   []string      `json:"texts"`
+	Pooling model.Pooling `json:"pooling,omitempty"`
+}
+
+// EmbedResponse is returned from /v1/embed, embeddings are in the same order as the texts
+type EmbedResponse struct {
+	ModelRef
+	Embeddings []model.Embedding `json:"embeddings"`
+}
+
+// ClassifyRequest is the body of /v1/classify.
+// Either texts or pairs are classified, pairs are sentence pair tasks such as paraphrase detection.
+type ClassifyRequest struct {
+	ModelRef
+	Limits
+	Texts []string    `json:"texts,omitempty"`
+	Pairs [][2]string `json:"pairs,omitempty"`
+}
+
+// Classification is the prediction for a single text
+type Classification struct {
+	Label         string    `json:"label"`
+	Score         float32   `json:"score"`
+	Probabilities []float32 `json:"probabilities"`
+}
+
+// ClassifyResponse is returned from /v1/classify, results are in the same order as the inputs.
+// Probabilities of each result are ordered as the labels.
+type ClassifyResponse struct {
+	ModelRef
+	Labels  []string         `json:"labels"`
+	Results []Classification `json:"results"`
+}
+
+// TokenizeRequest is the body of /v1/tokenize
+type TokenizeRequest struct {
+	ModelRef
+	Texts []string `json:"texts"`
+}
+
+// Tokenization is the tokenized form of a single text, as it is fed to the model
+type Tokenization struct {
+	Tokens    []string `json:"tokens"`
+	IDs       []int32  `json:"ids"`
+	TypeIDs   []int32  `json:"type_ids"`
+	Truncated int      `json:"truncated"`
+}
+
+// TokenizeResponse is returned from /v1/tokenize
+type TokenizeResponse struct {
+	ModelRef
+	SeqLen  int32          `json:"seq_len"`
+	Results []Tokenization `json:"results"`
+}
+
+// ModelInfo describes a registered model in /v1/models
+type ModelInfo struct {
+	ModelRef
+	Type   model.ModelType `json:"type"`
+	SeqLen int32           `json:"seq_len"`
+	Labels []string        `json:"labels,omitempty"`
+}
+
+// ModelsResponse is returned from /v1/models
+type ModelsResponse struct {
+	Models []ModelInfo `json:"models"`
+}
+
+// ErrorResponse is returned with any non 2xx status
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
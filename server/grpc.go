@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sunhailin-Leo/gobert/inferencepb"
+	"github.com/sunhailin-Leo/gobert/model"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// RegisterGRPC registers the inference service of s on g, see inferencepb for the generated client
+func (s *Server) RegisterGRPC(g *grpc.Server) {
+	inferencepb.RegisterInferenceServer(g, grpcServer{s: s})
+}
+
+// GRPCServerOptions returns the options for a grpc.Server matching the configuration of s,
+// ex tracing requests when WithTracerProvider is set
+func (s *Server) GRPCServerOptions() []grpc.ServerOption {
+	if s.tp == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler(
+		otelgrpc.WithTracerProvider(s.tp),
+		otelgrpc.WithPropagators(propagator),
+	))}
+}
+
+// grpcServer adapts a Server to the generated service, converting to and from the JSON API types
+type grpcServer struct {
+	inferencepb.UnimplementedInferenceServer
+	s *Server
+}
+
+func (g grpcServer) ListModels(ctx context.Context, req *inferencepb.ListModelsRequest) (*inferencepb.ListModelsResponse, error) {
+	res := &inferencepb.ListModelsResponse{}
+	for _, m := range g.s.Models().Models {
+		res.Models = append(res.Models, &inferencepb.ModelInfo{
+			Ref:    pbRef(m.ModelRef),
+			Type:   string(m.Type),
+			SeqLen: m.SeqLen,
+			Labels: m.Labels,
+		})
+	}
+	return res, nil
+}
+
+func (g grpcServer) Embed(ctx context.Context, req *inferencepb.EmbedRequest) (*inferencepb.EmbedResponse, error) {
+	res, err := g.s.Embed(ctx, embedRequest(req))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return pbEmbedResponse(res), nil
+}
+
+func (g grpcServer) Classify(ctx context.Context, req *inferencepb.ClassifyRequest) (*inferencepb.ClassifyResponse, error) {
+	creq := ClassifyRequest{
+		ModelRef: ref(req.GetRef()),
+		Limits:   Limits{MaxSeqLen: req.GetLimits().GetMaxSeqLen()},
+		Texts:    req.GetTexts(),
+	}
+	for _, p := range req.GetPairs() {
+		creq.Pairs = append(creq.Pairs, [2]string{p.GetFirst(), p.GetSecond()})
+	}
+	res, err := g.s.Classify(ctx, creq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	pres := &inferencepb.ClassifyResponse{Ref: pbRef(res.ModelRef), Labels: res.Labels}
+	for _, c := range res.Results {
+		pres.Results = append(pres.Results, &inferencepb.Classification{
+			Label:         c.Label,
+			Score:         c.Score,
+			Probabilities: c.Probabilities,
+		})
+	}
+	return pres, nil
+}
+
+func (g grpcServer) Tokenize(ctx context.Context, req *inferencepb.TokenizeRequest) (*inferencepb.TokenizeResponse, error) {
+	res, err := g.s.Tokenize(ctx, TokenizeRequest{ModelRef: ref(req.GetRef()), Texts: req.GetTexts()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	pres := &inferencepb.TokenizeResponse{Ref: pbRef(res.ModelRef), SeqLen: res.SeqLen}
+	for _, t := range res.Results {
+		pres.Results = append(pres.Results, &inferencepb.Tokenization{
+			Tokens:    t.Tokens,
+			Ids:       t.IDs,
+			TypeIds:   t.TypeIDs,
+			Truncated: int32(t.Truncated),
+		})
+	}
+	return pres, nil
+}
+
+// EmbedStream embeds each request in the order received, the stream ends at the first failed request
+func (g grpcServer) EmbedStream(stream inferencepb.Inference_EmbedStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		res, err := g.s.Embed(stream.Context(), embedRequest(req.GetRequest()))
+		if err != nil {
+			return grpcError(err)
+		}
+		if err := stream.Send(&inferencepb.EmbedStreamResponse{Id: req.GetId(), Response: pbEmbedResponse(res)}); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcError maps an error to a status with the code matching its HTTP status
+func grpcError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return grpcstatus.Error(codes.Canceled, err.Error())
+	}
+	code := codes.Internal
+	switch status(err) {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return grpcstatus.Error(code, err.Error())
+}
+
+func embedRequest(req *inferencepb.EmbedRequest) EmbedRequest {
+	return EmbedRequest{
+		ModelRef: ref(req.GetRef()),
+		Limits:   Limits{MaxSeqLen: req.GetLimits().GetMaxSeqLen()},
+		Texts:    req.GetTexts(),
+		Pooling:  model.Pooling(req.GetPooling()),
+	}
+}
+
+func pbEmbedResponse(res EmbedResponse) *inferencepb.EmbedResponse {
+	pres := &inferencepb.EmbedResponse{Ref: pbRef(res.ModelRef)}
+	for _, emb := range res.Embeddings {
+		pres.Embeddings = append(pres.Embeddings, &inferencepb.Embedding{Values: emb})
+	}
+	return pres
+}
+
+func ref(r *inferencepb.ModelRef) ModelRef {
+	return ModelRef{Model: r.GetModel(), Version: r.GetVersion()}
+}
+
+func pbRef(r ModelRef) *inferencepb.ModelRef {
+	return &inferencepb.ModelRef{Model: r.Model, Version: r.Version}
+}
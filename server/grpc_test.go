@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/inferencepb"
+	"github.com/sunhailin-Leo/gobert/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, opts ...Option) inferencepb.InferenceClient {
+	t.Helper()
+	reg := model.NewRegistry()
+	reg.Register(model.Entry{ModelConfig: model.ModelConfig{Name: "emb", Type: model.EmbeddingModel}, Model: newFakeModel(model.EmbeddingModel)})
+	reg.Register(model.Entry{ModelConfig: model.ModelConfig{Name: "cls", Type: model.ClassifierModel}, Model: newFakeModel(model.ClassifierModel)})
+	lis := bufconn.Listen(1 << 20)
+	srv := New(reg, opts...)
+	g := grpc.NewServer(srv.GRPCServerOptions()...)
+	srv.RegisterGRPC(g)
+	go g.Serve(lis)
+	t.Cleanup(g.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return inferencepb.NewInferenceClient(conn)
+}
+
+func TestGRPCUnary(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	emb, err := c.Embed(ctx, &inferencepb.EmbedRequest{Ref: &inferencepb.ModelRef{Model: "emb"}, Texts: []string{"the dog"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := emb.GetEmbeddings()[0].GetValues(); !reflect.DeepEqual(got, []float32{2, 1}) {
+		t.Errorf("Invalid Embedding - Got: %v", got)
+	}
+	cls, err := c.Classify(ctx, &inferencepb.ClassifyRequest{Ref: &inferencepb.ModelRef{Model: "cls"}, Pairs: []*inferencepb.TextPair{{First: "dog", Second: "the"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cls.GetResults()[0]; got.GetLabel() != "1" || got.GetScore() != 0.8 {
+		t.Errorf("Invalid Classification - Got: %v", got)
+	}
+	tok, err := c.Tokenize(ctx, &inferencepb.TokenizeRequest{Ref: &inferencepb.ModelRef{Model: "emb"}, Texts: []string{"hairy dog"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tok.GetResults()[0].GetIds(); !reflect.DeepEqual(got, []int32{0, 6, 4, 1}) {
+		t.Errorf("Invalid Token IDs - Got: %v", got)
+	}
+	models, err := c.ListModels(ctx, &inferencepb.ListModelsRequest{})
+	if err != nil || len(models.GetModels()) != 2 {
+		t.Errorf("Invalid Models - Got: %v %v", models, err)
+	}
+	for _, test := range []struct {
+		req  *inferencepb.EmbedRequest
+		code codes.Code
+	}{
+		{&inferencepb.EmbedRequest{Ref: &inferencepb.ModelRef{Model: "nope"}, Texts: []string{"a"}}, codes.NotFound},
+		{&inferencepb.EmbedRequest{Ref: &inferencepb.ModelRef{Model: "emb"}}, codes.InvalidArgument},
+	} {
+		if _, err := c.Embed(ctx, test.req); grpcstatus.Code(err) != test.code {
+			t.Errorf("Invalid Error Code - Want: %s, Got: %v", test.code, err)
+		}
+	}
+}
+
+func TestGRPCEmbedStream(t *testing.T) {
+	c := newTestClient(t)
+	stream, err := c.EmbedStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches := [][]string{{"the", "dog"}, {"hairy"}, {"is", "the", "dog"}}
+	go func() {
+		for i, texts := range batches {
+			stream.Send(&inferencepb.EmbedStreamRequest{Id: uint64(i + 10), Request: &inferencepb.EmbedRequest{
+				Ref:     &inferencepb.ModelRef{Model: "emb"},
+				Texts:   texts,
+				Pooling: string(model.MaxPooling),
+			}})
+		}
+		stream.CloseSend()
+	}()
+	var n int
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if res.GetId() != uint64(n+10) || len(res.GetResponse().GetEmbeddings()) != len(batches[n]) {
+			t.Errorf("Invalid Stream Response %d - Got: %v", n, res)
+		}
+		n++
+	}
+	if n != len(batches) {
+		t.Errorf("Invalid Response Count - Want: %d, Got: %d", len(batches), n)
+	}
+}
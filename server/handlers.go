@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+func (s *Server) models(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return s.Models(), nil
+}
+
+func (s *Server) embed(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var req EmbedRequest
+	if err := s.decode(w, r, &req); err != nil {
+		return nil, err
+	}
+	return s.Embed(r.Context(), req)
+}
+
+func (s *Server) classify(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var req ClassifyRequest
+	if err := s.decode(w, r, &req); err != nil {
+		return nil, err
+	}
+	return s.Classify(r.Context(), req)
+}
+
+func (s *Server) tokenize(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var req TokenizeRequest
+	if err := s.decode(w, r, &req); err != nil {
+		return nil, err
+	}
+	return s.Tokenize(r.Context(), req)
+}
+
+// Models lists the registered models
+func (s *Server) Models() ModelsResponse {
+	res := ModelsResponse{Models: []ModelInfo{}}
+	for _, e := range s.reg.Entries() {
+		res.Models = append(res.Models, ModelInfo{
+			ModelRef: ModelRef{Model: e.Name, Version: e.Version},
+			Type:     e.Type,
+			SeqLen:   e.SeqLen,
+			Labels:   e.Labels,
+		})
+	}
+	return res
+}
+
+// Embed returns pooled embeddings of the texts, it is shared by the HTTP and gRPC APIs
+func (s *Server) Embed(ctx context.Context, req EmbedRequest) (EmbedResponse, error) {
+	e, err := s.entry(req.ModelRef, model.EmbeddingModel)
+	if err != nil {
+		return EmbedResponse{}, err
+	}
+	if req.Pooling == "" {
+		req.Pooling = model.MeanPooling
+	}
+	fs, err := s.features(e, req.Limits, req.Texts)
+	if err != nil {
+		return EmbedResponse{}, err
+	}
+	vals, err := model.PredictFeatures(ctx, e.Model, fs)
+	if err != nil {
+		return EmbedResponse{}, err
+	}
+	if len(vals) == 0 {
+		return EmbedResponse{}, fmt.Errorf("model %s returned no outputs", e.Name)
+	}
+	embs, err := model.Embeddings(vals[0], fs, req.Pooling)
+	if err != nil {
+		return EmbedResponse{}, errorf(http.StatusBadRequest, "%s", err)
+	}
+	return EmbedResponse{ModelRef: ModelRef{Model: e.Name, Version: e.Version}, Embeddings: embs}, nil
+}
+
+// Classify returns label probabilities of texts or pairs, it is shared by the HTTP and gRPC APIs
+func (s *Server) Classify(ctx context.Context, req ClassifyRequest) (ClassifyResponse, error) {
+	e, err := s.entry(req.ModelRef, model.ClassifierModel)
+	if err != nil {
+		return ClassifyResponse{}, err
+	}
+	texts := req.Texts
+	if len(req.Pairs) > 0 {
+		if len(texts) > 0 {
+			return ClassifyResponse{}, errorf(http.StatusBadRequest, "texts and pairs can't be combined")
+		}
+		for i, p := range req.Pairs {
+			text, err := tokenize.JoinPair(p[0], p[1])
+			if err != nil {
+				return ClassifyResponse{}, errorf(http.StatusBadRequest, "pair %d: %s", i, err)
+			}
+			texts = append(texts, text)
+		}
+	}
+	fs, err := s.features(e, req.Limits, texts)
+	if err != nil {
+		return ClassifyResponse{}, err
+	}
+	vals, err := model.PredictFeatures(ctx, e.Model, fs)
+	if err != nil {
+		return ClassifyResponse{}, err
+	}
+	if len(vals) == 0 {
+		return ClassifyResponse{}, fmt.Errorf("model %s returned no outputs", e.Name)
+	}
+	probs, ok := vals[0].Value().([][]float32)
+	if !ok {
+		return ClassifyResponse{}, fmt.Errorf("expected probabilities [][]float32, got %T", vals[0].Value())
+	}
+	ls, err := labels(e, probs)
+	if err != nil {
+		return ClassifyResponse{}, err
+	}
+	res := ClassifyResponse{
+		ModelRef: ModelRef{Model: e.Name, Version: e.Version},
+		Labels:   ls,
+		Results:  make([]Classification, len(probs)),
+	}
+	for i, ps := range probs {
+		c := Classification{Probabilities: ps}
+		for j, p := range ps {
+			if j == 0 || p > c.Score {
+				c.Label, c.Score = res.Labels[j], p
+			}
+		}
+		res.Results[i] = c
+	}
+	return res, nil
+}
+
+// Tokenize returns texts as they are fed to the model, it is shared by the HTTP and gRPC APIs
+func (s *Server) Tokenize(ctx context.Context, req TokenizeRequest) (TokenizeResponse, error) {
+	e, err := s.entry(req.ModelRef, "")
+	if err != nil {
+		return TokenizeResponse{}, err
+	}
+	fs, err := s.features(e, Limits{}, req.Texts)
+	if err != nil {
+		return TokenizeResponse{}, err
+	}
+	res := TokenizeResponse{
+		ModelRef: ModelRef{Model: e.Name, Version: e.Version},
+		SeqLen:   e.SeqLen,
+		Results:  make([]Tokenization, len(fs)),
+	}
+	for i, f := range fs {
+		n := f.Count()
+		res.Results[i] = Tokenization{
+			Tokens:    f.Tokens[:n],
+			IDs:       f.TokenIDs[:n],
+			TypeIDs:   f.TypeIDs[:n],
+			Truncated: f.Truncated,
+		}
+	}
+	return res, nil
+}
+
+// entry looks up the requested model, checking it is of type typ if set
+func (s *Server) entry(ref ModelRef, typ model.ModelType) (*model.Entry, error) {
+	e, err := s.reg.Get(ref.Model, ref.Version)
+	if err != nil {
+		return nil, errorf(http.StatusNotFound, "%s", err)
+	}
+	if typ != "" && e.Type != typ {
+		return nil, errorf(http.StatusBadRequest, "model %s is a %s model, not %s", e.Name, e.Type, typ)
+	}
+	return e, nil
+}
+
+// features tokenizes texts, rejecting requests with too many texts or texts over the limits
+func (s *Server) features(e *model.Entry, l Limits, texts []string) ([]tokenize.Feature, error) {
+	if len(texts) == 0 {
+		return nil, errorf(http.StatusBadRequest, "no texts in request")
+	}
+	if s.maxTexts > 0 && len(texts) > s.maxTexts {
+		return nil, errorf(http.StatusRequestEntityTooLarge, "%d texts in request, the limit is %d", len(texts), s.maxTexts)
+	}
+	if l.MaxSeqLen > e.SeqLen {
+		return nil, errorf(http.StatusBadRequest, "max_seq_len %d exceeds the model seq len %d", l.MaxSeqLen, e.SeqLen)
+	}
+	fs := e.Model.Features(texts...)
+	if l.MaxSeqLen <= 0 {
+		return fs, nil
+	}
+	for i, f := range fs {
+		if n := f.Count() + f.Truncated; n > int(l.MaxSeqLen) {
+			return nil, errorf(http.StatusBadRequest, "text %d has %d tokens, max_seq_len is %d", i, n, l.MaxSeqLen)
+		}
+	}
+	return fs, nil
+}
+
+// labels returns the configured labels of a classifier, or the output indexes when there are none
+func labels(e *model.Entry, probs [][]float32) ([]string, error) {
+	if len(probs) == 0 {
+		return e.Labels, nil
+	}
+	n := len(probs[0])
+	if len(e.Labels) > 0 && len(e.Labels) != n {
+		return nil, fmt.Errorf("model %s has %d labels configured for %d outputs", e.Name, len(e.Labels), n)
+	}
+	if len(e.Labels) > 0 {
+		return e.Labels, nil
+	}
+	ls := make([]string, n)
+	for i := range ls {
+		ls[i] = strconv.Itoa(i)
+	}
+	return ls, nil
+}
@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+// KServe v2 inference protocol, see https://kserve.github.io/website/latest/modelserving/data_plane/v2_protocol/
+//
+//	GET  /v2
+//	GET  /v2/health/live
+//	GET  /v2/health/ready
+//	GET  /v2/models/{name}[/versions/{version}]
+//	GET  /v2/models/{name}[/versions/{version}]/ready
+//	POST /v2/models/{name}[/versions/{version}]/infer
+//
+// Inputs are BYTES tensors of raw texts named "text" and optionally "text_pair", tokenized on the server.
+// Outputs are the unprocessed model outputs.
+
+// ServerMetadataResponse is returned from GET /v2
+type ServerMetadataResponse struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	Extensions []string `json:"extensions"`
+}
+
+// ModelMetadataResponse is returned from GET /v2/models/{name}
+type ModelMetadataResponse struct {
+	Name     string           `json:"name"`
+	Versions []string         `json:"versions,omitempty"`
+	Platform string           `json:"platform"`
+	Inputs   []TensorMetadata `json:"inputs"`
+	Outputs  []TensorMetadata `json:"outputs"`
+}
+
+// TensorMetadata describes an input or output, -1 is a variable dimension
+type TensorMetadata struct {
+	Name     string  `json:"name"`
+	Datatype string  `json:"datatype"`
+	Shape    []int64 `json:"shape"`
+}
+
+// InferTensor is an input or output tensor, data is in row-major order and may be nested
+type InferTensor struct {
+	Name     string      `json:"name"`
+	Shape    []int64     `json:"shape"`
+	Datatype string      `json:"datatype"`
+	Data     interface{} `json:"data"`
+}
+
+// InferRequest is the body of /infer, outputs selects the returned outputs by name when set
+type InferRequest struct {
+	ID      string        `json:"id,omitempty"`
+	Inputs  []InferTensor `json:"inputs"`
+	Outputs []struct {
+		Name string `json:"name"`
+	} `json:"outputs,omitempty"`
+}
+
+// InferResponse is returned from /infer
+type InferResponse struct {
+	ModelName    string        `json:"model_name"`
+	ModelVersion string        `json:"model_version,omitempty"`
+	ID           string        `json:"id,omitempty"`
+	Outputs      []InferTensor `json:"outputs"`
+}
+
+// kserve routes requests below /v2
+func (s *Server) kserve(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/v2"), "/")
+	var fn handlerFunc
+	method := http.MethodGet
+	switch p {
+	case "":
+		fn = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return ServerMetadataResponse{Name: "gobert", Extensions: []string{}}, nil
+		}
+	case "health/live":
+		fn = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return map[string]bool{"live": true}, nil
+		}
+	case "health/ready":
+		fn = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			if !s.isReady() {
+				return nil, errorf(http.StatusServiceUnavailable, "not ready")
+			}
+			return map[string]bool{"ready": true}, nil
+		}
+	default:
+		mp := strings.TrimPrefix(p, "models/")
+		if mp == p {
+			http.NotFound(w, r)
+			return
+		}
+		ref, rest := modelPath(mp)
+		switch rest {
+		case "":
+			fn = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				return s.modelMetadata(ref)
+			}
+		case "ready":
+			fn = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				e, err := s.entry(ref, "")
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"name": e.Name, "ready": true}, nil
+			}
+		case "infer":
+			method = http.MethodPost
+			fn = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				var req InferRequest
+				if err := s.decode(w, r, &req); err != nil {
+					return nil, err
+				}
+				return s.Infer(r.Context(), ref, req)
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+	}
+	if r.Method != method {
+		fn = methodNotAllowed(method)
+	}
+	s.serve(w, r, fn)
+}
+
+// modelMetadata describes the inputs and outputs of a model, versions lists all of them when none is requested
+func (s *Server) modelMetadata(ref ModelRef) (ModelMetadataResponse, error) {
+	e, err := s.entry(ref, "")
+	if err != nil {
+		return ModelMetadataResponse{}, err
+	}
+	res := ModelMetadataResponse{
+		Name:     e.Name,
+		Platform: "tensorflow_savedmodel",
+		Inputs: []TensorMetadata{
+			{Name: TextInput, Datatype: "BYTES", Shape: []int64{-1}},
+			{Name: TextPairInput, Datatype: "BYTES", Shape: []int64{-1}},
+		},
+	}
+	if ref.Version != "" {
+		res.Versions = []string{e.Version}
+	} else {
+		for _, o := range s.reg.Entries() {
+			if o.Name == e.Name && o.Version != "" {
+				res.Versions = append(res.Versions, o.Version)
+			}
+		}
+	}
+	out := TensorMetadata{Name: outputName(e, 0), Datatype: "FP32", Shape: []int64{-1, int64(e.SeqLen), -1}}
+	if e.Type == model.ClassifierModel {
+		out.Shape = []int64{-1, -1}
+		if len(e.Labels) > 0 {
+			out.Shape[1] = int64(len(e.Labels))
+		}
+	}
+	res.Outputs = []TensorMetadata{out}
+	return res, nil
+}
+
+// Infer runs the text inputs through the model and returns its outputs as KServe v2 tensors
+func (s *Server) Infer(ctx context.Context, ref ModelRef, req InferRequest) (InferResponse, error) {
+	e, err := s.entry(ref, "")
+	if err != nil {
+		return InferResponse{}, err
+	}
+	var texts, pairs []string
+	for _, in := range req.Inputs {
+		vals, err := inputStrings(in)
+		if err != nil {
+			return InferResponse{}, err
+		}
+		switch in.Name {
+		case TextInput:
+			texts = vals
+		case TextPairInput:
+			pairs = vals
+		default:
+			return InferResponse{}, errorf(http.StatusBadRequest, "unknown input %q, expected %q or %q", in.Name, TextInput, TextPairInput)
+		}
+	}
+	if texts, err = pairTexts(texts, pairs); err != nil {
+		return InferResponse{}, err
+	}
+	vals, err := s.predict(ctx, e, texts)
+	if err != nil {
+		return InferResponse{}, err
+	}
+	outs := make(map[string]InferTensor, len(vals))
+	var names []string
+	for i, v := range vals {
+		t, err := flatten(v.Value())
+		if err != nil {
+			return InferResponse{}, err
+		}
+		name := outputName(e, i)
+		names = append(names, name)
+		outs[name] = InferTensor{Name: name, Shape: t.shape, Datatype: t.datatype, Data: t.data}
+	}
+	if len(req.Outputs) > 0 {
+		names = names[:0]
+		for _, o := range req.Outputs {
+			if _, ok := outs[o.Name]; !ok {
+				return InferResponse{}, errorf(http.StatusBadRequest, "unknown output %q", o.Name)
+			}
+			names = append(names, o.Name)
+		}
+	}
+	res := InferResponse{ModelName: e.Name, ModelVersion: e.Version, ID: req.ID}
+	for _, n := range names {
+		res.Outputs = append(res.Outputs, outs[n])
+	}
+	return res, nil
+}
+
+// inputStrings returns the values of a BYTES tensor, checking they match its shape
+func inputStrings(in InferTensor) ([]string, error) {
+	if in.Datatype != "BYTES" {
+		return nil, errorf(http.StatusBadRequest, "input %q has datatype %s, expected BYTES", in.Name, in.Datatype)
+	}
+	var vals []string
+	if err := appendStrings(&vals, in.Data); err != nil {
+		return nil, errorf(http.StatusBadRequest, "input %q: %s", in.Name, err)
+	}
+	n := int64(1)
+	for _, d := range in.Shape {
+		n *= d
+	}
+	if n != int64(len(vals)) {
+		return nil, errorf(http.StatusBadRequest, "input %q has %d values for shape %v", in.Name, len(vals), in.Shape)
+	}
+	return vals, nil
+}
+
+// appendStrings flattens decoded JSON data of strings
+func appendStrings(dst *[]string, data interface{}) error {
+	switch d := data.(type) {
+	case string:
+		*dst = append(*dst, d)
+	case []interface{}:
+		for _, v := range d {
+			if err := appendStrings(dst, v); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("expected string data, got %T", d)
+	}
+	return nil
+}
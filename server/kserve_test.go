@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKServeInfer(t *testing.T) {
+	ts := newTestServer(t)
+	req := InferRequest{ID: "42", Inputs: []InferTensor{
+		{Name: TextInput, Shape: []int64{2}, Datatype: "BYTES", Data: []string{"dog", "the"}},
+		{Name: TextPairInput, Shape: []int64{2, 1}, Datatype: "BYTES", Data: [][]string{{"the"}, {"dog"}}},
+	}}
+	var res struct {
+		ModelName string `json:"model_name"`
+		ID        string `json:"id"`
+		Outputs   []struct {
+			Name     string    `json:"name"`
+			Shape    []int64   `json:"shape"`
+			Datatype string    `json:"datatype"`
+			Data     []float32 `json:"data"`
+		} `json:"outputs"`
+	}
+	if code := post(t, ts.URL+"/v2/models/cls/infer", req, &res); code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	if res.ModelName != "cls" || res.ID != "42" || len(res.Outputs) != 1 {
+		t.Fatalf("Invalid Response - Got: %+v", res)
+	}
+	out := res.Outputs[0]
+	if out.Name != "probabilities" || out.Datatype != "FP32" || !reflect.DeepEqual(out.Shape, []int64{2, 2}) ||
+		!reflect.DeepEqual(out.Data, []float32{0.2, 0.8, 0.9, 0.1}) {
+		t.Errorf("Invalid Output - Got: %+v", out)
+	}
+}
+
+func TestKServeEndpoints(t *testing.T) {
+	ts := newTestServer(t)
+	for _, test := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/v2", http.StatusOK, `"name":"gobert"`},
+		{"/v2/health/live", http.StatusOK, `"live":true`},
+		{"/v2/health/ready", http.StatusOK, `"ready":true`},
+		{"/v2/models/emb", http.StatusOK, `"versions":["1"]`},
+		{"/v2/models/emb/versions/1/ready", http.StatusOK, `"ready":true`},
+		{"/v2/models/emb/versions/2/ready", http.StatusNotFound, "version"},
+		{"/v2/models/emb/infer", http.StatusMethodNotAllowed, "not allowed"},
+		{"/v2/other", http.StatusNotFound, ""},
+	} {
+		resp, err := http.Get(ts.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body json.RawMessage
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != test.code || !strings.Contains(string(body), test.body) {
+			t.Errorf("Invalid Response for %s - Want: %d %s, Got: %d %s", test.path, test.code, test.body, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestKServeErrors(t *testing.T) {
+	ts := newTestServer(t)
+	for _, test := range []struct {
+		in  InferTensor
+		err string
+	}{
+		{InferTensor{Name: TextInput, Shape: []int64{1}, Datatype: "FP32", Data: []float32{1}}, "expected BYTES"},
+		{InferTensor{Name: TextInput, Shape: []int64{2}, Datatype: "BYTES", Data: []string{"a"}}, "1 values for shape [2]"},
+		{InferTensor{Name: TextInput, Shape: []int64{1}, Datatype: "BYTES", Data: []int{1}}, "expected string"},
+		{InferTensor{Name: "input_ids", Shape: []int64{1}, Datatype: "BYTES", Data: []string{"a"}}, "unknown input"},
+	} {
+		var res ErrorResponse
+		if code := post(t, ts.URL+"/v2/models/emb/infer", InferRequest{Inputs: []InferTensor{test.in}}, &res); code != http.StatusBadRequest || !strings.Contains(res.Error, test.err) {
+			t.Errorf("Invalid Error for %+v - Want: 400 %q, Got: %d %q", test.in, test.err, code, res.Error)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package server exposes the models of a model.Registry over HTTP with JSON requests and responses
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Default limits
+const (
+	DefaultMaxTexts     = 64
+	DefaultMaxBodyBytes = 4 << 20
+)
+
+// Server is an http.Handler serving inference requests for registered models
+type Server struct {
+	reg          *model.Registry
+	mux          *http.ServeMux
+	handler      http.Handler
+	tp           trace.TracerProvider
+	maxTexts     int
+	maxBodyBytes int64
+	logger       *slog.Logger
+	ready        int32
+}
+
+// Option configures a Server
+type Option func(s *Server) *Server
+
+// WithMaxTexts limits the number of texts in a single request
+func WithMaxTexts(n int) Option {
+	return func(s *Server) *Server {
+		s.maxTexts = n
+		return s
+	}
+}
+
+// WithMaxBodyBytes limits the size of request bodies
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *Server) *Server {
+		s.maxBodyBytes = n
+		return s
+	}
+}
+
+// WithLogger logs requests failing with a server error
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) *Server {
+		s.logger = l
+		return s
+	}
+}
+
+// WithTracerProvider traces requests with tp, continuing traces propagated with W3C trace context headers
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Server) *Server {
+		s.tp = tp
+		return s
+	}
+}
+
+// New returns a server for the models in reg, it reports ready until SetReady(false) is called
+func New(reg *model.Registry, opts ...Option) *Server {
+	s := &Server{
+		reg:          reg,
+		mux:          http.NewServeMux(),
+		maxTexts:     DefaultMaxTexts,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		ready:        1,
+	}
+	for _, opt := range opts {
+		s = opt(s)
+	}
+	s.mux.HandleFunc("/healthz", s.health)
+	s.mux.HandleFunc("/readyz", s.readiness)
+	s.mux.Handle("/v1/models", s.handle(http.MethodGet, s.models))
+	s.mux.Handle("/v1/embed", s.handle(http.MethodPost, s.embed))
+	s.mux.Handle("/v1/classify", s.handle(http.MethodPost, s.classify))
+	s.mux.Handle("/v1/tokenize", s.handle(http.MethodPost, s.tokenize))
+	s.mux.HandleFunc("/v1/models/", s.tfServing)
+	s.mux.HandleFunc("/v2/", s.kserve)
+	s.mux.HandleFunc("/v2", s.kserve)
+	s.handler = s.mux
+	if s.tp != nil {
+		s.handler = otelhttp.NewHandler(s.mux, "gobert",
+			otelhttp.WithTracerProvider(s.tp),
+			otelhttp.WithPropagators(propagator),
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return r.Method + " " + r.URL.Path
+			}),
+		)
+	}
+	return s
+}
+
+// propagator extracts the trace context and baggage of incoming requests
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// ServeHTTP routes requests to the API handlers
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// SetReady toggles the readiness endpoint, ex set to false before a graceful shutdown to drain traffic
+func (s *Server) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&s.ready, v)
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// isReady is true when models are registered and the server isn't shutting down
+func (s *Server) isReady() bool {
+	return atomic.LoadInt32(&s.ready) == 1 && len(s.reg.Entries()) > 0
+}
+
+func (s *Server) readiness(w http.ResponseWriter, r *http.Request) {
+	if !s.isReady() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// handlerFunc returns a value to be encoded as the JSON response body
+type handlerFunc func(w http.ResponseWriter, r *http.Request) (interface{}, error)
+
+// handle adapts fn to an http.Handler accepting only method, errors are written as an ErrorResponse
+func (s *Server) handle(method string, fn handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			s.serve(w, r, methodNotAllowed(method))
+			return
+		}
+		s.serve(w, r, fn)
+	})
+}
+
+// serve writes the result of fn as JSON, or an ErrorResponse if it fails.
+// Server errors are logged, client errors are only reported to the client.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, fn handlerFunc) {
+	res, err := fn(w, r)
+	if err != nil {
+		if code := status(err); code >= http.StatusInternalServerError && s.logger != nil {
+			s.logger.ErrorContext(r.Context(), "request failed",
+				slog.String("path", r.URL.Path), slog.Int("status", code), slog.Any("error", err))
+		}
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// methodNotAllowed is a handlerFunc rejecting requests that don't use method
+func methodNotAllowed(method string) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		w.Header().Set("Allow", method)
+		return nil, errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+}
+
+// decode reads a JSON request body into v
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes)).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %s", err)
+	}
+	return nil
+}
+
+// httpError is an error with the status code it should be reported with
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e httpError) Error() string {
+	return e.msg
+}
+
+func errorf(code int, format string, args ...interface{}) error {
+	return httpError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// StatusClientClosedRequest is reported when the client went away before its response, as nginx does.
+// It isn't logged as a server error.
+const StatusClientClosedRequest = 499
+
+// status maps an error to an HTTP status code
+func status(err error) int {
+	var he httpError
+	switch {
+	case errors.As(err, &he):
+		return he.code
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, model.ErrClosed), errors.Is(err, model.ErrQueueFull), errors.Is(err, model.ErrSaturated):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, status(err), ErrorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
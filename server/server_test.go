@@ -0,0 +1,231 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+	"github.com/sunhailin-Leo/gobert/tokenize/vocab"
+	"github.com/valyala/bytebufferpool"
+)
+
+// fakeModel stands in for a BERT model, its outputs are derived from token ids
+type fakeModel struct {
+	*modeltest.Predictor
+	ff        *tokenize.FeatureFactory
+	typ       model.ModelType
+	tokenized int32 // texts tokenized by Features
+}
+
+// newFakeModel predicts token vectors of [id, 1] for embeddings,
+// and probabilities favoring the second label when the second token is "dog" for classifiers
+func newFakeModel(typ model.ModelType) *fakeModel {
+	voc := vocab.New([]string{"[CLS]", "[SEP]", "[UNK]", "the", "dog", "is", "hairy", "."})
+	m := &fakeModel{
+		ff:  &tokenize.FeatureFactory{Tokenizer: tokenize.NewTokenizer(voc, bytebufferpool.Get()), SeqLen: 6},
+		typ: typ,
+	}
+	m.Predictor = modeltest.New(func(texts []string) interface{} {
+		return m.outputs(m.Features(texts...))
+	})
+	m.FeatureFunc = func(texts ...string) []tokenize.Feature {
+		atomic.AddInt32(&m.tokenized, int32(len(texts)))
+		return m.ff.Features(texts...)
+	}
+	return m
+}
+
+func (m *fakeModel) SeqLen() int32 {
+	return m.ff.SeqLen
+}
+
+// PredictFeaturesContext predicts texts tokenized by Features without tokenizing them again
+func (m *fakeModel) PredictFeaturesContext(ctx context.Context, fs ...tokenize.Feature) ([]model.ValueProvider, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []model.ValueProvider{modeltest.Value{V: m.outputs(fs)}}, nil
+}
+
+func (m *fakeModel) outputs(fs []tokenize.Feature) interface{} {
+	if m.typ == model.ClassifierModel {
+		probs := make([][]float32, len(fs))
+		for i, f := range fs {
+			probs[i] = []float32{0.9, 0.1}
+			if f.Tokens[1] == "dog" {
+				probs[i] = []float32{0.2, 0.8}
+			}
+		}
+		return probs
+	}
+	vals := make([][][]float32, len(fs))
+	for i, f := range fs {
+		vals[i] = make([][]float32, len(f.TokenIDs))
+		for j, id := range f.TokenIDs {
+			vals[i][j] = []float32{float32(id), 1}
+		}
+	}
+	return vals
+}
+
+func newTestServer(t *testing.T, opts ...Option) *httptest.Server {
+	t.Helper()
+	reg := model.NewRegistry()
+	for _, e := range []model.Entry{
+		{ModelConfig: model.ModelConfig{Name: "emb", Version: "1", Type: model.EmbeddingModel}, Model: newFakeModel(model.EmbeddingModel)},
+		{ModelConfig: model.ModelConfig{Name: "cls", Type: model.ClassifierModel, Labels: []string{"other", "dog"}}, Model: newFakeModel(model.ClassifierModel)},
+	} {
+		if err := reg.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(New(reg, append([]Option{WithMaxTexts(3)}, opts...)...))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func post(t *testing.T, url string, req interface{}, res interface{}) int {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestEmbed(t *testing.T) {
+	ts := newTestServer(t)
+	var res EmbedResponse
+	code := post(t, ts.URL+"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}, Texts: []string{"the dog", "hairy"}}, &res)
+	if code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	// mean of [CLS]=0, the=3, dog=4, [SEP]=1 and [CLS]=0, hairy=6, [SEP]=1
+	want := []model.Embedding{{2, 1}, {7.0 / 3, 1}}
+	if res.Version != "1" || !reflect.DeepEqual(res.Embeddings, want) {
+		t.Errorf("Invalid Embeddings - Want: %v, Got: %+v", want, res)
+	}
+	code = post(t, ts.URL+"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}, Texts: []string{"the dog"}, Pooling: model.CLSPooling}, &res)
+	if code != http.StatusOK || !reflect.DeepEqual(res.Embeddings, []model.Embedding{{0, 1}}) {
+		t.Errorf("Invalid CLS Embeddings - Got: %d %+v", code, res)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	ts := newTestServer(t)
+	var res ClassifyResponse
+	req := ClassifyRequest{ModelRef: ModelRef{Model: "cls"}, Pairs: [][2]string{{"dog", "the dog"}, {"the", "dog"}}}
+	if code := post(t, ts.URL+"/v1/classify", req, &res); code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	if len(res.Results) != 2 || res.Results[0].Label != "dog" || res.Results[1].Label != "other" || res.Results[0].Score != 0.8 {
+		t.Errorf("Invalid Classification - Got: %+v", res)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	ts := newTestServer(t)
+	var res TokenizeResponse
+	if code := post(t, ts.URL+"/v1/tokenize", TokenizeRequest{ModelRef: ModelRef{Model: "emb"}, Texts: []string{"the dog is hairy."}}, &res); code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	want := Tokenization{
+		Tokens:    []string{"[CLS]", "the", "dog", "is", "hairy", "[SEP]"},
+		IDs:       []int32{0, 3, 4, 5, 6, 1},
+		TypeIDs:   []int32{0, 0, 0, 0, 0, 0},
+		Truncated: 1,
+	}
+	if res.SeqLen != 6 || !reflect.DeepEqual(res.Results, []Tokenization{want}) {
+		t.Errorf("Invalid Tokenization - Want: %+v, Got: %+v", want, res)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts := newTestServer(t)
+	for _, test := range []struct {
+		path string
+		req  interface{}
+		code int
+		err  string
+	}{
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "nope"}, Texts: []string{"a"}}, http.StatusNotFound, "not found"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb", Version: "2"}, Texts: []string{"a"}}, http.StatusNotFound, "version"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "cls"}, Texts: []string{"a"}}, http.StatusBadRequest, "classifier"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}}, http.StatusBadRequest, "no texts"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}, Texts: []string{"a", "b", "c", "d"}}, http.StatusRequestEntityTooLarge, "limit"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}, Texts: []string{"a"}, Pooling: "median"}, http.StatusBadRequest, "pooling"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}, Limits: Limits{MaxSeqLen: 7}, Texts: []string{"a"}}, http.StatusBadRequest, "exceeds"},
+		{"/v1/embed", EmbedRequest{ModelRef: ModelRef{Model: "emb"}, Limits: Limits{MaxSeqLen: 4}, Texts: []string{"the dog", "the dog is"}}, http.StatusBadRequest, "text 1 has 5 tokens"},
+		{"/v1/classify", ClassifyRequest{ModelRef: ModelRef{Model: "cls"}, Texts: []string{"a"}, Pairs: [][2]string{{"a", "b"}}}, http.StatusBadRequest, "combined"},
+		{"/v1/classify", ClassifyRequest{ModelRef: ModelRef{Model: "cls"}, Pairs: [][2]string{{"a", "b ||| c"}}}, http.StatusBadRequest, "sequence separator"},
+		{"/v1/tokenize", "texts", http.StatusBadRequest, "invalid request body"},
+	} {
+		var res ErrorResponse
+		if code := post(t, ts.URL+test.path, test.req, &res); code != test.code || !strings.Contains(res.Error, test.err) {
+			t.Errorf("Invalid Error for %s %+v - Want: %d %q, Got: %d %q", test.path, test.req, test.code, test.err, code, res.Error)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	reg := model.NewRegistry()
+	srv := New(reg)
+	for _, test := range []struct {
+		path  string
+		setup func()
+		code  int
+	}{
+		{"/healthz", func() {}, http.StatusOK},
+		{"/readyz", func() {}, http.StatusServiceUnavailable}, // no models
+		{"/readyz", func() {
+			reg.Register(model.Entry{ModelConfig: model.ModelConfig{Name: "emb"}, Model: newFakeModel(model.EmbeddingModel)})
+		}, http.StatusOK},
+		{"/readyz", func() { srv.SetReady(false) }, http.StatusServiceUnavailable},
+		{"/v1/models", func() {}, http.StatusOK},
+	} {
+		test.setup()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("Invalid Status for %s - Want: %d, Got: %d", test.path, test.code, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/embed", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Invalid Status for GET /v1/embed - Want: 405, Got: %d", w.Code)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want int
+	}{
+		{context.Canceled, StatusClientClosedRequest},
+		{fmt.Errorf("predict: %w", context.Canceled), StatusClientClosedRequest},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{model.ErrQueueFull, http.StatusServiceUnavailable},
+		{errorf(http.StatusBadRequest, "bad"), http.StatusBadRequest},
+		{fmt.Errorf("boom"), http.StatusInternalServerError},
+	} {
+		if got := status(test.err); got != test.want {
+			t.Errorf("Invalid Status for %v - Want: %v, Got: %v", test.err, test.want, got)
+		}
+	}
+}
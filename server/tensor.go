@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// Input names of raw text tensors in the TF Serving and KServe APIs
+const (
+	TextInput     = "text"
+	TextPairInput = "text_pair"
+)
+
+// predict runs raw texts through the model, returning its outputs unprocessed
+func (s *Server) predict(ctx context.Context, e *model.Entry, texts []string) ([]model.ValueProvider, error) {
+	fs, err := s.features(e, Limits{}, texts)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := model.PredictFeatures(ctx, e.Model, fs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("model %s returned no outputs", e.Name)
+	}
+	return vals, nil
+}
+
+// pairTexts joins texts with their pairs so they are tokenized as sentence pairs, pairs may be empty
+func pairTexts(texts, pairs []string) ([]string, error) {
+	if len(pairs) == 0 {
+		return texts, nil
+	}
+	if len(pairs) != len(texts) {
+		return nil, errorf(http.StatusBadRequest, "%d %s values for %d %s values", len(pairs), TextPairInput, len(texts), TextInput)
+	}
+	joined := make([]string, len(texts))
+	for i := range texts {
+		var err error
+		if joined[i], err = tokenize.JoinPair(texts[i], pairs[i]); err != nil {
+			return nil, errorf(http.StatusBadRequest, "pair %d: %s", i, err)
+		}
+	}
+	return joined, nil
+}
+
+// outputName names the i-th output of a model after the graph operation it is read from
+func outputName(e *model.Entry, i int) string {
+	if i == 0 {
+		switch e.Type {
+		case model.EmbeddingModel:
+			return model.EmbeddingOp
+		case model.ClassifierModel:
+			return model.ClassifierOutputOp
+		}
+	}
+	return fmt.Sprintf("output_%d", i)
+}
+
+// rows splits a model output along its batch dimension
+func rows(v interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("expected a batch of outputs, got %T", v)
+	}
+	rs := make([]interface{}, rv.Len())
+	for i := range rs {
+		rs[i] = rv.Index(i).Interface()
+	}
+	return rs, nil
+}
+
+// datatypes maps Go kinds to KServe tensor datatypes
+var datatypes = map[reflect.Kind]string{
+	reflect.Bool:    "BOOL",
+	reflect.Uint8:   "UINT8",
+	reflect.Int32:   "INT32",
+	reflect.Int64:   "INT64",
+	reflect.Float32: "FP32",
+	reflect.Float64: "FP64",
+	reflect.String:  "BYTES",
+}
+
+// flatTensor is a tensor value in row-major order
+type flatTensor struct {
+	data     []interface{}
+	shape    []int64
+	datatype string
+}
+
+// flatten converts a nested slice such as [][]float32 to a flatTensor
+func flatten(v interface{}) (flatTensor, error) {
+	var t flatTensor
+	if err := t.add(reflect.ValueOf(v), 0); err != nil {
+		return flatTensor{}, fmt.Errorf("can't convert %T to a tensor: %w", v, err)
+	}
+	return t, nil
+}
+
+func (t *flatTensor) add(rv reflect.Value, depth int) error {
+	if rv.Kind() == reflect.Slice {
+		if depth == len(t.shape) {
+			t.shape = append(t.shape, int64(rv.Len()))
+		} else if t.shape[depth] != int64(rv.Len()) {
+			return fmt.Errorf("ragged dimension %d", depth)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := t.add(rv.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	dt, ok := datatypes[rv.Kind()]
+	if !ok {
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+	if depth != len(t.shape) {
+		return fmt.Errorf("ragged dimension %d", depth)
+	}
+	t.datatype = dt
+	t.data = append(t.data, rv.Interface())
+	return nil
+}
+
+// modelPath parses "{name}[/versions/{version}]" and returns what follows it
+func modelPath(p string) (ModelRef, string) {
+	var ref ModelRef
+	var rest string
+	ref.Model, rest, _ = strings.Cut(p, "/")
+	if v := strings.TrimPrefix(rest, "versions/"); v != rest {
+		ref.Version, rest, _ = strings.Cut(v, "/")
+	}
+	return ref, rest
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sunhailin-Leo/gobert/tokenize"
+)
+
+// TF Serving REST API, see https://www.tensorflow.org/tfx/serving/api_rest
+//
+//	GET  /v1/models/{name}[/versions/{version}]
+//	POST /v1/models/{name}[/versions/{version}]:predict
+//
+// Instances are raw texts, either strings or objects with a "text" and optional "text_pair".
+// They are tokenized on the server and predictions are the unprocessed model outputs.
+
+// PredictRequest is the body of :predict, in the row format (instances) or the columnar format (inputs)
+type PredictRequest struct {
+	SignatureName string            `json:"signature_name,omitempty"`
+	Instances     []json.RawMessage `json:"instances,omitempty"`
+	Inputs        json.RawMessage   `json:"inputs,omitempty"`
+}
+
+// PredictResponse is returned from :predict, predictions for instances or outputs for inputs
+type PredictResponse struct {
+	Predictions []interface{} `json:"predictions,omitempty"`
+	Outputs     interface{}   `json:"outputs,omitempty"`
+}
+
+// ModelStatusResponse is returned from GET /v1/models/{name}
+type ModelStatusResponse struct {
+	ModelVersionStatus []ModelVersionStatus `json:"model_version_status"`
+}
+
+// ModelVersionStatus is the state of a single model version, models are AVAILABLE once registered
+type ModelVersionStatus struct {
+	Version string `json:"version"`
+	State   string `json:"state"`
+	Status  struct {
+		ErrorCode    string `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+}
+
+// textInstance is an instance naming its inputs
+type textInstance struct {
+	Text     *string `json:"text"`
+	TextPair *string `json:"text_pair"`
+}
+
+// tfServing routes requests below /v1/models/
+func (s *Server) tfServing(w http.ResponseWriter, r *http.Request) {
+	p, verb, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/models/"), ":")
+	ref, rest := modelPath(p)
+	switch {
+	case ref.Model == "" || rest != "":
+		http.NotFound(w, r)
+	case verb == "" && r.Method != http.MethodGet:
+		s.serve(w, r, methodNotAllowed(http.MethodGet))
+	case verb == "":
+		s.serve(w, r, func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return s.modelStatus(ref)
+		})
+	case verb != "predict":
+		s.serve(w, r, func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return nil, errorf(http.StatusNotFound, "unsupported method :%s, only :predict is served", verb)
+		})
+	case r.Method != http.MethodPost:
+		s.serve(w, r, methodNotAllowed(http.MethodPost))
+	default:
+		s.serve(w, r, func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			var req PredictRequest
+			if err := s.decode(w, r, &req); err != nil {
+				return nil, err
+			}
+			return s.tfPredict(r.Context(), ref, req)
+		})
+	}
+}
+
+// modelStatus lists the versions of a model, or only the requested one
+func (s *Server) modelStatus(ref ModelRef) (ModelStatusResponse, error) {
+	res := ModelStatusResponse{ModelVersionStatus: []ModelVersionStatus{}}
+	for _, e := range s.reg.Entries() {
+		if e.Name != ref.Model || (ref.Version != "" && e.Version != ref.Version) {
+			continue
+		}
+		st := ModelVersionStatus{Version: e.Version, State: "AVAILABLE"}
+		st.Status.ErrorCode = "OK"
+		res.ModelVersionStatus = append(res.ModelVersionStatus, st)
+	}
+	if len(res.ModelVersionStatus) == 0 {
+		if _, err := s.reg.Get(ref.Model, ref.Version); err != nil {
+			return res, errorf(http.StatusNotFound, "%s", err)
+		}
+	}
+	return res, nil
+}
+
+// tfPredict predicts instances in the row format or inputs in the columnar format
+func (s *Server) tfPredict(ctx context.Context, ref ModelRef, req PredictRequest) (PredictResponse, error) {
+	e, err := s.entry(ref, "")
+	if err != nil {
+		return PredictResponse{}, err
+	}
+	columnar := len(req.Inputs) > 0
+	if columnar == (len(req.Instances) > 0) {
+		return PredictResponse{}, errorf(http.StatusBadRequest, "exactly one of instances or inputs is required")
+	}
+	var texts []string
+	if columnar {
+		texts, err = columnTexts(req.Inputs)
+	} else {
+		texts, err = instanceTexts(req.Instances)
+	}
+	if err != nil {
+		return PredictResponse{}, err
+	}
+	vals, err := s.predict(ctx, e, texts)
+	if err != nil {
+		return PredictResponse{}, err
+	}
+	if columnar {
+		if len(vals) == 1 {
+			return PredictResponse{Outputs: vals[0].Value()}, nil
+		}
+		outs := make(map[string]interface{}, len(vals))
+		for i, v := range vals {
+			outs[outputName(e, i)] = v.Value()
+		}
+		return PredictResponse{Outputs: outs}, nil
+	}
+	res := PredictResponse{Predictions: make([]interface{}, len(texts))}
+	for i, v := range vals {
+		rs, err := rows(v.Value())
+		if err != nil {
+			return PredictResponse{}, err
+		}
+		if len(rs) != len(texts) {
+			return PredictResponse{}, fmt.Errorf("model %s returned %d rows for %d instances", e.Name, len(rs), len(texts))
+		}
+		for j := range res.Predictions {
+			if len(vals) == 1 {
+				res.Predictions[j] = rs[j]
+				continue
+			}
+			if res.Predictions[j] == nil {
+				res.Predictions[j] = make(map[string]interface{}, len(vals))
+			}
+			res.Predictions[j].(map[string]interface{})[outputName(e, i)] = rs[j]
+		}
+	}
+	return res, nil
+}
+
+// instanceTexts reads texts from instances that are strings or textInstance objects
+func instanceTexts(instances []json.RawMessage) ([]string, error) {
+	texts := make([]string, len(instances))
+	for i, raw := range instances {
+		if err := json.Unmarshal(raw, &texts[i]); err == nil {
+			continue
+		}
+		var inst textInstance
+		if err := json.Unmarshal(raw, &inst); err != nil || inst.Text == nil {
+			return nil, errorf(http.StatusBadRequest, "instance %d is not a string or an object with %q", i, TextInput)
+		}
+		texts[i] = *inst.Text
+		if inst.TextPair != nil {
+			var err error
+			if texts[i], err = tokenize.JoinPair(*inst.Text, *inst.TextPair); err != nil {
+				return nil, errorf(http.StatusBadRequest, "instance %d: %s", i, err)
+			}
+		}
+	}
+	return texts, nil
+}
+
+// columnTexts reads texts from inputs that are a list of strings or an object of named lists
+func columnTexts(inputs json.RawMessage) ([]string, error) {
+	var texts []string
+	if err := json.Unmarshal(inputs, &texts); err == nil {
+		return texts, nil
+	}
+	var named map[string][]string
+	if err := json.Unmarshal(inputs, &named); err != nil {
+		return nil, errorf(http.StatusBadRequest, "inputs are not a list of strings or an object with %q", TextInput)
+	}
+	for k := range named {
+		if k != TextInput && k != TextPairInput {
+			return nil, errorf(http.StatusBadRequest, "unknown input %q", k)
+		}
+	}
+	return pairTexts(named[TextInput], named[TextPairInput])
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+)
+
+func TestTFServingPredict(t *testing.T) {
+	ts := newTestServer(t)
+	var res struct {
+		Predictions [][]float32 `json:"predictions"`
+	}
+	req := map[string]interface{}{"instances": []interface{}{"dog", map[string]string{"text": "the", "text_pair": "dog"}}}
+	if code := post(t, ts.URL+"/v1/models/cls:predict", req, &res); code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	if want := [][]float32{{0.2, 0.8}, {0.9, 0.1}}; !reflect.DeepEqual(res.Predictions, want) {
+		t.Errorf("Invalid Predictions - Want: %v, Got: %v", want, res.Predictions)
+	}
+	var cres struct {
+		Outputs [][][]float32 `json:"outputs"`
+	}
+	req = map[string]interface{}{"inputs": map[string][]string{"text": {"the dog"}}}
+	if code := post(t, ts.URL+"/v1/models/emb/versions/1:predict", req, &cres); code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	if want := [][][]float32{{{0, 1}, {3, 1}, {4, 1}, {1, 1}, {0, 1}, {0, 1}}}; !reflect.DeepEqual(cres.Outputs, want) {
+		t.Errorf("Invalid Outputs - Want: %v, Got: %v", want, cres.Outputs)
+	}
+}
+
+func TestTFServingStatus(t *testing.T) {
+	ts := newTestServer(t)
+	resp, err := http.Get(ts.URL + "/v1/models/emb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res ModelStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ModelVersionStatus) != 1 || res.ModelVersionStatus[0].Version != "1" || res.ModelVersionStatus[0].State != "AVAILABLE" {
+		t.Errorf("Invalid Status - Got: %+v", res)
+	}
+}
+
+func TestTFServingErrors(t *testing.T) {
+	ts := newTestServer(t)
+	for _, test := range []struct {
+		path string
+		req  interface{}
+		code int
+		err  string
+	}{
+		{"/v1/models/nope:predict", map[string]interface{}{"instances": []string{"a"}}, http.StatusNotFound, "not found"},
+		{"/v1/models/emb:classify", map[string]interface{}{"instances": []string{"a"}}, http.StatusNotFound, "unsupported"},
+		{"/v1/models/emb:predict", map[string]interface{}{}, http.StatusBadRequest, "exactly one"},
+		{"/v1/models/emb:predict", map[string]interface{}{"instances": []int{1}}, http.StatusBadRequest, "instance 0"},
+		{"/v1/models/emb:predict", map[string]interface{}{"inputs": map[string][]string{"ids": {"a"}}}, http.StatusBadRequest, "unknown input"},
+		{"/v1/models/cls:predict", map[string]interface{}{"inputs": map[string][]string{"text": {"a"}, "text_pair": {"a", "b"}}}, http.StatusBadRequest, "text_pair"},
+		{"/v1/models/cls:predict", map[string]interface{}{"inputs": map[string][]string{"text": {"a ||| b"}, "text_pair": {"c"}}}, http.StatusBadRequest, "sequence separator"},
+		{"/v1/models/cls:predict", map[string]interface{}{"instances": []map[string]string{{"text": "a", "text_pair": "b ||| c"}}}, http.StatusBadRequest, "sequence separator"},
+		{"/v1/models/emb:predict", map[string]interface{}{"instances": []string{"a", "b", "c", "d"}}, http.StatusRequestEntityTooLarge, "limit"},
+	} {
+		var res ErrorResponse
+		if code := post(t, ts.URL+test.path, test.req, &res); code != test.code || !strings.Contains(res.Error, test.err) {
+			t.Errorf("Invalid Error for %s %+v - Want: %d %q, Got: %d %q", test.path, test.req, test.code, test.err, code, res.Error)
+		}
+	}
+}
+
+func TestTFServingTokenizesOnce(t *testing.T) {
+	m := newFakeModel(model.ClassifierModel)
+	reg := model.NewRegistry()
+	if err := reg.Register(model.Entry{ModelConfig: model.ModelConfig{Name: "cls", Type: model.ClassifierModel}, Model: m}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(New(reg))
+	defer ts.Close()
+	var res struct {
+		Predictions [][]float32 `json:"predictions"`
+	}
+	req := map[string]interface{}{"instances": []string{"the dog", "dog"}}
+	if code := post(t, ts.URL+"/v1/models/cls:predict", req, &res); code != http.StatusOK {
+		t.Fatalf("Invalid Status - Want: 200, Got: %d", code)
+	}
+	if n := atomic.LoadInt32(&m.tokenized); n != 2 {
+		t.Errorf("Invalid Tokenized Texts - Want: %v, Got: %v", 2, n)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/inferencepb"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestTracePropagation(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	ts := newTestServer(t, WithTracerProvider(tp))
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/embed", bytes.NewBufferString(`{"model": "emb", "texts": ["the dog"]}`))
+	req.Header.Set("traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Invalid Span Count - Want: 1, Got: %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "POST /v1/embed" || s.SpanContext.TraceID().String() != testTraceID || s.Parent.SpanID().String() != testSpanID || !s.Parent.IsRemote() {
+		t.Errorf("Invalid Server Span - Got: %s %s parent %s", s.Name, s.SpanContext.TraceID(), s.Parent.SpanID())
+	}
+}
+
+func TestGRPCTracePropagation(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	c := newTestClient(t, WithTracerProvider(tp))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+	if _, err := c.Embed(ctx, &inferencepb.EmbedRequest{Ref: &inferencepb.ModelRef{Model: "emb"}, Texts: []string{"the dog"}}); err != nil {
+		t.Fatal(err)
+	}
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Invalid Span Count - Want: 1, Got: %d", len(spans))
+	}
+	if s := spans[0]; s.SpanContext.TraceID().String() != testTraceID || s.Parent.SpanID().String() != testSpanID {
+		t.Errorf("Invalid Server Span - Got: %s %s parent %s", s.Name, s.SpanContext.TraceID(), s.Parent.SpanID())
+	}
+}
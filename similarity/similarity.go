@@ -0,0 +1,286 @@
+// Package similarity compares embeddings of a corpus to each other: the similarity matrix of all pairs,
+// the most similar pairs (paraphrase mining) and near-duplicates. Similarities are cosine similarities,
+// computed by a blocked matrix multiply of the normalized embeddings on concurrent workers.
+package similarity
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// Defaults of the comparisons
+const (
+	DefaultBlockSize = 64
+	DefaultChunkSize = 512
+)
+
+// config holds the parameters of a comparison
+type config struct {
+	block   int
+	chunk   int
+	workers int
+}
+
+// Option configures a comparison
+type Option func(c *config) *config
+
+// WithBlockSize sets the number of vectors of the tiles of the matrix multiply, they should fit in the CPU cache
+func WithBlockSize(n int) Option {
+	return func(c *config) *config {
+		c.block = n
+		return c
+	}
+}
+
+// WithChunkSize sets the number of rows compared at once, MinePairs and NearDuplicates hold the chunk×chunk
+// scores of a chunk of rows and one of columns per worker
+func WithChunkSize(n int) Option {
+	return func(c *config) *config {
+		c.chunk = n
+		return c
+	}
+}
+
+// WithWorkers sets the number of chunks compared concurrently, GOMAXPROCS by default
+func WithWorkers(n int) Option {
+	return func(c *config) *config {
+		c.workers = n
+		return c
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{block: DefaultBlockSize, chunk: DefaultChunkSize, workers: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		c = opt(c)
+	}
+	if c.block <= 0 {
+		c.block = DefaultBlockSize
+	}
+	if c.chunk <= 0 {
+		c.chunk = DefaultChunkSize
+	}
+	if c.workers <= 0 {
+		c.workers = 1
+	}
+	return c
+}
+
+// Pair is two embeddings of a corpus, I < J are their indexes, and their cosine similarity
+type Pair struct {
+	I, J  int
+	Score float32
+}
+
+// Matrix returns the N×N cosine similarities of embs, row i holds the similarities of embs[i]
+func Matrix(embs []model.Embedding, opts ...Option) ([][]float32, error) {
+	vecs, err := Normalize(embs)
+	if err != nil {
+		return nil, err
+	}
+	c := newConfig(opts)
+	n := len(vecs)
+	flat := make([]float32, n*n)
+	c.each(n, func(from, to int) {
+		multiply(flat[from*n:to*n], vecs[from:to], vecs, c.block)
+	})
+	m := make([][]float32, n)
+	for i := range m {
+		m[i] = flat[i*n : (i+1)*n : (i+1)*n]
+	}
+	return m, nil
+}
+
+// MinePairs returns the k most similar pairs of embs by decreasing score, ex to mine paraphrases of a corpus.
+// Memory is bounded by the chunk size, the N×N matrix is never held.
+func MinePairs(embs []model.Embedding, k int, opts ...Option) ([]Pair, error) {
+	vecs, err := Normalize(embs)
+	if err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	n := len(vecs)
+	var mu sync.Mutex
+	top := search.NewTopK(k)
+	newConfig(opts).pairs(vecs, func() (func(i, j int, score float32), func()) {
+		local := search.NewTopK(k)
+		visit := func(i, j int, score float32) {
+			local.Push(search.Hit{ID: i*n + j, Score: score})
+		}
+		return visit, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, h := range local.Sorted() {
+				top.Push(h)
+			}
+		}
+	})
+	hits := top.Sorted()
+	pairs := make([]Pair, len(hits))
+	for p, h := range hits {
+		pairs[p] = Pair{I: h.ID / n, J: h.ID % n, Score: h.Score}
+	}
+	return pairs, nil
+}
+
+// NearDuplicates returns every pair of embs with a similarity of at least threshold, by decreasing score
+func NearDuplicates(embs []model.Embedding, threshold float32, opts ...Option) ([]Pair, error) {
+	vecs, err := Normalize(embs)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mu    sync.Mutex
+		pairs []Pair
+	)
+	newConfig(opts).pairs(vecs, func() (func(i, j int, score float32), func()) {
+		var local []Pair
+		visit := func(i, j int, score float32) {
+			if score >= threshold {
+				local = append(local, Pair{I: i, J: j, Score: score})
+			}
+		}
+		return visit, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			pairs = append(pairs, local...)
+		}
+	})
+	sort.Slice(pairs, func(a, b int) bool {
+		pa, pb := pairs[a], pairs[b]
+		if pa.Score != pb.Score {
+			return pa.Score > pb.Score
+		}
+		return pa.I < pb.I || (pa.I == pb.I && pa.J < pb.J)
+	})
+	return pairs, nil
+}
+
+// Groups returns the groups of the n embeddings connected by pairs, ex near-duplicates, as sorted indexes.
+// Groups are sorted by their first index, embeddings in no pair are left out.
+func Groups(n int, pairs []Pair) [][]int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, p := range pairs {
+		a, b := find(p.I), find(p.J)
+		if a > b {
+			a, b = b, a
+		}
+		parent[b] = a
+	}
+	index := map[int]int{}
+	var groups [][]int
+	for i := range parent {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	dups := groups[:0]
+	for _, g := range groups {
+		if len(g) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	return dups
+}
+
+// pairs visits the pairs i < j of vecs by chunks of rows, chunk returns the visit function of a chunk
+// and a flush function called once the chunk is visited. A chunk of rows is compared to the chunks of
+// vectors from its first row on, a worker holds the scores of a chunk of rows and one of columns.
+func (c *config) pairs(vecs []model.Embedding, chunk func() (visit func(i, j int, score float32), flush func())) {
+	n := len(vecs)
+	size := min(c.chunk, n)
+	buffers := make(chan []float32, c.workers)
+	c.each(n, func(from, to int) {
+		var buf []float32
+		select {
+		case buf = <-buffers:
+		default:
+			buf = make([]float32, size*size)
+		}
+		defer func() { buffers <- buf }()
+		visit, flush := chunk()
+		for col := from; col < n; col += size {
+			cols := vecs[col:min(col+size, n)]
+			scores := buf[:(to-from)*len(cols)]
+			multiply(scores, vecs[from:to], cols, c.block)
+			for i := from; i < to; i++ {
+				row := scores[(i-from)*len(cols) : (i-from+1)*len(cols)]
+				for j := max(i+1, col); j < col+len(cols); j++ {
+					visit(i, j, row[j-col])
+				}
+			}
+		}
+		flush()
+	})
+}
+
+// each runs fn on the chunks of n rows with the workers
+func (c *config) each(n int, fn func(from, to int)) {
+	starts := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for from := range starts {
+				fn(from, min(from+c.chunk, n))
+			}
+		}()
+	}
+	for from := 0; from < n; from += c.chunk {
+		starts <- from
+	}
+	close(starts)
+	wg.Wait()
+}
+
+// multiply sets out[i*len(cols)+j] to the dot product of rows[i] and cols[j].
+// Both are tiled by bs vectors so a tile of cols stays in cache while the rows of a tile are compared to it.
+func multiply(out []float32, rows, cols []model.Embedding, bs int) {
+	for j0 := 0; j0 < len(cols); j0 += bs {
+		j1 := min(j0+bs, len(cols))
+		for i0 := 0; i0 < len(rows); i0 += bs {
+			i1 := min(i0+bs, len(rows))
+			for i := i0; i < i1; i++ {
+				row := out[i*len(cols) : (i+1)*len(cols)]
+				for j := j0; j < j1; j++ {
+					row[j] = search.Dot(rows[i], cols[j])
+				}
+			}
+		}
+	}
+}
+
+// Normalize returns unit length copies of embs, they must all have the same dimension
+func Normalize(embs []model.Embedding) ([]model.Embedding, error) {
+	vecs := make([]model.Embedding, len(embs))
+	for i, e := range embs {
+		if len(e) == 0 || len(e) != len(embs[0]) {
+			return nil, fmt.Errorf("mismatched embedding dimension %d, expected %d", len(e), len(embs[0]))
+		}
+		vecs[i] = search.Normalize(e)
+	}
+	return vecs, nil
+}
@@ -0,0 +1,101 @@
+package similarity
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sunhailin-Leo/gobert/model"
+	"github.com/sunhailin-Leo/gobert/model/modeltest"
+	"github.com/sunhailin-Leo/gobert/search"
+)
+
+// allPairs returns every pair of embs sorted by decreasing score
+func allPairs(embs []model.Embedding) []Pair {
+	var pairs []Pair
+	for i := range embs {
+		for j := i + 1; j < len(embs); j++ {
+			pairs = append(pairs, Pair{I: i, J: j, Score: search.Dot(search.Normalize(embs[i]), search.Normalize(embs[j]))})
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].Score > pairs[b].Score })
+	return pairs
+}
+
+func TestMatrix(t *testing.T) {
+	embs := modeltest.Embeddings(1, 50, 12, 0, 0)
+	m, err := Matrix(embs, WithBlockSize(7), WithChunkSize(9), WithWorkers(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != len(embs) {
+		t.Fatalf("Invalid Matrix Size - Want: %d, Got: %d", len(embs), len(m))
+	}
+	for i := range embs {
+		for j := range embs {
+			want := search.Dot(search.Normalize(embs[i]), search.Normalize(embs[j]))
+			if d := m[i][j] - want; d < -1e-5 || d > 1e-5 {
+				t.Fatalf("Invalid Similarity of %d, %d - Want: %v, Got: %v", i, j, want, m[i][j])
+			}
+		}
+	}
+	if _, err := Matrix([]model.Embedding{{1, 0}, {1}}); err == nil {
+		t.Errorf("Invalid Mismatched Dimensions - Want: error")
+	}
+	if m, err := Matrix(nil); err != nil || len(m) != 0 {
+		t.Errorf("Invalid Empty Matrix - Want: [], Got: %v %v", m, err)
+	}
+}
+
+func TestMinePairs(t *testing.T) {
+	embs := modeltest.Embeddings(2, 103, 8, 0, 0)
+	want := allPairs(embs)[:20]
+	for _, opts := range [][]Option{nil, {WithBlockSize(5), WithChunkSize(10), WithWorkers(4)}, {WithChunkSize(1), WithWorkers(1)}} {
+		pairs, err := MinePairs(embs, 20, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pairs) != len(want) {
+			t.Fatalf("Invalid Pair Count - Want: %d, Got: %d", len(want), len(pairs))
+		}
+		for p := range want {
+			if pairs[p].I != want[p].I || pairs[p].J != want[p].J {
+				t.Errorf("Invalid Pair %d - Want: %+v, Got: %+v", p, want[p], pairs[p])
+			}
+		}
+	}
+	if pairs, _ := MinePairs(embs[:3], 10); len(pairs) != 3 {
+		t.Errorf("Invalid Pair Count - Want: 3, Got: %d", len(pairs))
+	}
+}
+
+func TestNearDuplicates(t *testing.T) {
+	embs := []model.Embedding{{1, 0, 0}, {0, 1, 0}, {2, 0.01, 0}, {0, 0, 1}, {0, 0.99, 0.01}, {1, 0.02, 0}}
+	pairs, err := NearDuplicates(embs, 0.99, WithChunkSize(2), WithWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]int
+	for _, p := range pairs {
+		got = append(got, [2]int{p.I, p.J})
+		if p.Score < 0.99 {
+			t.Errorf("Invalid Score Under Threshold - Got: %+v", p)
+		}
+	}
+	if want := [][2]int{{0, 2}, {1, 4}, {2, 5}, {0, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid Near Duplicates - Want: %v, Got: %v", want, got)
+	}
+	if want := [][]int{{0, 2, 5}, {1, 4}}; !reflect.DeepEqual(Groups(len(embs), pairs), want) {
+		t.Errorf("Invalid Groups - Want: %v, Got: %v", want, Groups(len(embs), pairs))
+	}
+}
+
+func BenchmarkMinePairs(b *testing.B) {
+	embs := modeltest.Embeddings(3, 1000, 768, 0, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MinePairs(embs, 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package tokenize
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"sync"
 )
@@ -12,6 +14,17 @@ const (
 	SequenceSeparator = " ||| "
 )
 
+// JoinPair joins the sentences of a pair into a text tokenized as the pair.
+// It fails if a sentence contains SequenceSeparator, which would split it in more sentences.
+func JoinPair(a, b string) (string, error) {
+	for _, s := range []string{a, b} {
+		if strings.Contains(s, SequenceSeparator) {
+			return "", fmt.Errorf("text %q contains the sequence separator %q", s, SequenceSeparator)
+		}
+	}
+	return a + SequenceSeparator + b, nil
+}
+
 // Feature is an input feature for a BERT model.
 // Maps to extract_features.InputFeature in ref-impl
 type Feature struct {
@@ -21,6 +34,8 @@ type Feature struct {
 	TokenIDs []int32
 	Mask     []int32 // short?
 	TypeIDs  []int32 // sequence ids, short?
+	// Truncated is the number of tokens dropped to fit the sequence length
+	Truncated int
 }
 
 // Count will return the number of tokens in the feature by counting the mask bits
@@ -62,6 +77,19 @@ func (ff *FeatureFactory) Features(texts ...string) []Feature {
 	return fs
 }
 
+// FeaturesContext will create multiple features with incremental IDs,
+// stopping early with ctx.Err() if ctx is done before all texts are tokenized
+func (ff *FeatureFactory) FeaturesContext(ctx context.Context, texts ...string) ([]Feature, error) {
+	fs := make([]Feature, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fs[i] = ff.Feature(text)
+	}
+	return fs, nil
+}
+
 // SequenceFeature will take a sequence string and
 // build features for the model from it
 func sequenceFeature(tkz VocabTokenizer, seqLen int32, text string) Feature {
@@ -77,7 +105,9 @@ func sequenceFeature(tkz VocabTokenizer, seqLen int32, text string) Feature {
 	for i, part := range parts {
 		seqs[i] = tkz.Tokenize(part)
 	}
+	total := tokenCount(seqs)
 	seqs = truncate(seqs, seqLen-int32(len(seqs))-1) // truncate w/ space for CLS/SEP
+	f.Truncated = total - tokenCount(seqs)
 	voc := tkz.Vocab()
 	var s int
 	f.Tokens[s] = ClassToken
@@ -102,6 +132,15 @@ func sequenceFeature(tkz VocabTokenizer, seqLen int32, text string) Feature {
 	return f
 }
 
+// tokenCount is the number of tokens in all seqs
+func tokenCount(seqs [][]string) int {
+	var n int
+	for _, seq := range seqs {
+		n += len(seq)
+	}
+	return n
+}
+
 // truncate uses heuristic of trimming seq with longest len until seqlen satisfied
 func truncate(seqs [][]string, maxlen int32) [][]string {
 	// TODO test
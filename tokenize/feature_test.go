@@ -1,6 +1,8 @@
 package tokenize
 
 import (
+	"context"
+
 	"github.com/valyala/bytebufferpool"
 	"reflect"
 	"testing"
@@ -28,6 +30,34 @@ func TestFeatureCount(t *testing.T) {
 	}
 }
 
+func TestFeaturesContext(t *testing.T) {
+	voc := vocab.New([]string{"[CLS]", "[SEP]", "the", "dog", "is", "hairy", "."})
+	ff := FeatureFactory{Tokenizer: NewTokenizer(voc, bytebufferpool.Get()), SeqLen: 7}
+	fs, err := ff.FeaturesContext(context.Background(), "the dog", "is hairy")
+	if err != nil {
+		t.Fatalf("Unexpected Error - %s", err)
+	}
+	if len(fs) != 2 || fs[0].ID != 0 || fs[1].ID != 1 {
+		t.Errorf("Invalid Features - Got: %+v", fs)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ff.FeaturesContext(ctx, "the dog"); err != context.Canceled {
+		t.Errorf("Invalid Cancel Error - Want: %v, Got: %v", context.Canceled, err)
+	}
+}
+
+func TestJoinPair(t *testing.T) {
+	if text, err := JoinPair("the dog", "is hairy"); err != nil || text != "the dog ||| is hairy" {
+		t.Errorf("Invalid Pair - Want: %q, Got: %q %v", "the dog ||| is hairy", text, err)
+	}
+	for _, pair := range [][2]string{{"a ||| b", "c"}, {"a", "b ||| c"}} {
+		if _, err := JoinPair(pair[0], pair[1]); err == nil {
+			t.Errorf("Invalid Error for %q - Want: an error, Got: %v", pair, err)
+		}
+	}
+}
+
 func Test_sequenceFeature(t *testing.T) {
 	voc := vocab.New([]string{"[CLS]", "[SEP]", "the", "dog", "is", "hairy", "."})
 	tkz := NewTokenizer(voc, bytebufferpool.Get())
@@ -37,12 +67,13 @@ func Test_sequenceFeature(t *testing.T) {
 	}{
 		// TODO more tests, but this one covers some good edge cases
 		{"the dog is hairy. ||| the ||| a dog is hairy", Feature{
-			ID:       0,
-			Text:     "the dog is hairy. ||| the ||| a dog is hairy",
-			Tokens:   []string{"[CLS]", "the", "dog", "[SEP]", "the", "[SEP]", "[UNK]", "[SEP]"},
-			TokenIDs: []int32{0, 2, 3, 1, 2, 1, -1, 1},
-			Mask:     []int32{1, 1, 1, 1, 1, 1, 1, 1},
-			TypeIDs:  []int32{0, 0, 0, 0, 1, 1, 2, 2},
+			ID:        0,
+			Text:      "the dog is hairy. ||| the ||| a dog is hairy",
+			Tokens:    []string{"[CLS]", "the", "dog", "[SEP]", "the", "[SEP]", "[UNK]", "[SEP]"},
+			TokenIDs:  []int32{0, 2, 3, 1, 2, 1, -1, 1},
+			Mask:      []int32{1, 1, 1, 1, 1, 1, 1, 1},
+			TypeIDs:   []int32{0, 0, 0, 0, 1, 1, 2, 2},
+			Truncated: 6,
 		}},
 	} {
 		f := sequenceFeature(tkz, 8, test.text)